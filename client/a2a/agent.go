@@ -0,0 +1,74 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+
+	einoA2A "github.com/cloudwego/eino-ext/a2a/extension/eino"
+	"github.com/cloudwego/eino/adk"
+)
+
+// agentOption holds configuration options for the wrapped remote agent
+type agentOption struct {
+	Name        *string // Agent name, from the agent card by default
+	Description *string // Agent description, from the agent card by default
+	Streaming   *bool   // Whether to call message/stream, from the agent card capabilities by default
+}
+
+// AgentOptionFn is a function type for configuring agent options using the functional options pattern
+type AgentOptionFn func(*agentOption)
+
+// WithAgentName overrides the agent name taken from the agent card
+func WithAgentName(name string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.Name = &name
+	}
+}
+
+// WithAgentDescription overrides the agent description taken from the agent card
+func WithAgentDescription(description string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.Description = &description
+	}
+}
+
+// WithStreaming sets whether the agent calls message/stream or message/send,
+// by default it streams if the agent card declares the streaming capability.
+func WithStreaming(streaming bool) AgentOptionFn {
+	return func(o *agentOption) {
+		o.Streaming = &streaming
+	}
+}
+
+// NewAgent wraps the remote agent as a local adk.Agent.
+// The agent can be used as a sub-agent of a local agent, or as a tool through adk.NewAgentTool.
+// If the remote agent asks for input, the local agent is interrupted, and resuming it continues the remote task.
+func (c *Client) NewAgent(ctx context.Context, opts ...AgentOptionFn) (adk.Agent, error) {
+	o := &agentOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.Name == nil || o.Description == nil || o.Streaming == nil {
+		card, err := c.AgentCard(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get agent card: %w", err)
+		}
+		if o.Name == nil {
+			o.Name = &card.Name
+		}
+		if o.Description == nil {
+			o.Description = &card.Description
+		}
+		if o.Streaming == nil {
+			o.Streaming = &card.Capabilities.Streaming
+		}
+	}
+
+	return einoA2A.NewAgent(ctx, einoA2A.AgentConfig{
+		Client:      c.cli,
+		Name:        o.Name,
+		Description: o.Description,
+		Streaming:   o.Streaming,
+	})
+}
@@ -0,0 +1,82 @@
+package a2a
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+// authenticator applies the configured credentials to every A2A request except the agent card request,
+// which must stay accessible without credentials so the client can discover the security schemes.
+type authenticator struct {
+	headers map[string]string
+	queries map[string]string
+	// credentials by security scheme name, resolved against the agent card
+	credentials map[string]string
+	card        func(ctx context.Context) (*models.AgentCard, error)
+	cardPath    string
+}
+
+func (a *authenticator) middleware(next client.Endpoint) client.Endpoint {
+	return func(ctx context.Context, req *protocol.Request, resp *protocol.Response) error {
+		if string(req.URI().Path()) == a.cardPath {
+			return next(ctx, req, resp)
+		}
+		for k, v := range a.headers {
+			req.Header.Set(k, v)
+		}
+		for k, v := range a.queries {
+			req.URI().QueryArgs().Set(k, v)
+		}
+		if len(a.credentials) > 0 {
+			card, err := a.card(ctx)
+			if err != nil {
+				return err
+			}
+			a.applySchemes(req, card)
+		}
+		return next(ctx, req, resp)
+	}
+}
+
+// wrapHertzClient returns a hertz client authenticating the requests with a, then sending them with cli.
+// The middlewares and options of cli, e.g. its TLS config or its HTTP/2 factory, still apply.
+func wrapHertzClient(cli *client.Client, a *authenticator) (*client.Client, error) {
+	w, err := client.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hertz client: %w", err)
+	}
+	w.Use(a.middleware)
+	if err := w.UseAsLast(func(client.Endpoint) client.Endpoint { return cli.Do }); err != nil {
+		return nil, fmt.Errorf("failed to wrap hertz client: %w", err)
+	}
+	return w, nil
+}
+
+// applySchemes sets the credentials of the security schemes declared by card on req
+func (a *authenticator) applySchemes(req *protocol.Request, card *models.AgentCard) {
+	for name, credential := range a.credentials {
+		scheme, ok := card.SecuritySchemes[name]
+		if !ok || scheme == nil {
+			continue
+		}
+		switch strings.ToLower(scheme.Type) {
+		case "basic":
+			req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credential)))
+		case "apikey":
+			if strings.EqualFold(scheme.In, "query") {
+				req.URI().QueryArgs().Set(scheme.Name, credential)
+			} else {
+				req.Header.Set(scheme.Name, credential)
+			}
+		default:
+			// http bearer, oauth2 and openIdConnect schemes all send a bearer token
+			req.Header.Set("Authorization", "Bearer "+credential)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/client"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino-ext/a2a/transport/jsonrpc"
+	hertzClient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const defaultAgentCardPath = ".well-known/agent-card.json"
+
+// Client represents an A2A client bound to a single remote agent.
+// It mirrors server/a2a: the agent card and handler paths are resolved the same way against the base URL.
+type Client struct {
+	cli  *client.A2AClient
+	opts *option
+
+	mu       sync.Mutex // Guards the cached agent card
+	card     *models.AgentCard
+	cardTime time.Time
+}
+
+// option holds configuration options for the client
+type option struct {
+	AgentCardPath *string // Agent card path
+	HandlerPath   string  // Agent handler path
+
+	HertzClient *hertzClient.Client // Underlying HTTP client
+
+	AgentCardTTL   time.Duration // How long the fetched agent card is cached
+	PollInterval   time.Duration // Interval between two tasks/get calls in WaitTask
+	MaxResubscribe int           // Max resubscribe attempts of a broken stream
+
+	Headers     map[string]string // Static headers sent with every A2A call
+	Queries     map[string]string // Static query parameters sent with every A2A call
+	Credentials map[string]string // Credentials by agent card security scheme name
+}
+
+// OptionFn is a function type for configuring client options using the functional options pattern
+type OptionFn func(*option)
+
+// WithAgentCardPath sets the agent card path relative to the base URL.
+// Default is ".well-known/agent-card.json", the same as server/a2a.
+func WithAgentCardPath(path string) OptionFn {
+	return func(o *option) {
+		o.AgentCardPath = &path
+	}
+}
+
+// WithHandlerPath sets the JSON-RPC handler path relative to the base URL.
+// Default is empty, meaning the base URL itself.
+func WithHandlerPath(handlerPath string) OptionFn {
+	return func(o *option) {
+		o.HandlerPath = handlerPath
+	}
+}
+
+// WithHertzClient sets the underlying hertz client.
+// It is left as is: the requests are authenticated before being handed to it, so it can be shared by several clients.
+func WithHertzClient(cli *hertzClient.Client) OptionFn {
+	return func(o *option) {
+		o.HertzClient = cli
+	}
+}
+
+// WithAgentCardTTL sets how long the fetched agent card is cached. Default is 5 minutes.
+// Zero disables caching, a negative value caches the card forever.
+func WithAgentCardTTL(ttl time.Duration) OptionFn {
+	return func(o *option) {
+		o.AgentCardTTL = ttl
+	}
+}
+
+// WithPollInterval sets the interval between two tasks/get calls in WaitTask. Default is 1 second.
+func WithPollInterval(interval time.Duration) OptionFn {
+	return func(o *option) {
+		o.PollInterval = interval
+	}
+}
+
+// WithMaxResubscribe sets how many times a broken stream is resubscribed with tasks/resubscribe
+// before its error is returned. Default is 3, zero disables resubscription.
+func WithMaxResubscribe(n int) OptionFn {
+	return func(o *option) {
+		o.MaxResubscribe = n
+	}
+}
+
+// WithHeader sets a header sent with every A2A call
+func WithHeader(key, value string) OptionFn {
+	return func(o *option) {
+		if o.Headers == nil {
+			o.Headers = make(map[string]string)
+		}
+		o.Headers[key] = value
+	}
+}
+
+// WithBearerToken authenticates every A2A call with "Authorization: Bearer <token>"
+func WithBearerToken(token string) OptionFn {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithAPIKey authenticates every A2A call with an API key sent in the given header
+func WithAPIKey(header, key string) OptionFn {
+	return WithHeader(header, key)
+}
+
+// WithAPIKeyQuery authenticates every A2A call with an API key sent as the given query parameter
+func WithAPIKeyQuery(param, key string) OptionFn {
+	return func(o *option) {
+		if o.Queries == nil {
+			o.Queries = make(map[string]string)
+		}
+		o.Queries[param] = key
+	}
+}
+
+// WithCredential sets the credential of a security scheme declared in the remote agent card.
+// The credential is applied according to the scheme type: "user:password" for basic schemes,
+// the key for apiKey schemes (in header or query as declared), and a token for http bearer, oauth2 and openIdConnect schemes.
+func WithCredential(scheme, credential string) OptionFn {
+	return func(o *option) {
+		if o.Credentials == nil {
+			o.Credentials = make(map[string]string)
+		}
+		o.Credentials[scheme] = credential
+	}
+}
+
+// New creates a client for the remote agent served at baseURL
+func New(ctx context.Context, baseURL string, opts ...OptionFn) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("base url cannot be empty")
+	}
+
+	o := &option{
+		AgentCardTTL:   5 * time.Minute,
+		PollInterval:   time.Second,
+		MaxResubscribe: 3,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	hCli := o.HertzClient
+	if hCli == nil {
+		var err error
+		hCli, err = hertzClient.NewClient(hertzClient.WithDialTimeout(consts.DefaultDialTimeout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hertz client: %w", err)
+		}
+	}
+
+	c := &Client{opts: o}
+
+	if len(o.Headers) > 0 || len(o.Queries) > 0 || len(o.Credentials) > 0 {
+		cardPath := defaultAgentCardPath
+		if o.AgentCardPath != nil {
+			cardPath = *o.AgentCardPath
+		}
+		cardURL, err := url.JoinPath(baseURL, cardPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join agent card url: %w", err)
+		}
+		u, err := url.Parse(cardURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse agent card url: %w", err)
+		}
+		auth := &authenticator{
+			headers:     o.Headers,
+			queries:     o.Queries,
+			credentials: o.Credentials,
+			card:        c.AgentCard,
+			cardPath:    u.Path,
+		}
+		if o.HertzClient != nil {
+			// the client of the caller may be shared with other agents, the auth is applied by a wrapper
+			var err error
+			hCli, err = wrapHertzClient(o.HertzClient, auth)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			hCli.Use(auth.middleware)
+		}
+	}
+
+	trans, err := jsonrpc.NewTransport(ctx, &jsonrpc.ClientConfig{
+		BaseURL:       baseURL,
+		HandlerPath:   o.HandlerPath,
+		AgentCardPath: o.AgentCardPath,
+		HertzClient:   hCli,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	c.cli, err = client.NewA2AClient(ctx, &client.Config{Transport: trans})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a2a client: %w", err)
+	}
+	return c, nil
+}
+
+// AgentCard returns the agent card of the remote agent, fetching it if the cached one has expired
+func (c *Client) AgentCard(ctx context.Context) (*models.AgentCard, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.opts.AgentCardTTL
+	if c.card != nil && (ttl < 0 || time.Since(c.cardTime) < ttl) {
+		return c.card, nil
+	}
+
+	card, err := c.cli.AgentCard(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.card = card
+	c.cardTime = time.Now()
+	return card, nil
+}
+
+// RefreshAgentCard drops the cached agent card and fetches it again
+func (c *Client) RefreshAgentCard(ctx context.Context) (*models.AgentCard, error) {
+	c.mu.Lock()
+	c.card = nil
+	c.mu.Unlock()
+	return c.AgentCard(ctx)
+}
+
+// SendMessage sends a message with message/send.
+// If the message is sent in non-blocking mode, use WaitTask to wait for the returned task.
+func (c *Client) SendMessage(ctx context.Context, params *models.MessageSendParams) (*models.SendMessageResponseUnion, error) {
+	return c.cli.SendMessage(ctx, params)
+}
+
+// SendMessageStreaming sends a message with message/stream.
+// The returned stream resubscribes to the task if the connection breaks before the final event.
+func (c *Client) SendMessageStreaming(ctx context.Context, params *models.MessageSendParams) (*Stream, error) {
+	s, err := c.cli.SendMessageStreaming(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	stream := &Stream{ctx: ctx, c: c, s: s}
+	if params != nil && params.Message.TaskID != nil {
+		stream.taskID = *params.Message.TaskID
+	}
+	return stream, nil
+}
+
+// ResubscribeTask resubscribes to the event stream of a running task with tasks/resubscribe
+func (c *Client) ResubscribeTask(ctx context.Context, taskID string) (*Stream, error) {
+	s, err := c.cli.ResubscribeTask(ctx, &models.TaskIDParams{ID: taskID})
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{ctx: ctx, c: c, s: s, taskID: taskID}, nil
+}
+
+// GetTask gets a task with tasks/get
+func (c *Client) GetTask(ctx context.Context, params *models.TaskQueryParams) (*models.Task, error) {
+	return c.cli.GetTask(ctx, params)
+}
+
+// CancelTask cancels a task with tasks/cancel
+func (c *Client) CancelTask(ctx context.Context, taskID string) (*models.Task, error) {
+	return c.cli.CancelTask(ctx, &models.TaskIDParams{ID: taskID})
+}
+
+// WaitTask polls the task with tasks/get until it reaches a terminal state or waits for the client,
+// i.e. input-required or auth-required.
+func (c *Client) WaitTask(ctx context.Context, taskID string) (*models.Task, error) {
+	ticker := time.NewTicker(c.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		t, err := c.GetTask(ctx, &models.TaskQueryParams{ID: taskID})
+		if err != nil {
+			return nil, err
+		}
+		if isTerminalState(t.Status.State) || isPausedState(t.Status.State) {
+			return t, nil
+		}
+		select {
+		case <-ctx.Done():
+			return t, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isTerminalState(state models.TaskState) bool {
+	switch state {
+	case models.TaskStateCompleted, models.TaskStateCanceled, models.TaskStateFailed,
+		models.TaskStateRejected, models.TaskStateUnknown:
+		return true
+	}
+	return false
+}
+
+func isPausedState(state models.TaskState) bool {
+	return state == models.TaskStateInputRequired || state == models.TaskStateAuthRequired
+}
@@ -0,0 +1,234 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	einoA2A "github.com/cloudwego/eino-ext/a2a/extension/eino"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino-ext/a2a/transport/jsonrpc"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	hertzClient "github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoAgent struct{}
+
+func (e *echoAgent) Name(_ context.Context) string { return "echo" }
+
+func (e *echoAgent) Description(_ context.Context) string { return "echoes the input" }
+
+func (e *echoAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	content := input.Messages[len(input.Messages)-1].Content
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage("echo: "+content, nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+// startServer serves the echo agent behind a bearer "token" check and returns its base url
+func startServer(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	h := server.New(server.WithHostPorts(addr), server.WithExitWaitTime(0))
+	h.Use(func(ctx context.Context, c *app.RequestContext) {
+		if string(c.Method()) == "POST" && string(c.Request.Header.Peek("Authorization")) != "Bearer token" {
+			c.AbortWithStatus(401)
+			return
+		}
+		c.Next(ctx)
+	})
+	r, err := jsonrpc.NewRegistrar(ctx, &jsonrpc.ServerConfig{Router: h})
+	assert.NoError(t, err)
+	assert.NoError(t, einoA2A.RegisterServerHandlers(ctx, &echoAgent{}, &einoA2A.ServerConfig{
+		Registrar: r,
+		SecuritySchemes: map[string]*spec.SecurityScheme{
+			"bearer": {SecuritySchemeProps: spec.SecuritySchemeProps{Type: "http"}},
+		},
+	}))
+	go h.Spin()
+	t.Cleanup(func() { _ = h.Shutdown(ctx) })
+	assert.Eventually(t, h.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	return "http://" + addr
+}
+
+func textMessage(text string) *models.MessageSendParams {
+	return &models.MessageSendParams{Message: models.Message{
+		Role:      models.RoleUser,
+		MessageID: "m1",
+		Parts:     []models.Part{{Kind: models.PartKindText, Text: &text}},
+	}}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	baseURL := startServer(t)
+
+	c, err := New(ctx, baseURL, WithCredential("bearer", "token"))
+	assert.NoError(t, err)
+
+	card, err := c.AgentCard(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", card.Name)
+	assert.True(t, card.Capabilities.Streaming)
+	cached, err := c.AgentCard(ctx)
+	assert.NoError(t, err)
+	assert.Same(t, card, cached)
+
+	resp, err := c.SendMessage(ctx, textMessage("hi"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, resp.Task) {
+		assert.Equal(t, models.TaskStateCompleted, resp.Task.Status.State)
+		assert.Equal(t, "echo: hi", *resp.Task.Status.Message.Parts[0].Text)
+
+		task, err := c.WaitTask(ctx, resp.Task.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.TaskStateCompleted, task.Status.State)
+	}
+
+	stream, err := c.SendMessageStreaming(ctx, textMessage("yo"))
+	assert.NoError(t, err)
+	var texts []string
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		if event.Message != nil {
+			texts = append(texts, *event.Message.Parts[0].Text)
+		}
+	}
+	assert.Equal(t, []string{"echo: yo"}, texts)
+	assert.NotEmpty(t, stream.TaskID())
+
+	unauthorized, err := New(ctx, baseURL)
+	assert.NoError(t, err)
+	_, err = unauthorized.SendMessage(ctx, textMessage("hi"))
+	assert.Error(t, err)
+}
+
+func TestClientAgent(t *testing.T) {
+	ctx := context.Background()
+	baseURL := startServer(t)
+
+	c, err := New(ctx, baseURL, WithBearerToken("token"))
+	assert.NoError(t, err)
+	a, err := c.NewAgent(ctx, WithStreaming(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "echo", a.Name(ctx))
+
+	iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: a}).Query(ctx, "ping")
+	var outputs []string
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+		assert.NoError(t, event.Err)
+		if event.Output != nil && event.Output.MessageOutput != nil {
+			m, err := event.Output.MessageOutput.GetMessage()
+			assert.NoError(t, err)
+			outputs = append(outputs, m.Content)
+		}
+	}
+	assert.Contains(t, outputs, "echo: ping")
+}
+
+func TestClientSharedHertzClient(t *testing.T) {
+	ctx := context.Background()
+	baseURL := startServer(t)
+
+	hCli, err := hertzClient.NewClient()
+	assert.NoError(t, err)
+	authorized, err := New(ctx, baseURL, WithHertzClient(hCli), WithBearerToken("token"))
+	assert.NoError(t, err)
+	other, err := New(ctx, baseURL, WithHertzClient(hCli), WithBearerToken("other"))
+	assert.NoError(t, err)
+
+	// the credentials of a client are not sent by the others sharing its hertz client
+	_, err = other.SendMessage(ctx, textMessage("hi"))
+	assert.Error(t, err)
+	_, err = authorized.SendMessage(ctx, textMessage("hi"))
+	assert.NoError(t, err)
+	code, _, err := hCli.Post(ctx, nil, baseURL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, code)
+}
+
+func TestStreamResubscribe(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		methods []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		methods = append(methods, req.Method)
+		mu.Unlock()
+		state, final := "working", false
+		if req.Method == "tasks/resubscribe" {
+			state, final = "completed", true
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// the connection of message/stream ends cleanly before the final event
+		_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%s,\"result\":{\"kind\":\"status-update\","+
+			"\"taskId\":\"t1\",\"contextId\":\"c1\",\"status\":{\"state\":%q},\"final\":%t}}\n\n", req.ID, state, final)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(ctx, srv.URL)
+	assert.NoError(t, err)
+	stream, err := c.SendMessageStreaming(ctx, textMessage("hi"))
+	assert.NoError(t, err)
+	var states []models.TaskState
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		states = append(states, event.TaskStatusUpdateEvent.Status.State)
+	}
+	assert.Equal(t, []models.TaskState{models.TaskStateWorking, models.TaskStateCompleted}, states)
+	mu.Lock()
+	assert.Equal(t, []string{"message/stream", "tasks/resubscribe"}, methods)
+	mu.Unlock()
+
+	// a clean break is unexpected once the resubscriptions are exhausted
+	c, err = New(ctx, srv.URL, WithMaxResubscribe(0))
+	assert.NoError(t, err)
+	stream, err = c.SendMessageStreaming(ctx, textMessage("hi"))
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,79 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/eino-ext/a2a/client"
+	"github.com/cloudwego/eino-ext/a2a/models"
+)
+
+// Stream receives the events of message/stream or tasks/resubscribe.
+// If the connection breaks before the final event, even cleanly, the stream resubscribes to the task and keeps receiving.
+// Once the resubscriptions are exhausted, a clean break is returned as io.ErrUnexpectedEOF.
+type Stream struct {
+	ctx context.Context
+	c   *Client
+	s   *client.ServerStreamingWrapper
+
+	taskID      string
+	final       bool
+	resubscribe int
+}
+
+// Recv returns the next event, or io.EOF once the stream has finished
+func (s *Stream) Recv() (*models.SendMessageStreamingResponseUnion, error) {
+	for {
+		resp, err := s.s.Recv()
+		if err == nil {
+			if resp != nil {
+				if id := resp.GetTaskID(); id != "" {
+					s.taskID = id
+				}
+				if isFinalEvent(resp) {
+					s.final = true
+				}
+			}
+			return resp, nil
+		}
+
+		// a connection dropped cleanly before the final event also ends with io.EOF
+		if s.final || s.taskID == "" {
+			return nil, err
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if s.resubscribe >= s.c.opts.MaxResubscribe || s.ctx.Err() != nil {
+			return nil, err
+		}
+		s.resubscribe++
+		_ = s.s.Close()
+		ns, rErr := s.c.cli.ResubscribeTask(s.ctx, &models.TaskIDParams{ID: s.taskID})
+		if rErr != nil {
+			return nil, errors.Join(err, rErr)
+		}
+		s.s = ns
+	}
+}
+
+// TaskID returns the id of the task the stream belongs to, it is empty until the first task event is received
+func (s *Stream) TaskID() string {
+	return s.taskID
+}
+
+// Close closes the stream
+func (s *Stream) Close() error {
+	return s.s.Close()
+}
+
+func isFinalEvent(resp *models.SendMessageStreamingResponseUnion) bool {
+	switch {
+	case resp.TaskStatusUpdateEvent != nil:
+		return resp.TaskStatusUpdateEvent.Final
+	case resp.Task != nil:
+		return isTerminalState(resp.Task.Status.State) || isPausedState(resp.Task.Status.State)
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package embeddingprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// EmbedStrings embeds the texts in batches of at most Config.BatchSize texts, sent one after the other.
+// The embeddings are returned in the order of the texts.
+func (e *Embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += e.batchSize {
+		end := min(start+e.batchSize, len(texts))
+		batch, err := e.Embedder.EmbedStrings(ctx, texts[start:end], opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts[%d:%d]: %w", start, end, err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("failed to embed texts[%d:%d]: got %d embeddings", start, end, len(batch))
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	if len(embeddings) > 0 {
+		e.dims.learn(len(embeddings[0]))
+	}
+	return embeddings, nil
+}
+
+// BatchSize returns the max number of texts embedded by a request
+func (e *Embedder) BatchSize() int {
+	return e.batchSize
+}
+
+// Dimensions returns the dimension of the embeddings: Config.Dimensions, the dimension of the model
+// found in the catalog, or the dimension of the embeddings returned so far. False if it is still unknown.
+func (e *Embedder) Dimensions() (int, bool) {
+	return e.dims.get()
+}
+
+type dimensions struct {
+	mu    sync.RWMutex
+	value int
+}
+
+func (d *dimensions) get() (int, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.value, d.value > 0
+}
+
+func (d *dimensions) set(value int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.value = value
+}
+
+// learn sets the dimension from the embeddings returned, the models of the catalog may be deployed with another one
+func (d *dimensions) learn(value int) {
+	if cur, _ := d.get(); cur != value {
+		d.set(value)
+	}
+}
+
+var (
+	catalogMu        sync.RWMutex
+	dimensionCatalog = map[string]map[string]int{
+		"openai": {
+			"text-embedding-3-small": 1536,
+			"text-embedding-3-large": 3072,
+			"text-embedding-ada-002": 1536,
+		},
+		"gemini": {
+			"gemini-embedding-001": 3072,
+			"text-embedding-004":   768,
+			"embedding-001":        768,
+		},
+		"ollama": {
+			"nomic-embed-text":  768,
+			"mxbai-embed-large": 1024,
+			"all-minilm":        384,
+			"bge-m3":            1024,
+		},
+		"volcengine": {
+			"doubao-embedding-text-240715":       2560,
+			"doubao-embedding-large-text-240915": 4096,
+		},
+		"dashscope": {
+			"text-embedding-v1": 1536,
+			"text-embedding-v2": 1536,
+			"text-embedding-v3": 1024,
+			"text-embedding-v4": 1024,
+		},
+	}
+	// providerAliases are the providers serving the models of another one
+	providerAliases = map[string]string{
+		"azure":     "openai",
+		"vertex_ai": "gemini",
+	}
+)
+
+// RegisterDimensions registers the dimension of the embeddings of the models starting with modelPrefix,
+// e.g. for fine-tuned or self-hosted models. It overrides the dimension found in the built-in catalog.
+func RegisterDimensions(provider, modelPrefix string, dims int) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	provider = strings.ToLower(provider)
+	if dimensionCatalog[provider] == nil {
+		dimensionCatalog[provider] = make(map[string]int)
+	}
+	dimensionCatalog[provider][strings.ToLower(modelPrefix)] = dims
+}
+
+// LookupDimensions returns the dimension of the embeddings of the model of the provider,
+// with the longest model prefix of the catalog
+func LookupDimensions(provider, modelName string) (int, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	provider = strings.ToLower(provider)
+	if alias, ok := providerAliases[provider]; ok {
+		provider = alias
+	}
+	modelName = strings.ToLower(modelName)
+	best, dims := -1, 0
+	for prefix, d := range dimensionCatalog[provider] {
+		if strings.HasPrefix(modelName, prefix) && len(prefix) > best {
+			best, dims = len(prefix), d
+		}
+	}
+	return dims, best >= 0
+}
@@ -0,0 +1,78 @@
+package embeddingprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbedStrings(t *testing.T) {
+	ctx := t.Context()
+	var (
+		mu      sync.Mutex
+		batches []int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input      []string `json:"input"`
+			Dimensions int      `json:"dimensions"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		batches = append(batches, len(req.Input))
+		mu.Unlock()
+		if strings.Contains(strings.Join(req.Input, ""), "fail") {
+			http.Error(w, `{"error":{"message":"bad input"}}`, http.StatusBadRequest)
+			return
+		}
+		dims := req.Dimensions
+		if dims == 0 {
+			dims = 3
+		}
+		var data []string
+		for i, text := range req.Input {
+			embedding := make([]string, dims)
+			for j := range embedding {
+				embedding[j] = text[len(text)-1:]
+			}
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%s]}`, i, strings.Join(embedding, ",")))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"object":"list","model":"m","data":[%s],"usage":{"prompt_tokens":1,"total_tokens":1}}`, strings.Join(data, ","))
+	}))
+	defer srv.Close()
+
+	e, err := NewEmbedder(ctx, &Config{Provider: "openai", APIKey: "key", BaseURL: srv.URL, Model: "custom-embedding", BatchSize: 2})
+	assert.Nil(t, err)
+	_, ok := e.Dimensions()
+	assert.False(t, ok)
+
+	embeddings, err := e.EmbedStrings(ctx, []string{"t1", "t2", "t3", "t4", "t5"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 2, 1}, batches)
+	assert.Len(t, embeddings, 5)
+	for i, embedding := range embeddings {
+		assert.Equal(t, []float64{float64(i + 1), float64(i + 1), float64(i + 1)}, embedding)
+	}
+	dims, ok := e.Dimensions()
+	assert.True(t, ok)
+	assert.Equal(t, 3, dims)
+
+	_, err = e.EmbedStrings(ctx, []string{"t1", "t2", "fail"})
+	assert.ErrorContains(t, err, "texts[2:3]")
+
+	RegisterDimensions("openai", "custom-", 2)
+	e, err = NewEmbedder(ctx, &Config{Provider: "openai", APIKey: "key", BaseURL: srv.URL, Model: "custom-embedding", Dimensions: ptrOf(2)})
+	assert.Nil(t, err)
+	embeddings, err = e.EmbedStrings(ctx, []string{"t1"})
+	assert.Nil(t, err)
+	assert.Len(t, embeddings[0], 2)
+	dims, _ = LookupDimensions("azure", "CUSTOM-embedding")
+	assert.Equal(t, 2, dims)
+}
@@ -0,0 +1,257 @@
+package embeddingprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/eino-ext/components/embedding/ark"
+	"github.com/cloudwego/eino-ext/components/embedding/dashscope"
+	"github.com/cloudwego/eino-ext/components/embedding/gemini"
+	"github.com/cloudwego/eino-ext/components/embedding/ollama"
+	"github.com/cloudwego/eino-ext/components/embedding/openai"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	"google.golang.org/genai"
+
+	"github.com/eino-contrib/agentkit-ve/libs/veauth"
+)
+
+type Config struct {
+	Provider string
+
+	APIKey string
+
+	BaseURL string
+
+	// Model is the embedding model name.
+	Model string
+
+	// Dimensions is the dimension of the embeddings, for the models able to return shorter embeddings.
+	// Default is the dimension of the model.
+	Dimensions *int
+
+	// BatchSize is the max number of texts embedded by a request, larger inputs are split into batches.
+	// Default is the limit of the provider.
+	BatchSize int
+
+	// HTTPClient sends the requests to the provider, default is the client of the provider SDK.
+	HTTPClient *http.Client
+}
+
+const (
+	defaultProvider = "volcengine"
+	defaultModel    = "doubao-embedding-text-240715"
+)
+
+type embedderType string
+
+const (
+	openaiEmbedderType      embedderType = "OpenAI"
+	azureOpenaiEmbedderType embedderType = "AzureOpenAI"
+	geminiEmbedderType      embedderType = "Gemini"
+	ollamaEmbedderType      embedderType = "Ollama"
+	arkEmbedderType         embedderType = "Ark"
+	dashscopeEmbedderType   embedderType = "DashScope"
+)
+
+var providerPrefixToEmbedderType = map[string]embedderType{
+	"openai": openaiEmbedderType,
+	"azure":  azureOpenaiEmbedderType,
+
+	"vertex_ai": geminiEmbedderType,
+	"gemini":    geminiEmbedderType,
+
+	"ollama":     ollamaEmbedderType,
+	"volcengine": arkEmbedderType,
+	"dashscope":  dashscopeEmbedderType,
+}
+
+// maxBatchSizes are the max number of texts of a request accepted by the providers
+var maxBatchSizes = map[embedderType]int{
+	openaiEmbedderType:      2048,
+	azureOpenaiEmbedderType: 2048,
+	geminiEmbedderType:      100,
+	ollamaEmbedderType:      512,
+	arkEmbedderType:         256,
+	dashscopeEmbedderType:   10,
+}
+
+// Embedder embeds texts with the embedder of the provider, in batches of at most Config.BatchSize texts
+type Embedder struct {
+	cfg       *Config
+	eType     embedderType
+	batchSize int
+	dims      dimensions
+	embedding.Embedder
+}
+
+func NewEmbedder(ctx context.Context, cfg *Config) (*Embedder, error) {
+
+	var (
+		err      error
+		embedder embedding.Embedder
+	)
+
+	if cfg == nil {
+		cfg = &Config{
+			Provider: defaultProvider,
+			Model:    defaultModel,
+		}
+	}
+
+	if cfg.Provider == "" {
+		cfg.Provider = defaultProvider
+	}
+
+	if cfg.Provider == defaultProvider && cfg.Model == "" {
+		cfg.Model = defaultModel
+	}
+
+	if cfg.Provider == defaultProvider && cfg.APIKey == "" {
+		cfg.APIKey, err = veauth.GetDefaultArkAPIKey()
+		if err != nil {
+			return nil, fmt.Errorf("volcengine provider: failed to get default Ark API key: %w", err)
+		}
+	}
+
+	eType, ok := providerPrefixToEmbedderType[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("not support provider %s", cfg.Provider)
+	}
+
+	if cfg.BatchSize < 0 {
+		return nil, fmt.Errorf("invalid batch size: %d", cfg.BatchSize)
+	}
+	batchSize := maxBatchSizes[eType]
+	if cfg.BatchSize > 0 && cfg.BatchSize < batchSize {
+		batchSize = cfg.BatchSize
+	}
+
+	switch eType {
+	case arkEmbedderType:
+		if cfg.Dimensions != nil {
+			return nil, fmt.Errorf("volcengine provider: Dimensions is not supported")
+		}
+		embedder, err = ark.NewEmbedder(ctx, cfg.toArkConfig())
+		if err != nil {
+			return nil, err
+		}
+	case geminiEmbedderType:
+		geminiCfg, err := cfg.toGeminiConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		embedder, err = gemini.NewEmbedder(ctx, geminiCfg)
+		if err != nil {
+			return nil, err
+		}
+	case ollamaEmbedderType:
+		if cfg.Dimensions != nil {
+			return nil, fmt.Errorf("ollama provider: Dimensions is not supported")
+		}
+		embedder, err = ollama.NewEmbedder(ctx, cfg.toOllamaConfig())
+		if err != nil {
+			return nil, err
+		}
+	case azureOpenaiEmbedderType:
+		openaiCfg := cfg.toOpenAIConfig()
+		openaiCfg.ByAzure = true
+		embedder, err = openai.NewEmbedder(ctx, openaiCfg)
+		if err != nil {
+			return nil, err
+		}
+	case openaiEmbedderType:
+		embedder, err = openai.NewEmbedder(ctx, cfg.toOpenAIConfig())
+		if err != nil {
+			return nil, err
+		}
+	case dashscopeEmbedderType:
+		embedder, err = dashscope.NewEmbedder(ctx, cfg.toDashScopeConfig())
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid embedder type: %s", eType)
+	}
+
+	e := &Embedder{
+		cfg:       cfg,
+		eType:     eType,
+		batchSize: batchSize,
+		Embedder:  embedder,
+	}
+	if cfg.Dimensions != nil {
+		e.dims.set(*cfg.Dimensions)
+	} else if dims, ok := LookupDimensions(cfg.Provider, cfg.Model); ok {
+		e.dims.set(dims)
+	}
+	return e, nil
+}
+
+func (e *Embedder) GetType() string {
+	typer, ok := e.Embedder.(components.Typer)
+	if !ok {
+		return "unknown"
+	}
+	return typer.GetType()
+}
+
+func (c *Config) toArkConfig() *ark.EmbeddingConfig {
+	return &ark.EmbeddingConfig{
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
+	}
+}
+
+func (c *Config) toGeminiConfig(ctx context.Context) (*gemini.EmbeddingConfig, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:     c.APIKey,
+		HTTPClient: c.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: c.BaseURL,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &gemini.EmbeddingConfig{
+		Client: client,
+		Model:  c.Model,
+	}
+	if c.Dimensions != nil {
+		dims := int32(*c.Dimensions)
+		cfg.OutputDimensionality = &dims
+	}
+	return cfg, nil
+}
+
+func (c *Config) toOllamaConfig() *ollama.EmbeddingConfig {
+	return &ollama.EmbeddingConfig{
+		BaseURL:    c.BaseURL,
+		Model:      c.Model,
+		HTTPClient: c.HTTPClient,
+	}
+}
+
+func (c *Config) toOpenAIConfig() *openai.EmbeddingConfig {
+	return &openai.EmbeddingConfig{
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
+		Dimensions: c.Dimensions,
+	}
+}
+
+func (c *Config) toDashScopeConfig() *dashscope.EmbeddingConfig {
+	return &dashscope.EmbeddingConfig{
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		HTTPClient: c.HTTPClient,
+		Dimensions: c.Dimensions,
+	}
+}
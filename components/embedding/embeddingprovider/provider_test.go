@@ -0,0 +1,58 @@
+package embeddingprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEmbedder(t *testing.T) {
+	ctx := t.Context()
+	e, err := NewEmbedder(ctx, &Config{Provider: "openai", Model: "text-embedding-3-small"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OpenAI", e.GetType())
+	assert.Equal(t, 2048, e.BatchSize())
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "azure", Model: "text-embedding-3-large"})
+	assert.Nil(t, err)
+	assert.Equal(t, "OpenAI", e.GetType())
+	dims, ok := e.Dimensions()
+	assert.True(t, ok)
+	assert.Equal(t, 3072, dims)
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "gemini", APIKey: "api-key", Model: "gemini-embedding-001", Dimensions: ptrOf(768)})
+	assert.Nil(t, err)
+	assert.Equal(t, "Gemini", e.GetType())
+	dims, _ = e.Dimensions()
+	assert.Equal(t, 768, dims)
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "vertex_ai", APIKey: "api-key"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Gemini", e.GetType())
+	assert.Equal(t, 100, e.BatchSize())
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "ollama", Model: "nomic-embed-text", BatchSize: 16})
+	assert.Nil(t, err)
+	assert.Equal(t, "Ollama", e.GetType())
+	assert.Equal(t, 16, e.BatchSize())
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "volcengine", APIKey: "api-key"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Ark", e.GetType())
+	dims, _ = e.Dimensions()
+	assert.Equal(t, 2560, dims)
+
+	e, err = NewEmbedder(ctx, &Config{Provider: "dashscope", APIKey: "api-key", Model: "text-embedding-v4", BatchSize: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "DashScope", e.GetType())
+	assert.Equal(t, 10, e.BatchSize())
+
+	_, err = NewEmbedder(ctx, &Config{Provider: "ollama", Dimensions: ptrOf(256)})
+	assert.Error(t, err)
+	_, err = NewEmbedder(ctx, &Config{Provider: "unknown"})
+	assert.Error(t, err)
+}
+
+func ptrOf[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,145 @@
+package chatmodelprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/cloudwego/eino-ext/components/model/claude"
+	"github.com/cloudwego/eino/components/model"
+)
+
+// bedrockVersion is the version of the Anthropic API served by Bedrock
+const bedrockVersion = "bedrock-2023-05-31"
+
+// newBedrockChatModel returns the model of Bedrock. The requests of the Anthropic client are sent to the InvokeModel API
+// with the client of cfg for the Anthropic models, see bedrockTransport, the other models are served by the Converse API,
+// see bedrockConverseModel.
+func newBedrockChatModel(ctx context.Context, cfg *claude.Config) (model.ToolCallingChatModel, error) {
+	var opts []func(*awsConfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsConfig.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKey != "" && cfg.SecretAccessKey != "" {
+		opts = append(opts, awsConfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretAccessKey, cfg.SessionToken)))
+	}
+	if cfg.HTTPClient != nil {
+		opts = append(opts, awsConfig.WithHTTPClient(cfg.HTTPClient))
+	}
+	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock provider: failed to load AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, errors.New("bedrock provider: Region is required, e.g. with the AWS_REGION env")
+	}
+	if awsCfg.Credentials == nil {
+		return nil, errors.New("bedrock provider: AWS credentials are required")
+	}
+	if !strings.Contains(strings.ToLower(cfg.Model), "anthropic.") {
+		return newBedrockConverseModel(awsCfg, cfg), nil
+	}
+
+	base := cfg.HTTPClient
+	if base == nil {
+		base = &http.Client{}
+	}
+	client := *base
+	client.Transport = &bedrockTransport{cfg: awsCfg, signer: v4.NewSigner(), next: base.Transport}
+	if cfg.BaseURL == nil {
+		baseURL := fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", awsCfg.Region)
+		cfg.BaseURL = &baseURL
+	}
+	cfg.HTTPClient = &client
+	cfg.APIKey = ""
+	cfg.ByBedrock = false
+	return claude.NewChatModel(ctx, cfg)
+}
+
+// bedrockTransport sends the requests of the Messages API to the InvokeModel API of Bedrock, signed with SigV4
+type bedrockTransport struct {
+	cfg    aws.Config
+	signer *v4.Signer
+	next   http.RoundTripper
+}
+
+func (t *bedrockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	req = req.Clone(ctx)
+	req.Header.Del("X-Api-Key")
+
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body, err = t.toInvoke(req, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+	}
+
+	creds, err := t.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock provider: failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err = t.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "bedrock", t.cfg.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("bedrock provider: failed to sign request: %w", err)
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
+
+// toInvoke returns the body of the InvokeModel request, the model and the streaming are set by its path
+func (t *bedrockTransport) toInvoke(req *http.Request, data []byte) ([]byte, error) {
+	if req.Method != http.MethodPost || req.URL.Path != "/v1/messages" {
+		return data, nil
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("bedrock provider: failed to decode request: %w", err)
+	}
+	var (
+		modelID string
+		stream  bool
+	)
+	_ = json.Unmarshal(body["model"], &modelID)
+	_ = json.Unmarshal(body["stream"], &stream)
+	delete(body, "model")
+	delete(body, "stream")
+	if _, ok := body["anthropic_version"]; !ok {
+		body["anthropic_version"], _ = json.Marshal(bedrockVersion)
+	}
+
+	req.URL.Path = "/model/" + modelID + "/invoke"
+	if stream {
+		req.URL.Path += "-with-response-stream"
+	}
+	req.URL.RawPath = ""
+	return json.Marshal(body)
+}
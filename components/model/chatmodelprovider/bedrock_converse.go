@@ -0,0 +1,330 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/cloudwego/eino-ext/components/model/claude"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// bedrockConverseModel is the model of Bedrock served by the Converse API, for the models other than the Anthropic ones,
+// e.g. the Amazon Nova, Meta Llama or Mistral models. The reasoning options are ignored.
+type bedrockConverseModel struct {
+	client *bedrockruntime.Client
+	cfg    *claude.Config
+	tools  []*schema.ToolInfo
+}
+
+// newBedrockConverseModel returns the model of cfg, its requests are signed with the credentials of awsCfg
+func newBedrockConverseModel(awsCfg aws.Config, cfg *claude.Config) *bedrockConverseModel {
+	client := bedrockruntime.NewFromConfig(awsCfg, func(o *bedrockruntime.Options) {
+		if cfg.BaseURL != nil {
+			o.BaseEndpoint = cfg.BaseURL
+		}
+	})
+	return &bedrockConverseModel{client: client, cfg: cfg}
+}
+
+func (m *bedrockConverseModel) GetType() string {
+	return string(bedrockModelType)
+}
+
+func (m *bedrockConverseModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &bedrockConverseModel{client: m.client, cfg: m.cfg, tools: tools}, nil
+}
+
+func (m *bedrockConverseModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	req, err := m.toConverseRequest(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId:         req.ModelId,
+		Messages:        req.Messages,
+		System:          req.System,
+		InferenceConfig: req.InferenceConfig,
+		ToolConfig:      req.ToolConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock provider: converse failed: %w", err)
+	}
+	out, ok := resp.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, errors.New("bedrock provider: converse returned no message")
+	}
+	msg := &schema.Message{Role: schema.Assistant}
+	for _, block := range out.Value.Content {
+		switch b := block.(type) {
+		case *types.ContentBlockMemberText:
+			msg.Content += b.Value
+		case *types.ContentBlockMemberToolUse:
+			args := "{}"
+			if b.Value.Input != nil {
+				data, err := b.Value.Input.MarshalSmithyDocument()
+				if err != nil {
+					return nil, fmt.Errorf("bedrock provider: failed to decode tool input: %w", err)
+				}
+				args = string(data)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+				ID:       aws.ToString(b.Value.ToolUseId),
+				Type:     "function",
+				Function: schema.FunctionCall{Name: aws.ToString(b.Value.Name), Arguments: args},
+			})
+		}
+	}
+	msg.ResponseMeta = &schema.ResponseMeta{FinishReason: string(resp.StopReason), Usage: toTokenUsage(resp.Usage)}
+	return msg, nil
+}
+
+func (m *bedrockConverseModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	req, err := m.toConverseRequest(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.ConverseStream(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock provider: converse stream failed: %w", err)
+	}
+	stream := resp.GetStream()
+	sr, sw := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer sw.Close()
+		defer stream.Close()
+		for event := range stream.Events() {
+			chunk := converseStreamChunk(event)
+			if chunk == nil {
+				continue
+			}
+			if closed := sw.Send(chunk, nil); closed {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			sw.Send(nil, fmt.Errorf("bedrock provider: converse stream failed: %w", err))
+		}
+	}()
+	return sr, nil
+}
+
+// converseStreamChunk returns the chunk of a stream event, nil if the event carries nothing.
+// The tool calls are indexed by their content block, their arguments are streamed as JSON fragments.
+func converseStreamChunk(event types.ConverseStreamOutput) *schema.Message {
+	switch e := event.(type) {
+	case *types.ConverseStreamOutputMemberContentBlockStart:
+		start, ok := e.Value.Start.(*types.ContentBlockStartMemberToolUse)
+		if !ok {
+			return nil
+		}
+		index := int(aws.ToInt32(e.Value.ContentBlockIndex))
+		return &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+			Index:    &index,
+			ID:       aws.ToString(start.Value.ToolUseId),
+			Type:     "function",
+			Function: schema.FunctionCall{Name: aws.ToString(start.Value.Name)},
+		}}}
+	case *types.ConverseStreamOutputMemberContentBlockDelta:
+		switch delta := e.Value.Delta.(type) {
+		case *types.ContentBlockDeltaMemberText:
+			return &schema.Message{Role: schema.Assistant, Content: delta.Value}
+		case *types.ContentBlockDeltaMemberToolUse:
+			index := int(aws.ToInt32(e.Value.ContentBlockIndex))
+			return &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+				Index:    &index,
+				Function: schema.FunctionCall{Arguments: aws.ToString(delta.Value.Input)},
+			}}}
+		}
+	case *types.ConverseStreamOutputMemberMessageStop:
+		return &schema.Message{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{FinishReason: string(e.Value.StopReason)}}
+	case *types.ConverseStreamOutputMemberMetadata:
+		if e.Value.Usage != nil {
+			return &schema.Message{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{Usage: toTokenUsage(e.Value.Usage)}}
+		}
+	}
+	return nil
+}
+
+func toTokenUsage(usage *types.TokenUsage) *schema.TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	return &schema.TokenUsage{
+		PromptTokens:     int(aws.ToInt32(usage.InputTokens)),
+		CompletionTokens: int(aws.ToInt32(usage.OutputTokens)),
+		TotalTokens:      int(aws.ToInt32(usage.TotalTokens)),
+	}
+}
+
+// toConverseRequest returns the request of the call, with the params of the config overridden by the options
+func (m *bedrockConverseModel) toConverseRequest(input []*schema.Message, opts []model.Option) (*bedrockruntime.ConverseStreamInput, error) {
+	defaults := &model.Options{
+		Model:       &m.cfg.Model,
+		Temperature: m.cfg.Temperature,
+		TopP:        m.cfg.TopP,
+		Stop:        m.cfg.StopSequences,
+		Tools:       m.tools,
+	}
+	if m.cfg.MaxTokens > 0 {
+		defaults.MaxTokens = &m.cfg.MaxTokens
+	}
+	o := model.GetCommonOptions(defaults, opts...)
+
+	req := &bedrockruntime.ConverseStreamInput{
+		ModelId: o.Model,
+		InferenceConfig: &types.InferenceConfiguration{
+			Temperature:   o.Temperature,
+			TopP:          o.TopP,
+			StopSequences: o.Stop,
+		},
+	}
+	if o.MaxTokens != nil {
+		req.InferenceConfig.MaxTokens = aws.Int32(int32(*o.MaxTokens))
+	}
+
+	for _, msg := range input {
+		if msg.Role == schema.System {
+			req.System = append(req.System, &types.SystemContentBlockMemberText{Value: msg.Content})
+			continue
+		}
+		role, content, err := toConverseContent(msg)
+		if err != nil {
+			return nil, err
+		}
+		// the roles alternate, e.g. the results of parallel tool calls are sent in one user message
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, content...)
+			continue
+		}
+		req.Messages = append(req.Messages, types.Message{Role: role, Content: content})
+	}
+
+	if len(o.Tools) > 0 && (o.ToolChoice == nil || *o.ToolChoice != schema.ToolChoiceForbidden) {
+		toolConfig := &types.ToolConfiguration{}
+		for _, t := range o.Tools {
+			spec, err := toConverseTool(t)
+			if err != nil {
+				return nil, err
+			}
+			toolConfig.Tools = append(toolConfig.Tools, spec)
+		}
+		if o.ToolChoice != nil && *o.ToolChoice == schema.ToolChoiceForced {
+			if len(o.Tools) == 1 {
+				toolConfig.ToolChoice = &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{Name: aws.String(o.Tools[0].Name)}}
+			} else {
+				toolConfig.ToolChoice = &types.ToolChoiceMemberAny{}
+			}
+		}
+		req.ToolConfig = toolConfig
+	}
+	return req, nil
+}
+
+// toConverseContent returns the role and the content blocks of a message,
+// tool results are sent by the user as the Converse API expects
+func toConverseContent(msg *schema.Message) (types.ConversationRole, []types.ContentBlock, error) {
+	var content []types.ContentBlock
+	switch msg.Role {
+	case schema.Tool:
+		return types.ConversationRoleUser, []types.ContentBlock{&types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+			ToolUseId: aws.String(msg.ToolCallID),
+			Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: msg.Content}},
+		}}}, nil
+	case schema.Assistant:
+		if msg.Content != "" {
+			content = append(content, &types.ContentBlockMemberText{Value: msg.Content})
+		}
+		for _, tc := range msg.ToolCalls {
+			var args any = map[string]any{}
+			if tc.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+					return "", nil, fmt.Errorf("bedrock provider: invalid arguments of tool call %s: %w", tc.ID, err)
+				}
+			}
+			content = append(content, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+				ToolUseId: aws.String(tc.ID),
+				Name:      aws.String(tc.Function.Name),
+				Input:     document.NewLazyDocument(args),
+			}})
+		}
+		return types.ConversationRoleAssistant, content, nil
+	}
+
+	if msg.Content != "" {
+		content = append(content, &types.ContentBlockMemberText{Value: msg.Content})
+	}
+	for _, part := range msg.UserInputMultiContent {
+		switch {
+		case part.Type == schema.ChatMessagePartTypeText:
+			content = append(content, &types.ContentBlockMemberText{Value: part.Text})
+		case part.Image != nil:
+			block, err := toConverseImage(part.Image.MessagePartCommon)
+			if err != nil {
+				return "", nil, err
+			}
+			content = append(content, block)
+		default:
+			return "", nil, fmt.Errorf("bedrock provider: %s parts are not supported by the Converse API", part.Type)
+		}
+	}
+	return types.ConversationRoleUser, content, nil
+}
+
+// toConverseImage returns the image block of an image passed inline, the Converse API does not fetch URLs
+func toConverseImage(image schema.MessagePartCommon) (types.ContentBlock, error) {
+	data, mimeType := image.Base64Data, image.MIMEType
+	if data == nil && image.URL != nil {
+		// data URLs, e.g. "data:image/png;base64,..."
+		if header, encoded, ok := strings.Cut(strings.TrimPrefix(*image.URL, "data:"), ";base64,"); ok {
+			data, mimeType = &encoded, header
+		}
+	}
+	if data == nil {
+		return nil, errors.New("bedrock provider: images are only sent inline, as base64 data")
+	}
+	raw, err := base64.StdEncoding.DecodeString(*data)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock provider: invalid base64 image: %w", err)
+	}
+	format := types.ImageFormat(strings.TrimPrefix(strings.ToLower(mimeType), "image/"))
+	if format == "jpg" {
+		format = types.ImageFormatJpeg
+	}
+	return &types.ContentBlockMemberImage{Value: types.ImageBlock{Format: format, Source: &types.ImageSourceMemberBytes{Value: raw}}}, nil
+}
+
+// toConverseTool returns the spec of a tool, its JSON schema is passed as a plain JSON document
+func toConverseTool(t *schema.ToolInfo) (types.Tool, error) {
+	params := map[string]any{"type": "object", "properties": map[string]any{}}
+	if t.ParamsOneOf != nil {
+		js, err := t.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert parameters of tool[%s]: %w", t.Name, err)
+		}
+		data, err := json.Marshal(js)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode parameters of tool[%s]: %w", t.Name, err)
+		}
+		params = nil
+		if err = json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("failed to encode parameters of tool[%s]: %w", t.Name, err)
+		}
+	}
+	spec := types.ToolSpecification{
+		Name:        aws.String(t.Name),
+		InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(params)},
+	}
+	if t.Desc != "" {
+		spec.Description = aws.String(t.Desc)
+	}
+	return &types.ToolMemberToolSpec{Value: spec}, nil
+}
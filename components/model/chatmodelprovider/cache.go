@@ -0,0 +1,391 @@
+package chatmodelprovider
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ResponseCache stores the answers of chat model calls by cache key, see NewCachedChatModel.
+// Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the answer stored under key, false if missing or expired
+	Get(ctx context.Context, key string) (*schema.Message, bool, error)
+	// Set stores the answer under key, it expires after ttl, never if zero
+	Set(ctx context.Context, key string, m *schema.Message, ttl time.Duration) error
+}
+
+type cacheOption struct {
+	ttl   time.Duration
+	force bool
+}
+
+// CacheOptionFn is a function type for configuring the cached chat model
+type CacheOptionFn func(*cacheOption)
+
+// WithCacheTTL sets how long answers are cached, default is forever
+func WithCacheTTL(ttl time.Duration) CacheOptionFn {
+	return func(o *cacheOption) {
+		o.ttl = ttl
+	}
+}
+
+// WithCacheForce caches the calls whatever their temperature.
+// Default is to cache only the calls with a temperature of zero, whose answers are meant to be reproducible.
+func WithCacheForce(force bool) CacheOptionFn {
+	return func(o *cacheOption) {
+		o.force = force
+	}
+}
+
+// CachedChatModel answers the calls already made from a ResponseCache instead of calling the model,
+// e.g. so that evaluation runs repeating the same prompts call the model once per prompt.
+// Calls are identified by the provider and endpoint of the model, their messages, tools and generation params.
+// Cached answers are replayed as streams by Stream.
+type CachedChatModel struct {
+	model.ToolCallingChatModel
+	cache    ResponseCache
+	opts     *cacheOption
+	defaults model.Options // Generation params of the model, overridden by the options of each call
+	provider string        // Provider of the model, its type if it is not a ChatModel
+	baseURL  string        // BaseURL of the model, empty for the default endpoint of the provider
+}
+
+// NewCachedChatModel returns m answering from cache.
+// Calls with a temperature above zero, or without temperature as the default of providers is above zero,
+// bypass the cache unless WithCacheForce is set, as do the calls with implementation-specific options,
+// e.g. openai.WithExtraFields, which are not part of the cache key.
+// The params of m are read from its Config if it is a ChatModel.
+func NewCachedChatModel(m model.ToolCallingChatModel, cache ResponseCache, opts ...CacheOptionFn) *CachedChatModel {
+	o := &cacheOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c := &CachedChatModel{ToolCallingChatModel: m, cache: cache, opts: o}
+	c.provider = c.GetType()
+	if cm, ok := m.(*ChatModel); ok {
+		c.provider, c.baseURL = cm.provider, cm.cfg.BaseURL
+		c.defaults = model.Options{
+			Model:       &cm.cfg.Model,
+			Temperature: cm.cfg.Temperature,
+			TopP:        cm.cfg.TopP,
+			MaxTokens:   cm.cfg.MaxTokens,
+			Stop:        cm.cfg.Stop,
+		}
+	}
+	return c
+}
+
+func (c *CachedChatModel) GetType() string {
+	if typer, ok := c.ToolCallingChatModel.(components.Typer); ok {
+		return typer.GetType()
+	}
+	return "unknown"
+}
+
+func (c *CachedChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	m, err := c.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	defaults := c.defaults
+	defaults.Tools = tools
+	return &CachedChatModel{ToolCallingChatModel: m, cache: c.cache, opts: c.opts, defaults: defaults,
+		provider: c.provider, baseURL: c.baseURL}, nil
+}
+
+func (c *CachedChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	key, ok, err := c.key(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.ToolCallingChatModel.Generate(ctx, input, opts...)
+	}
+	if m, hit, err := c.cache.Get(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to get cached answer: %w", err)
+	} else if hit {
+		return m, nil
+	}
+	out, err := c.ToolCallingChatModel.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// the answer is returned even if it fails to be cached, as by Stream
+	_ = c.cache.Set(ctx, key, out, c.opts.ttl)
+	return out, nil
+}
+
+func (c *CachedChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	key, ok, err := c.key(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.ToolCallingChatModel.Stream(ctx, input, opts...)
+	}
+	if m, hit, err := c.cache.Get(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to get cached answer: %w", err)
+	} else if hit {
+		return schema.StreamReaderFromArray(splitMessage(m)), nil
+	}
+	sr, err := c.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// the answer is cached once the stream completes, failed streams are not cached
+	copies := sr.Copy(2)
+	go func() {
+		defer copies[1].Close()
+		var chunks []*schema.Message
+		for {
+			chunk, err := copies[1].Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+		if m, err := schema.ConcatMessages(chunks); err == nil {
+			_ = c.cache.Set(context.WithoutCancel(ctx), key, m, c.opts.ttl)
+		}
+	}()
+	return copies[0], nil
+}
+
+// cacheKey is the normalized form of a call, hashed into its cache key
+type cacheKey struct {
+	Provider    string             `json:"provider,omitempty"`
+	BaseURL     string             `json:"base_url,omitempty"`
+	Model       string             `json:"model,omitempty"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	TopP        *float32           `json:"top_p,omitempty"`
+	MaxTokens   *int               `json:"max_tokens,omitempty"`
+	Stop        []string           `json:"stop,omitempty"`
+	ToolChoice  *schema.ToolChoice `json:"tool_choice,omitempty"`
+	Tools       []cacheKeyTool     `json:"tools,omitempty"`
+	Messages    []cacheKeyMsg      `json:"messages"`
+}
+
+type cacheKeyTool struct {
+	Name   string `json:"name"`
+	Desc   string `json:"desc,omitempty"`
+	Params any    `json:"params,omitempty"`
+}
+
+// cacheKeyMsg is the part of a message sent to the model, response metadata and extras are left out
+type cacheKeyMsg struct {
+	Role       schema.RoleType `json:"role"`
+	Content    string          `json:"content,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolCalls  []string        `json:"tool_calls,omitempty"`
+	Parts      []any           `json:"parts,omitempty"`
+}
+
+// key returns the cache key of the call, false if the call bypasses the cache
+func (c *CachedChatModel) key(input []*schema.Message, opts []model.Option) (string, bool, error) {
+	defaults := c.defaults
+	o := model.GetCommonOptions(&defaults, opts...)
+	if !c.opts.force && (o.Temperature == nil || *o.Temperature > 0) {
+		return "", false, nil
+	}
+	if hasImplSpecificOptions(opts) {
+		return "", false, nil
+	}
+	k := cacheKey{Provider: c.provider, BaseURL: c.baseURL, Temperature: o.Temperature, TopP: o.TopP, MaxTokens: o.MaxTokens, Stop: o.Stop, ToolChoice: o.ToolChoice}
+	if o.Model != nil {
+		k.Model = *o.Model
+	}
+	for _, t := range o.Tools {
+		params, err := t.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return "", false, fmt.Errorf("failed to convert parameters of tool[%s]: %w", t.Name, err)
+		}
+		k.Tools = append(k.Tools, cacheKeyTool{Name: t.Name, Desc: t.Desc, Params: params})
+	}
+	for _, m := range input {
+		km := cacheKeyMsg{Role: m.Role, Content: m.Content, Name: m.Name, ToolCallID: m.ToolCallID}
+		for _, tc := range m.ToolCalls {
+			km.ToolCalls = append(km.ToolCalls, tc.ID+":"+tc.Function.Name+":"+tc.Function.Arguments)
+		}
+		for _, p := range m.MultiContent {
+			km.Parts = append(km.Parts, p)
+		}
+		for _, p := range m.UserInputMultiContent {
+			km.Parts = append(km.Parts, p)
+		}
+		for _, p := range m.AssistantGenMultiContent {
+			km.Parts = append(km.Parts, p)
+		}
+		k.Messages = append(k.Messages, km)
+	}
+	b, err := json.Marshal(k)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to encode cache key: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), true, nil
+}
+
+// hasImplSpecificOptions reports whether one of the options is specific to the model implementation.
+// Their values are not readable without their type, so that they cannot be part of the cache key.
+func hasImplSpecificOptions(opts []model.Option) bool {
+	for _, opt := range opts {
+		if fn := reflect.ValueOf(opt).FieldByName("implSpecificOptFn"); fn.IsValid() && !fn.IsNil() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewLRUCache returns an in-memory ResponseCache keeping the size most recently used answers
+func NewLRUCache(size int) ResponseCache {
+	return &lruCache{size: size, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+type lruCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Entries from the most to the least recently used
+}
+
+type lruEntry struct {
+	key string
+	// data is the answer in JSON, so that the callers mutating the answers they get do not change the cached one
+	data      []byte
+	expiresAt time.Time // Zero if the entry never expires
+}
+
+func (c *lruCache) Get(_ context.Context, key string) (*schema.Message, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := e.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(e)
+	m := &schema.Message{}
+	if err := json.Unmarshal(entry.data, m); err != nil {
+		return nil, false, err
+	}
+	return m, true, nil
+}
+
+func (c *lruCache) Set(_ context.Context, key string, m *schema.Message, ttl time.Duration) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	entry := &lruEntry{key: key, data: data}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.size > 0 && c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// NewDirCache returns a ResponseCache keeping the answers as JSON files in dir, so that they survive restarts
+// and are shared by the processes using the same dir. The directory is created if missing.
+func NewDirCache(dir string) (ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	return &dirCache{dir: dir}, nil
+}
+
+type dirCache struct {
+	dir string
+}
+
+type dirCacheEntry struct {
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	Message   *schema.Message `json:"message"`
+}
+
+func (c *dirCache) Get(_ context.Context, key string) (*schema.Message, bool, error) {
+	p := filepath.Join(c.dir, key+".json")
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	entry := &dirCacheEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		// a corrupted entry is a miss, it is replaced by the next Set
+		return nil, false, nil
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		_ = os.Remove(p)
+		return nil, false, nil
+	}
+	return entry.Message, true, nil
+}
+
+func (c *dirCache) Set(_ context.Context, key string, m *schema.Message, ttl time.Duration) error {
+	entry := &dirCacheEntry{Message: m}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// write then rename, so that readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, ".cache-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(c.dir, key+".json")); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
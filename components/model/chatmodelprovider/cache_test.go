@@ -0,0 +1,170 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedChatModel(t *testing.T) {
+	ctx := t.Context()
+	zero := float32(0)
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Model: "mock-1", Temperature: &zero})
+	assert.Nil(t, err)
+	mock := cm.ToolCallingChatModel.(*MockChatModel)
+	lru := NewLRUCache(10)
+	cached := NewCachedChatModel(cm, lru)
+	input := []*schema.Message{schema.SystemMessage("be brief"), schema.UserMessage("hello world")}
+
+	for i := 0; i < 3; i++ {
+		out, err := cached.Generate(ctx, input)
+		assert.Nil(t, err)
+		assert.Equal(t, "hello world", out.Content)
+	}
+	assert.Len(t, mock.Calls(), 1)
+
+	// cached answers are replayed as streams
+	sr, err := cached.Stream(ctx, input)
+	assert.Nil(t, err)
+	out, err := schema.ConcatMessageStream(sr)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", out.Content)
+	assert.Len(t, mock.Calls(), 1)
+
+	// the params and the tools are part of the key
+	_, err = cached.Generate(ctx, input, model.WithMaxTokens(10))
+	assert.Nil(t, err)
+	withTools, err := cached.WithTools([]*schema.ToolInfo{{Name: "get_weather", Desc: "Get the weather of a city"}})
+	assert.Nil(t, err)
+	_, err = withTools.Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Len(t, mock.Calls(), 3)
+
+	// calls with a temperature bypass the cache unless forced
+	one := float32(1)
+	_, err = cached.Generate(ctx, input, model.WithTemperature(one))
+	assert.Nil(t, err)
+	_, err = cached.Generate(ctx, input, model.WithTemperature(one))
+	assert.Nil(t, err)
+	assert.Len(t, mock.Calls(), 5)
+	forced := NewCachedChatModel(cm, NewLRUCache(10), WithCacheForce(true))
+	_, err = forced.Generate(ctx, input, model.WithTemperature(one))
+	assert.Nil(t, err)
+	_, err = forced.Generate(ctx, input, model.WithTemperature(one))
+	assert.Nil(t, err)
+	assert.Len(t, mock.Calls(), 6)
+
+	// streamed answers are cached once complete
+	other := []*schema.Message{schema.UserMessage("streamed answer")}
+	sr, err = cached.Stream(ctx, other)
+	assert.Nil(t, err)
+	_, err = schema.ConcatMessageStream(sr)
+	assert.Nil(t, err)
+	key, _, err := cached.key(other, nil)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		_, hit, _ := lru.Get(ctx, key)
+		return hit
+	}, time.Second, 10*time.Millisecond)
+	out, err = cached.Generate(ctx, other)
+	assert.Nil(t, err)
+	assert.Equal(t, "streamed answer", out.Content)
+	assert.Len(t, mock.Calls(), 7)
+
+	// calls with implementation-specific options bypass the cache
+	_, err = cached.Generate(ctx, input, openai.WithExtraFields(map[string]any{"seed": 1}))
+	assert.Nil(t, err)
+	assert.Len(t, mock.Calls(), 8)
+
+	// the endpoint is part of the key
+	otherCM, err := NewChatModel(ctx, &Config{Provider: "mock", Model: "mock-1", Temperature: &zero, BaseURL: "http://other"})
+	assert.Nil(t, err)
+	_, err = NewCachedChatModel(otherCM, lru).Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Len(t, otherCM.ToolCallingChatModel.(*MockChatModel).Calls(), 1)
+
+	// answers failing to be cached are returned
+	out, err = NewCachedChatModel(cm, failingCache{}).Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", out.Content)
+}
+
+// failingCache misses and fails to store the answers
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) (*schema.Message, bool, error) {
+	return nil, false, nil
+}
+
+func (failingCache) Set(context.Context, string, *schema.Message, time.Duration) error {
+	return errors.New("cache unavailable")
+}
+
+func TestResponseCaches(t *testing.T) {
+	ctx := t.Context()
+	lru := NewLRUCache(2)
+	for _, k := range []string{"a", "b", "c"} {
+		assert.NoError(t, lru.Set(ctx, k, schema.AssistantMessage(k, nil), 0))
+	}
+	_, ok, _ := lru.Get(ctx, "a")
+	assert.False(t, ok)
+	m, ok, _ := lru.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.Equal(t, "c", m.Content)
+
+	assert.NoError(t, lru.Set(ctx, "ttl", schema.AssistantMessage("ttl", nil), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ = lru.Get(ctx, "ttl")
+	assert.False(t, ok)
+
+	dir := t.TempDir()
+	disk, err := NewDirCache(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, disk.Set(ctx, "k", schema.AssistantMessage("persisted", nil), 0))
+	assert.NoError(t, disk.Set(ctx, "ttl", schema.AssistantMessage("ttl", nil), time.Millisecond))
+	disk, err = NewDirCache(dir)
+	assert.NoError(t, err)
+	m, ok, err = disk.Get(ctx, "k")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "persisted", m.Content)
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = disk.Get(ctx, "ttl")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCachedChatModelCopies(t *testing.T) {
+	ctx := t.Context()
+	zero := float32(0)
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Model: "mock-1", Temperature: &zero, Mock: &MockConfig{Repeat: true,
+		Responses: []*MockResponse{MockToolCall("get_weather", `{"city":"Paris"}`)}}})
+	assert.Nil(t, err)
+	cached := NewCachedChatModel(cm, NewLRUCache(10))
+	input := []*schema.Message{schema.UserMessage("weather in Paris?")}
+
+	// the answers returned on a miss and on a hit are mutated by the caller
+	for i := 0; i < 2; i++ {
+		out, err := cached.Generate(ctx, input)
+		assert.Nil(t, err)
+		out.Content = "changed"
+		out.ToolCalls[0].Function.Arguments = `{"city":"Rome"}`
+		out.ToolCalls = append(out.ToolCalls, schema.ToolCall{ID: "call_2"})
+		out.Extra = map[string]any{"changed": true}
+	}
+
+	out, err := cached.Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Empty(t, out.Content)
+	assert.Nil(t, out.Extra)
+	if assert.Len(t, out.ToolCalls, 1) {
+		assert.Equal(t, `{"city":"Paris"}`, out.ToolCalls[0].Function.Arguments)
+	}
+	assert.Len(t, cm.ToolCallingChatModel.(*MockChatModel).Calls(), 1)
+}
@@ -0,0 +1,220 @@
+package chatmodelprovider
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Modality is a kind of content a model takes as input or produces as output
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityImage Modality = "image"
+	ModalityAudio Modality = "audio"
+	ModalityVideo Modality = "video"
+	ModalityFile  Modality = "file" // Documents such as PDF
+)
+
+// Capabilities describes what a model supports, so that agents adapt their prompts and fail early on unsupported features.
+// Zero values mean unknown or unsupported.
+type Capabilities struct {
+	// ContextLength is the max number of tokens of the input and the output together.
+	ContextLength int
+	// MaxOutputTokens is the max number of tokens the model generates in one call.
+	MaxOutputTokens int
+	// InputModalities are the kinds of content the model takes as input, text only if empty.
+	InputModalities []Modality
+	// OutputModalities are the kinds of content the model generates, text only if empty.
+	OutputModalities []Modality
+	// ToolCalling reports whether the model calls tools.
+	ToolCalling bool
+	// StreamingToolCalls reports whether tool calls are streamed in chunks, rather than sent at once at the end of the stream.
+	StreamingToolCalls bool
+	// JSONMode reports whether the model can be constrained to answer with JSON.
+	JSONMode bool
+	// Reasoning reports whether the model outputs its reasoning, see schema.Message.ReasoningContent.
+	Reasoning bool
+}
+
+// SupportsInput reports whether the model takes the modality as input
+func (c Capabilities) SupportsInput(m Modality) bool {
+	return m == ModalityText || slices.Contains(c.InputModalities, m)
+}
+
+// SupportsOutput reports whether the model generates the modality
+func (c Capabilities) SupportsOutput(m Modality) bool {
+	return m == ModalityText || slices.Contains(c.OutputModalities, m)
+}
+
+// Capabilities returns the capabilities of the model, from Config.Capabilities if set, otherwise from the catalog.
+// It returns false if the model is not in the catalog, see RegisterCapabilities.
+func (c *ChatModel) Capabilities() (Capabilities, bool) {
+	if c.cfg.Capabilities != nil {
+		return *c.cfg.Capabilities, true
+	}
+	return LookupCapabilities(c.provider, c.cfg.Model)
+}
+
+var (
+	catalogMu sync.RWMutex
+	// catalog holds the capabilities by provider then by model name prefix, see LookupCapabilities
+	catalog = builtinCatalog()
+)
+
+// catalogAliases are the providers sharing the models of another provider
+var catalogAliases = map[string]string{
+	"azure":     "openai",
+	"vertex_ai": "gemini",
+	"bedrock":   "anthropic",
+}
+
+// RegisterCapabilities adds the capabilities of the models of provider whose name starts with modelPrefix
+// to the catalog, replacing the built-in entry with the same prefix if any.
+// Register custom or fine-tuned models, or correct the built-in entries, at init.
+func RegisterCapabilities(provider, modelPrefix string, caps Capabilities) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	models, ok := catalog[provider]
+	if !ok {
+		models = make(map[string]Capabilities)
+		catalog[provider] = models
+	}
+	models[strings.ToLower(modelPrefix)] = caps
+}
+
+// LookupCapabilities returns the capabilities of the model of provider from the catalog.
+// The entry with the longest prefix of the model name wins, so that "gpt-4o-2024-08-06" matches "gpt-4o",
+// and "gpt-4o-mini" matches its own entry. Models of OpenRouter are looked up by their vendor, e.g. "openai/gpt-4o",
+// and models of Bedrock without their region and vendor, e.g. "us.anthropic.claude-sonnet-4-20250514-v1:0".
+func LookupCapabilities(provider, modelName string) (Capabilities, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	modelName = strings.ToLower(modelName)
+	if _, name, ok := strings.Cut(modelName, "anthropic."); ok && provider == "bedrock" {
+		modelName = name
+	}
+	if caps, ok := lookupCapabilities(provider, modelName); ok {
+		return caps, true
+	}
+	if alias, ok := catalogAliases[provider]; ok {
+		return lookupCapabilities(alias, modelName)
+	}
+	if vendor, name, ok := strings.Cut(modelName, "/"); ok && provider == "openrouter" {
+		if vendor == "google" {
+			vendor = "gemini"
+		}
+		return lookupCapabilities(vendor, name)
+	}
+	return Capabilities{}, false
+}
+
+func lookupCapabilities(provider, modelName string) (Capabilities, bool) {
+	var (
+		caps   Capabilities
+		prefix string
+		found  bool
+	)
+	for p, c := range catalog[provider] {
+		if strings.HasPrefix(modelName, p) && (!found || len(p) > len(prefix)) {
+			caps, prefix, found = c, p, true
+		}
+	}
+	return caps, found
+}
+
+func builtinCatalog() map[string]map[string]Capabilities {
+	var (
+		vision     = []Modality{ModalityImage}
+		visual     = []Modality{ModalityImage, ModalityVideo}
+		multimodal = []Modality{ModalityImage, ModalityAudio, ModalityVideo, ModalityFile}
+	)
+	return map[string]map[string]Capabilities{
+		"volcengine": {
+			"doubao-seed-1-6":                {ContextLength: 256000, MaxOutputTokens: 32000, InputModalities: visual, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"doubao-1-5-thinking-pro":        {ContextLength: 128000, MaxOutputTokens: 16000, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"doubao-1-5-thinking-vision-pro": {ContextLength: 128000, MaxOutputTokens: 16000, InputModalities: visual, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"doubao-1-5-pro-32k":             {ContextLength: 32000, MaxOutputTokens: 12000, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"doubao-1-5-pro-256k":            {ContextLength: 256000, MaxOutputTokens: 12000, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"doubao-1-5-lite-32k":            {ContextLength: 32000, MaxOutputTokens: 12000, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"doubao-1-5-vision-pro":          {ContextLength: 128000, MaxOutputTokens: 16000, InputModalities: visual, ToolCalling: true, StreamingToolCalls: true},
+			"deepseek-r1":                    {ContextLength: 128000, MaxOutputTokens: 16000, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"deepseek-v3":                    {ContextLength: 128000, MaxOutputTokens: 16000, ToolCalling: true, StreamingToolCalls: true},
+		},
+		"openai": {
+			"gpt-4o":        {ContextLength: 128000, MaxOutputTokens: 16384, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"gpt-4o-mini":   {ContextLength: 128000, MaxOutputTokens: 16384, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"gpt-4.1":       {ContextLength: 1047576, MaxOutputTokens: 32768, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"gpt-5":         {ContextLength: 400000, MaxOutputTokens: 128000, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"o1":            {ContextLength: 200000, MaxOutputTokens: 100000, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"o3":            {ContextLength: 200000, MaxOutputTokens: 100000, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"o3-mini":       {ContextLength: 200000, MaxOutputTokens: 100000, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"o4-mini":       {ContextLength: 200000, MaxOutputTokens: 100000, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"gpt-3.5-turbo": {ContextLength: 16385, MaxOutputTokens: 4096, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+		},
+		"anthropic": {
+			"claude-3-5-haiku":  {ContextLength: 200000, MaxOutputTokens: 8192, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true},
+			"claude-3-5-sonnet": {ContextLength: 200000, MaxOutputTokens: 8192, InputModalities: []Modality{ModalityImage, ModalityFile}, ToolCalling: true, StreamingToolCalls: true},
+			"claude-3-7-sonnet": {ContextLength: 200000, MaxOutputTokens: 64000, InputModalities: []Modality{ModalityImage, ModalityFile}, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"claude-sonnet-4":   {ContextLength: 200000, MaxOutputTokens: 64000, InputModalities: []Modality{ModalityImage, ModalityFile}, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"claude-opus-4":     {ContextLength: 200000, MaxOutputTokens: 32000, InputModalities: []Modality{ModalityImage, ModalityFile}, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+		},
+		"gemini": {
+			"gemini-2.0-flash": {ContextLength: 1048576, MaxOutputTokens: 8192, InputModalities: multimodal, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"gemini-2.5-flash": {ContextLength: 1048576, MaxOutputTokens: 65536, InputModalities: multimodal, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"gemini-2.5-pro":   {ContextLength: 1048576, MaxOutputTokens: 65536, InputModalities: multimodal, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+		},
+		"deepseek": {
+			"deepseek-chat":     {ContextLength: 128000, MaxOutputTokens: 8192, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"deepseek-reasoner": {ContextLength: 128000, MaxOutputTokens: 65536, JSONMode: true, Reasoning: true},
+		},
+		"dashscope": {
+			"qwen-max":    {ContextLength: 32768, MaxOutputTokens: 8192, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"qwen-plus":   {ContextLength: 131072, MaxOutputTokens: 16384, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"qwen-turbo":  {ContextLength: 1000000, MaxOutputTokens: 16384, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"qwen-vl-max": {ContextLength: 131072, MaxOutputTokens: 8192, InputModalities: visual, ToolCalling: true, StreamingToolCalls: true},
+			"qwq-plus":    {ContextLength: 131072, MaxOutputTokens: 8192, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"qwen3":       {ContextLength: 131072, MaxOutputTokens: 16384, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+		},
+		"mistral": {
+			"mistral-large":  {ContextLength: 131072, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"mistral-medium": {ContextLength: 131072, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"mistral-small":  {ContextLength: 131072, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"codestral":      {ContextLength: 256000, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"magistral":      {ContextLength: 40000, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+		},
+		"groq": {
+			"llama-3.3-70b-versatile": {ContextLength: 131072, MaxOutputTokens: 32768, ToolCalling: true, StreamingToolCalls: true},
+			"llama-3.1-8b-instant":    {ContextLength: 131072, MaxOutputTokens: 131072, ToolCalling: true, StreamingToolCalls: true},
+			"openai/gpt-oss":          {ContextLength: 131072, MaxOutputTokens: 65536, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+		},
+		"xai": {
+			"grok-3": {ContextLength: 131072, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"grok-4": {ContextLength: 256000, InputModalities: vision, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+		},
+		"moonshot": {
+			"moonshot-v1-8k":   {ContextLength: 8192, ToolCalling: true, StreamingToolCalls: true},
+			"moonshot-v1-32k":  {ContextLength: 32768, ToolCalling: true, StreamingToolCalls: true},
+			"moonshot-v1-128k": {ContextLength: 131072, ToolCalling: true, StreamingToolCalls: true},
+			"kimi-k2":          {ContextLength: 131072, ToolCalling: true, StreamingToolCalls: true},
+		},
+		"zhipu": {
+			"glm-4-plus": {ContextLength: 128000, MaxOutputTokens: 4096, ToolCalling: true, StreamingToolCalls: true, JSONMode: true},
+			"glm-4.5":    {ContextLength: 128000, MaxOutputTokens: 98304, ToolCalling: true, StreamingToolCalls: true, JSONMode: true, Reasoning: true},
+			"glm-4.5v":   {ContextLength: 64000, MaxOutputTokens: 16384, InputModalities: visual, ToolCalling: true, Reasoning: true},
+		},
+		"minimax": {
+			"minimax-m1":      {ContextLength: 1000000, MaxOutputTokens: 80000, ToolCalling: true, StreamingToolCalls: true, Reasoning: true},
+			"minimax-text-01": {ContextLength: 1000192, ToolCalling: true, StreamingToolCalls: true},
+		},
+		"ollama": {
+			// the context of ollama models is bounded by their num_ctx option, the lengths are the max of the models
+			"llama3.1":    {ContextLength: 131072, ToolCalling: true},
+			"llama3.2":    {ContextLength: 131072, ToolCalling: true},
+			"qwen2.5":     {ContextLength: 32768, ToolCalling: true},
+			"qwen3":       {ContextLength: 40960, ToolCalling: true, Reasoning: true},
+			"deepseek-r1": {ContextLength: 131072, Reasoning: true},
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package chatmodelprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	ctx := t.Context()
+	cm, err := NewChatModel(ctx, &Config{Provider: "openai", Model: "gpt-4o-mini-2024-07-18"})
+	assert.Nil(t, err)
+	caps, ok := cm.Capabilities()
+	assert.True(t, ok)
+	assert.Equal(t, 128000, caps.ContextLength)
+	assert.True(t, caps.ToolCalling)
+	assert.True(t, caps.SupportsInput(ModalityImage))
+	assert.False(t, caps.SupportsInput(ModalityAudio))
+	assert.False(t, caps.Reasoning)
+
+	// the longest prefix wins
+	caps, ok = LookupCapabilities("openai", "o3-mini")
+	assert.True(t, ok)
+	assert.False(t, caps.SupportsInput(ModalityImage))
+
+	caps, ok = LookupCapabilities("azure", "gpt-4.1")
+	assert.True(t, ok)
+	assert.Equal(t, 1047576, caps.ContextLength)
+
+	caps, ok = LookupCapabilities("openrouter", "anthropic/claude-sonnet-4")
+	assert.True(t, ok)
+	assert.True(t, caps.Reasoning)
+
+	_, ok = LookupCapabilities("openai", "my-fine-tune")
+	assert.False(t, ok)
+	RegisterCapabilities("openai", "my-fine-tune", Capabilities{ContextLength: 4096})
+	caps, ok = LookupCapabilities("openai", "My-Fine-Tune-v2")
+	assert.True(t, ok)
+	assert.Equal(t, 4096, caps.ContextLength)
+
+	cm, err = NewChatModel(ctx, &Config{Provider: "openai", Model: "gpt-4o", Capabilities: &Capabilities{ContextLength: 8000}})
+	assert.Nil(t, err)
+	caps, ok = cm.Capabilities()
+	assert.True(t, ok)
+	assert.Equal(t, 8000, caps.ContextLength)
+	assert.False(t, caps.ToolCalling)
+}
@@ -0,0 +1,191 @@
+package chatmodelprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cassetteMode is the mode of a "record:<provider>" or "replay:<provider>" provider
+type cassetteMode string
+
+const (
+	cassetteRecord cassetteMode = "record"
+	cassetteReplay cassetteMode = "replay"
+)
+
+// ErrNoInteraction is returned in replay mode for the requests not recorded in the cassette
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// cassette is the content of a cassette file
+type cassette struct {
+	Interactions []*interaction `json:"interactions"`
+}
+
+// interaction is an HTTP exchange with a provider.
+// Request headers are not recorded, so that API keys are never written to cassettes.
+type interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers http.Header `json:"headers,omitempty"`
+		Body    string      `json:"body"`
+	} `json:"response"`
+}
+
+// secretQueryParams are the query parameters carrying API keys, left out of the recorded URLs
+var secretQueryParams = []string{"key", "api_key", "api-key", "apikey", "access_token"}
+
+// newCassetteClient returns an HTTP client recording the exchanges to the cassette at path,
+// or replaying them from it without network. Recording sends the requests with base, or the default client.
+func newCassetteClient(mode cassetteMode, path string, base *http.Client) (*http.Client, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%s provider: Cassette is required", mode)
+	}
+	t := &cassetteTransport{mode: mode, path: path, used: make(map[int]bool)}
+	switch mode {
+	case cassetteRecord:
+		t.next = http.DefaultTransport
+		if base != nil && base.Transport != nil {
+			t.next = base.Transport
+		}
+	case cassetteReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err = json.Unmarshal(data, &t.cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette[%s]: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid cassette mode: %s", mode)
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+type cassetteTransport struct {
+	mode cassetteMode
+	path string
+	next http.RoundTripper // Transport of the recorded requests
+
+	mu       sync.Mutex
+	cassette cassette
+	used     map[int]bool // Replayed interactions, identical requests are replayed in the recorded order
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	u := recordedURL(req.URL)
+	if t.mode == cassetteReplay {
+		return t.replay(req, u, body)
+	}
+
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// streams are recorded once complete, and replayed at once
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	it := &interaction{}
+	it.Request.Method, it.Request.URL, it.Request.Body = req.Method, u, string(body)
+	it.Response.Status, it.Response.Body = resp.StatusCode, string(respBody)
+	it.Response.Headers = resp.Header.Clone()
+	it.Response.Headers.Del("Set-Cookie")
+	if err = t.save(it); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (t *cassetteTransport) replay(req *http.Request, u string, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, it := range t.cassette.Interactions {
+		if t.used[i] || it.Request.Method != req.Method || it.Request.URL != u || !sameBody(it.Request.Body, body) {
+			continue
+		}
+		t.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        it.Response.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(it.Response.Body))),
+			ContentLength: int64(len(it.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, u)
+}
+
+// save appends the interaction to the cassette, the file is rewritten so that it is complete after each request
+func (t *cassetteTransport) save(it *interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, it)
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+	if err = os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+func recordedURL(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	for _, p := range secretQueryParams {
+		q.Del(p)
+	}
+	c.RawQuery = q.Encode()
+	c.User = nil
+	return c.String()
+}
+
+// sameBody compares JSON bodies regardless of the formatting and of the order of the keys
+func sameBody(recorded string, body []byte) bool {
+	if recorded == string(body) {
+		return true
+	}
+	var a, b any
+	if json.Unmarshal([]byte(recorded), &a) != nil || json.Unmarshal(body, &b) != nil {
+		return false
+	}
+	ra, _ := json.Marshal(a)
+	rb, _ := json.Marshal(b)
+	return bytes.Equal(ra, rb)
+}
@@ -0,0 +1,63 @@
+package chatmodelprovider
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette(t *testing.T) {
+	ctx := t.Context()
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		answer := "Paris"
+		if strings.Contains(string(body), "Italy") {
+			answer = "Rome"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"` + answer + `"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":1,"total_tokens":11}}`))
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassettes", "capitals.json")
+	cfg := &Config{Provider: "record:openai", APIKey: "secret-key", BaseURL: srv.URL, Model: "gpt-4o", Cassette: path}
+	cm, err := NewChatModel(ctx, cfg)
+	assert.Nil(t, err)
+	for _, country := range []string{"France", "Italy"} {
+		_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of " + country + "?")})
+		assert.Nil(t, err)
+	}
+	srv.Close()
+	assert.Equal(t, 2, requests)
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret-key")
+
+	cm, err = NewChatModel(ctx, &Config{Provider: "replay:openai", BaseURL: srv.URL, Model: "gpt-4o", Cassette: path})
+	assert.Nil(t, err)
+	out, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of Italy?")})
+	assert.Nil(t, err)
+	assert.Equal(t, "Rome", out.Content)
+	out, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of France?")})
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", out.Content)
+	caps, ok := cm.Capabilities()
+	assert.True(t, ok)
+	assert.True(t, caps.ToolCalling)
+
+	_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of Spain?")})
+	assert.ErrorIs(t, err, ErrNoInteraction)
+
+	_, err = NewChatModel(ctx, &Config{Provider: "replay:openai", Cassette: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
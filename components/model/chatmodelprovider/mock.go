@@ -0,0 +1,251 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrMockExhausted is returned by the mock provider once its scripted responses are all used
+var ErrMockExhausted = errors.New("mock responses exhausted")
+
+// MockResponse is a scripted answer of the mock provider
+type MockResponse struct {
+	// Message is the answer, streamed word by word by Stream, with its tool calls in the last chunk.
+	Message *schema.Message
+	// Chunks are the chunks sent by Stream, overriding the chunks of Message.
+	Chunks []*schema.Message
+	// Err is returned instead of the answer.
+	Err error
+	// Latency is the delay before the answer, or before the first chunk.
+	Latency time.Duration
+	// ChunkInterval is the delay between two chunks.
+	ChunkInterval time.Duration
+}
+
+// MockText returns a MockResponse answering text
+func MockText(text string) *MockResponse {
+	return &MockResponse{Message: schema.AssistantMessage(text, nil)}
+}
+
+// MockToolCall returns a MockResponse calling the tool name with the JSON arguments
+func MockToolCall(name, arguments string) *MockResponse {
+	return &MockResponse{Message: schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_" + name,
+		Type:     "function",
+		Function: schema.FunctionCall{Name: name, Arguments: arguments},
+	}})}
+}
+
+// MockError returns a MockResponse failing with err
+func MockError(err error) *MockResponse {
+	return &MockResponse{Err: err}
+}
+
+// MockConfig scripts the answers of the mock provider.
+// A nil MockConfig answers with the content of the last input message.
+type MockConfig struct {
+	// Responses are the answers of the successive calls, ErrMockExhausted is returned once they are all used.
+	Responses []*MockResponse
+	// Repeat answers the calls after the last response with the last response.
+	Repeat bool
+	// Respond answers the calls once the responses are all used, e.g. depending on the input.
+	Respond func(ctx context.Context, input []*schema.Message, tools []*schema.ToolInfo) (*MockResponse, error)
+}
+
+// MockCall is a call received by the mock provider
+type MockCall struct {
+	Input []*schema.Message
+	Tools []*schema.ToolInfo
+}
+
+// MockChatModel is the chat model of the mock provider, it answers the scripted responses of its MockConfig
+// without network, and records the calls it receives. The models returned by WithTools share its script and calls.
+type MockChatModel struct {
+	cfg   *MockConfig
+	state *mockState
+	tools []*schema.ToolInfo
+}
+
+type mockState struct {
+	mu    sync.Mutex
+	next  int
+	calls []MockCall
+}
+
+// NewMockChatModel returns a MockChatModel, see the "mock" provider
+func NewMockChatModel(cfg *MockConfig) *MockChatModel {
+	return &MockChatModel{cfg: cfg, state: &mockState{}}
+}
+
+// Calls returns the calls received so far
+func (m *MockChatModel) Calls() []MockCall {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+	return append([]MockCall(nil), m.state.calls...)
+}
+
+func (m *MockChatModel) GetType() string {
+	return string(mockModelType)
+}
+
+func (m *MockChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &MockChatModel{cfg: m.cfg, state: m.state, tools: tools}, nil
+}
+
+func (m *MockChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	resp, err := m.respond(ctx, input, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = sleep(ctx, resp.Latency); err != nil {
+		return nil, err
+	}
+	if resp.Message == nil {
+		return concatChunks(resp.Chunks)
+	}
+	return m.withMeta(input, resp.Message), nil
+}
+
+func (m *MockChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	resp, err := m.respond(ctx, input, opts)
+	if err != nil {
+		return nil, err
+	}
+	chunks := resp.Chunks
+	if chunks == nil {
+		chunks = splitMessage(m.withMeta(input, resp.Message))
+	}
+	sr, sw := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer sw.Close()
+		for i, chunk := range chunks {
+			delay := resp.ChunkInterval
+			if i == 0 {
+				delay = resp.Latency
+			}
+			if err := sleep(ctx, delay); err != nil {
+				sw.Send(nil, err)
+				return
+			}
+			if closed := sw.Send(chunk, nil); closed {
+				return
+			}
+		}
+	}()
+	return sr, nil
+}
+
+// respond records the call and returns its scripted response
+func (m *MockChatModel) respond(ctx context.Context, input []*schema.Message, opts []model.Option) (*MockResponse, error) {
+	tools := model.GetCommonOptions(&model.Options{Tools: m.tools}, opts...).Tools
+	m.state.mu.Lock()
+	m.state.calls = append(m.state.calls, MockCall{Input: input, Tools: tools})
+	var resp *MockResponse
+	switch {
+	case m.cfg == nil:
+		content := ""
+		if len(input) > 0 {
+			content = input[len(input)-1].Content
+		}
+		resp = MockText(content)
+	case m.state.next < len(m.cfg.Responses):
+		resp = m.cfg.Responses[m.state.next]
+		m.state.next++
+	case m.cfg.Respond != nil:
+	case m.cfg.Repeat && len(m.cfg.Responses) > 0:
+		resp = m.cfg.Responses[len(m.cfg.Responses)-1]
+	}
+	m.state.mu.Unlock()
+
+	if resp == nil && m.cfg != nil && m.cfg.Respond != nil {
+		var err error
+		resp, err = m.cfg.Respond(ctx, input, tools)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp == nil {
+		return nil, ErrMockExhausted
+	}
+	if resp.Err != nil {
+		if err := sleep(ctx, resp.Latency); err != nil {
+			return nil, err
+		}
+		return nil, resp.Err
+	}
+	if resp.Message == nil && len(resp.Chunks) == 0 {
+		return nil, fmt.Errorf("mock response has neither message nor chunks")
+	}
+	return resp, nil
+}
+
+// withMeta returns a copy of the answer with a finish reason and a token usage estimated from the input
+func (m *MockChatModel) withMeta(input []*schema.Message, answer *schema.Message) *schema.Message {
+	out := *answer
+	if out.Role == "" {
+		out.Role = schema.Assistant
+	}
+	if out.ResponseMeta == nil {
+		finish := "stop"
+		if len(out.ToolCalls) > 0 {
+			finish = "tool_calls"
+		}
+		prompt, _ := defaultTokenEstimator.CountTokens(context.Background(), input, m.tools)
+		completion := defaultTokenEstimator.text(out.Content) + defaultTokenEstimator.text(out.ReasoningContent)
+		out.ResponseMeta = &schema.ResponseMeta{
+			FinishReason: finish,
+			Usage:        &schema.TokenUsage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+		}
+	}
+	return &out
+}
+
+// splitMessage splits the answer into stream chunks: the reasoning, the words of the content,
+// then the tool calls and the response meta
+func splitMessage(m *schema.Message) []*schema.Message {
+	var chunks []*schema.Message
+	if m.ReasoningContent != "" {
+		chunks = append(chunks, &schema.Message{Role: m.Role, ReasoningContent: m.ReasoningContent})
+	}
+	for _, word := range strings.SplitAfter(m.Content, " ") {
+		if word != "" {
+			chunks = append(chunks, &schema.Message{Role: m.Role, Content: word})
+		}
+	}
+	last := &schema.Message{Role: m.Role, ResponseMeta: m.ResponseMeta}
+	for i, tc := range m.ToolCalls {
+		index := i
+		tc.Index = &index
+		last.ToolCalls = append(last.ToolCalls, tc)
+	}
+	return append(chunks, last)
+}
+
+func concatChunks(chunks []*schema.Message) (*schema.Message, error) {
+	m, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to concat mock chunks: %w", err)
+	}
+	return m, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockProvider(t *testing.T) {
+	ctx := t.Context()
+	errRateLimited := errors.New("rate limited")
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Mock: &MockConfig{Responses: []*MockResponse{
+		MockToolCall("get_weather", `{"city":"Paris"}`),
+		MockText("it is sunny in Paris"),
+		MockError(errRateLimited),
+		{Message: schema.AssistantMessage("slow", nil), Latency: time.Second},
+	}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "Mock", cm.GetType())
+
+	tool := &schema.ToolInfo{Name: "get_weather", Desc: "Get the weather of a city"}
+	withTools, err := cm.WithTools([]*schema.ToolInfo{tool})
+	assert.Nil(t, err)
+	out, err := withTools.Generate(ctx, []*schema.Message{schema.UserMessage("weather in Paris?")})
+	assert.Nil(t, err)
+	if assert.Len(t, out.ToolCalls, 1) {
+		assert.Equal(t, `{"city":"Paris"}`, out.ToolCalls[0].Function.Arguments)
+	}
+	assert.Equal(t, "tool_calls", out.ResponseMeta.FinishReason)
+	assert.Greater(t, out.ResponseMeta.Usage.PromptTokens, 0)
+
+	sr, err := withTools.Stream(ctx, []*schema.Message{schema.UserMessage("weather in Paris?")})
+	assert.Nil(t, err)
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.Nil(t, err)
+		chunks = append(chunks, chunk)
+	}
+	assert.Len(t, chunks, 6)
+	out, err = schema.ConcatMessages(chunks)
+	assert.Nil(t, err)
+	assert.Equal(t, "it is sunny in Paris", out.Content)
+
+	_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("again")})
+	assert.ErrorIs(t, err, errRateLimited)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = cm.Generate(timeoutCtx, []*schema.Message{schema.UserMessage("again")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("again")})
+	assert.ErrorIs(t, err, ErrMockExhausted)
+
+	mock := cm.ToolCallingChatModel.(*MockChatModel)
+	calls := mock.Calls()
+	assert.Len(t, calls, 5)
+	assert.Equal(t, []*schema.ToolInfo{tool}, calls[0].Tools)
+	assert.Equal(t, "weather in Paris?", calls[0].Input[0].Content)
+
+	// without script, the mock echoes the last message
+	cm, err = NewChatModel(ctx, &Config{Provider: "mock"})
+	assert.Nil(t, err)
+	out, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("hello")})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", out.Content)
+
+	cm, err = NewChatModel(ctx, &Config{Provider: "mock", Mock: &MockConfig{
+		Responses: []*MockResponse{{Chunks: []*schema.Message{schema.AssistantMessage("a", nil), schema.AssistantMessage("b", nil)}}},
+		Respond: func(_ context.Context, input []*schema.Message, _ []*schema.ToolInfo) (*MockResponse, error) {
+			return MockText("you said " + input[0].Content), nil
+		},
+	}})
+	assert.Nil(t, err)
+	out, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", out.Content)
+	out, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+	assert.Nil(t, err)
+	assert.Equal(t, "you said hi", out.Content)
+}
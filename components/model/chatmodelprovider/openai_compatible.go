@@ -0,0 +1,159 @@
+package chatmodelprovider
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+)
+
+// openaiCompatibleProvider is the provider of the endpoints serving the OpenAI API, configured with Config.Endpoint
+const openaiCompatibleProvider = "openai_compatible"
+
+// EndpointPreset describes an endpoint serving the OpenAI chat completions API, see RegisterEndpointPreset.
+// The preset of a provider is the default of Config.Endpoint.
+type EndpointPreset struct {
+	// BaseURL is the default BaseURL of the endpoint.
+	BaseURL string
+	// AuthHeader is the header carrying the API key as is, e.g. "api-key" or "x-api-key".
+	// Default is the Authorization header with the Bearer scheme.
+	AuthHeader string
+	// Headers are sent with each request, e.g. the HTTP-Referer and X-Title identifying the app to OpenRouter,
+	// set with Config.Endpoint.
+	Headers map[string]string
+	// MaxCompletionTokens sends Config.MaxTokens as max_completion_tokens, as the OpenAI API does,
+	// rather than as max_tokens, which most compatible endpoints expect. Default is false.
+	MaxCompletionTokens *bool
+	// ResponseFormat is the response format constraining the answers of GenerateStructured, default is "json_schema".
+	// The schema of the endpoints only accepting "json_object" is described in the prompt.
+	ResponseFormat string
+}
+
+const (
+	responseFormatJSONSchema = "json_schema"
+	responseFormatJSONObject = "json_object"
+)
+
+var (
+	presetsMu sync.RWMutex
+	// endpointPresets are the providers served with the OpenAI client, by provider name
+	endpointPresets = map[string]EndpointPreset{
+		openaiCompatibleProvider: {},
+		"openrouter":             {BaseURL: "https://openrouter.ai/api/v1"},
+		"vllm":                   {BaseURL: "http://localhost:8000/v1"},
+		"lmstudio":               {BaseURL: "http://localhost:1234/v1"},
+		"siliconflow":            {BaseURL: "https://api.siliconflow.cn/v1"},
+		"mistral":                {BaseURL: "https://api.mistral.ai/v1"},
+		"groq":                   {BaseURL: "https://api.groq.com/openai/v1"},
+		"xai":                    {BaseURL: "https://api.x.ai/v1"},
+		"moonshot":               {BaseURL: "https://api.moonshot.cn/v1", ResponseFormat: responseFormatJSONObject},
+		"zhipu":                  {BaseURL: "https://open.bigmodel.cn/api/paas/v4", ResponseFormat: responseFormatJSONObject},
+		"minimax":                {BaseURL: "https://api.minimaxi.com/v1", ResponseFormat: responseFormatJSONObject},
+		"hunyuan":                {BaseURL: "https://api.hunyuan.cloud.tencent.com/v1", ResponseFormat: responseFormatJSONObject},
+	}
+)
+
+// RegisterEndpointPreset adds the provider name served by the OpenAI-compatible endpoint of the preset,
+// replacing the built-in preset with the same name if any, e.g. for internal gateways. Register presets at init.
+func RegisterEndpointPreset(provider string, preset EndpointPreset) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	endpointPresets[strings.ToLower(provider)] = preset
+}
+
+// LookupEndpointPreset returns the preset of the provider
+func LookupEndpointPreset(provider string) (EndpointPreset, bool) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	preset, ok := endpointPresets[strings.ToLower(provider)]
+	return preset, ok
+}
+
+// merge returns the preset with the set fields of the override, the headers are merged
+func (p EndpointPreset) merge(override *EndpointPreset) EndpointPreset {
+	if override == nil {
+		return p
+	}
+	if override.BaseURL != "" {
+		p.BaseURL = override.BaseURL
+	}
+	if override.AuthHeader != "" {
+		p.AuthHeader = override.AuthHeader
+	}
+	if override.MaxCompletionTokens != nil {
+		p.MaxCompletionTokens = override.MaxCompletionTokens
+	}
+	if override.ResponseFormat != "" {
+		p.ResponseFormat = override.ResponseFormat
+	}
+	if len(override.Headers) > 0 {
+		headers := maps.Clone(p.Headers)
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		maps.Copy(headers, override.Headers)
+		p.Headers = headers
+	}
+	return p
+}
+
+// toOpenAICompatibleConfig returns the config of the OpenAI client sending the requests of the endpoint
+func (c *Config) toOpenAICompatibleConfig(provider string, preset EndpointPreset) (*openai.ChatModelConfig, error) {
+	preset = preset.merge(c.Endpoint)
+	cfg := c.toOpenAIConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = preset.BaseURL
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%s provider: BaseURL is required", provider)
+	}
+	switch preset.ResponseFormat {
+	case "", responseFormatJSONSchema, responseFormatJSONObject:
+	default:
+		return nil, fmt.Errorf("%s provider: invalid response format: %s", provider, preset.ResponseFormat)
+	}
+	if preset.MaxCompletionTokens == nil || !*preset.MaxCompletionTokens {
+		cfg.MaxTokens, cfg.MaxCompletionTokens = cfg.MaxCompletionTokens, nil
+	}
+
+	headers := maps.Clone(preset.Headers)
+	if preset.AuthHeader != "" && c.APIKey != "" {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[preset.AuthHeader] = c.APIKey
+		// the OpenAI client sends the Authorization header with the key, unless it is empty
+		cfg.APIKey = ""
+	}
+	if len(headers) > 0 {
+		base := c.HTTPClient
+		if base == nil {
+			base = &http.Client{}
+		}
+		client := *base
+		client.Transport = &headerTransport{headers: headers, next: base.Transport}
+		cfg.HTTPClient = &client
+	}
+	return cfg, nil
+}
+
+// headerTransport sets the headers of the endpoint on the requests
+type headerTransport struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
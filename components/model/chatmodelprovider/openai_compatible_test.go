@@ -0,0 +1,104 @@
+package chatmodelprovider
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAICompatible(t *testing.T) {
+	ctx := t.Context()
+	f := newFakeVendor(t, `{"id":"1","object":"chat.completion","model":"m","choices":[{"index":0,`+
+		`"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}],`+
+		`"usage":{"prompt_tokens":10,"completion_tokens":1,"total_tokens":11}}`)
+	input := []*schema.Message{schema.UserMessage("capital of France?")}
+	maxTokens := 100
+	yes, no := true, false
+
+	_, err := NewChatModel(ctx, &Config{Provider: "openai_compatible", Model: "m"})
+	assert.ErrorContains(t, err, "BaseURL is required")
+
+	// the app headers of OpenRouter are set with the endpoint of the config
+	cm, err := NewChatModel(ctx, &Config{
+		Provider:   "openrouter",
+		APIKey:     "key",
+		Model:      "openai/gpt-4o",
+		HTTPClient: f.client(),
+		Endpoint:   &EndpointPreset{Headers: map[string]string{"HTTP-Referer": "https://example.com", "X-Title": "example"}},
+	})
+	assert.Nil(t, err)
+	_, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	r, _ := f.last()
+	assert.Equal(t, "openrouter.ai/api/v1/chat/completions", r.Header.Get("X-Original-Host")+r.URL.Path)
+	assert.Equal(t, "https://example.com", r.Header.Get("HTTP-Referer"))
+	assert.Equal(t, "example", r.Header.Get("X-Title"))
+	assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+	caps, ok := cm.Capabilities()
+	assert.True(t, ok)
+	assert.True(t, caps.ToolCalling)
+
+	// local servers are called without key
+	cm, err = NewChatModel(ctx, &Config{Provider: "vllm", Model: "Qwen/Qwen3-8B", HTTPClient: f.client()})
+	assert.Nil(t, err)
+	_, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	r, _ = f.last()
+	assert.Equal(t, "localhost:8000", r.Header.Get("X-Original-Host"))
+	assert.Empty(t, r.Header.Get("Authorization"))
+
+	// internal gateways are configured with the endpoint of the config
+	cm, err = NewChatModel(ctx, &Config{
+		Provider:   "openai_compatible",
+		APIKey:     "key",
+		Model:      "gpt-4o",
+		MaxTokens:  &maxTokens,
+		HTTPClient: f.client(),
+		Endpoint: &EndpointPreset{
+			BaseURL:             "https://llm.corp.internal/openai/v1",
+			AuthHeader:          "X-Gateway-Key",
+			Headers:             map[string]string{"X-Team": "rag"},
+			MaxCompletionTokens: &yes,
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "OpenAI", cm.GetType())
+	_, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	r, body := f.last()
+	assert.Equal(t, "llm.corp.internal/openai/v1/chat/completions", r.Header.Get("X-Original-Host")+r.URL.Path)
+	assert.Equal(t, "key", r.Header.Get("X-Gateway-Key"))
+	assert.Empty(t, r.Header.Get("Authorization"))
+	assert.Equal(t, "rag", r.Header.Get("X-Team"))
+	assert.Equal(t, float64(100), body["max_completion_tokens"])
+	assert.NotContains(t, body, "max_tokens")
+
+	// the config clears the max_completion_tokens of the preset
+	RegisterEndpointPreset("corp-openai", EndpointPreset{BaseURL: "https://llm.corp.internal/openai/v1", MaxCompletionTokens: &yes})
+	cm, err = NewChatModel(ctx, &Config{
+		Provider:   "corp-openai",
+		Model:      "gpt-4o",
+		MaxTokens:  &maxTokens,
+		HTTPClient: f.client(),
+		Endpoint:   &EndpointPreset{MaxCompletionTokens: &no},
+	})
+	assert.Nil(t, err)
+	_, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	_, body = f.last()
+	assert.Equal(t, float64(100), body["max_tokens"])
+	assert.NotContains(t, body, "max_completion_tokens")
+
+	// registered presets are providers
+	_, err = NewChatModel(ctx, &Config{Provider: "corp", Model: "m"})
+	assert.ErrorContains(t, err, "not support provider")
+	RegisterEndpointPreset("corp", EndpointPreset{BaseURL: "https://llm.corp.internal/v1", AuthHeader: "api-key"})
+	cm, err = NewChatModel(ctx, &Config{Provider: "corp", APIKey: "key", Model: "m", HTTPClient: f.client()})
+	assert.Nil(t, err)
+	_, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	r, _ = f.last()
+	assert.Equal(t, "llm.corp.internal", r.Header.Get("X-Original-Host"))
+	assert.Equal(t, "key", r.Header.Get("api-key"))
+}
@@ -2,9 +2,9 @@ package chatmodelprovider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
+	"net/http"
+	"strings"
 
 	"github.com/cloudwego/eino-ext/components/model/ark"
 	"github.com/cloudwego/eino-ext/components/model/arkbot"
@@ -16,6 +16,7 @@ import (
 	"github.com/cloudwego/eino-ext/components/model/qwen"
 	"github.com/cloudwego/eino/components"
 	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
 	"google.golang.org/genai"
 
 	"github.com/eino-contrib/agentkit-ve/libs/veauth"
@@ -39,6 +40,42 @@ type Config struct {
 	TopP *float32
 	// Stop is the stop words, which controls the stopping condition of the model.
 	Stop []string
+	// Reasoning enables, budgets and returns the reasoning of the models thinking before answering,
+	// whatever the provider, see ReasoningConfig and GetReasoning.
+	Reasoning *ReasoningConfig
+
+	// Region is the AWS region of the "bedrock" provider, default is the region of the AWS config.
+	// The "bedrock" provider serves the Anthropic models of Bedrock, e.g. "us.anthropic.claude-sonnet-4-20250514-v1:0",
+	// with the Anthropic API, and the other models, e.g. "us.amazon.nova-pro-v1:0", with the Converse API.
+	Region string
+	// AccessKey, SecretKey and SessionToken are the AWS credentials of the "bedrock" provider,
+	// default is the credential chain of the AWS SDK, e.g. the AWS_ACCESS_KEY_ID env or the shared profile.
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	// Capabilities overrides the capabilities of the model found in the catalog, see ChatModel.Capabilities.
+	Capabilities *Capabilities
+	// Tokenizer counts the tokens sent to the model, e.g. with the tokenizer of the model.
+	// Default is an estimator calibrated on the tokenizer of the provider, see ChatModel.CountTokens.
+	Tokenizer TokenCounter
+	// UsageTracker rejects the calls of requests, tasks or tenants over budget with ErrBudgetExceeded.
+	// The usage is recorded by the callback handler of the tracker, see UsageTracker.Handler.
+	UsageTracker *UsageTracker
+
+	// HTTPClient sends the requests to the provider, default is the client of the provider SDK.
+	// The "bedrock" provider also loads its AWS config with it.
+	HTTPClient *http.Client
+	// Cassette is the file of the HTTP exchanges recorded by the "record:<provider>" providers,
+	// and replayed by the "replay:<provider>" providers, e.g. "replay:openai", so that tests run offline.
+	Cassette string
+	// Mock scripts the answers of the "mock" provider, see MockConfig.
+	Mock *MockConfig
+
+	// Endpoint overrides the preset of the OpenAI-compatible providers, e.g. "openrouter", "vllm",
+	// or "openai_compatible" whose preset is empty, see EndpointPreset and RegisterEndpointPreset.
+	// The presets send no app headers, set them with Endpoint.Headers, e.g. the HTTP-Referer and X-Title of OpenRouter.
+	Endpoint *EndpointPreset
 }
 
 const (
@@ -53,31 +90,37 @@ const (
 	azureOpenaiModelType modelType = "AzureOpenAI"
 	geminiModelType      modelType = "Gemini"
 	claudeModelType      modelType = "Claude"
+	bedrockModelType     modelType = "Bedrock"
 	ollamaModelType      modelType = "Ollama"
 	deepSeekModelType    modelType = "DeepSeek"
 	arkModelType         modelType = "Ark"
 	arkBotModelType      modelType = "ArkBot"
 	qwenModelType        modelType = "Qwen"
 	qianFaModelType      modelType = "QianFan"
+	mockModelType        modelType = "Mock"
 )
 
 var providerPrefixToModelType = map[string]modelType{
-	"openai":     openaiModelType,
-	"azure":      azureOpenaiModelType,
-	"openrouter": openaiModelType,
+	"openai": openaiModelType,
+	"azure":  azureOpenaiModelType,
 
 	"vertex_ai": geminiModelType,
 	"gemini":    geminiModelType,
 
 	"anthropic":  claudeModelType,
+	"bedrock":    bedrockModelType,
 	"ollama":     ollamaModelType,
 	"deepseek":   deepSeekModelType,
 	"volcengine": arkModelType,
 	"dashscope":  qwenModelType,
+	"mock":       mockModelType,
 }
 
 type ChatModel struct {
-	cfg *Config
+	cfg      *Config
+	conf     *Config // Config of the provider model, with the cassette client
+	provider string  // Provider of the model, without the cassette mode
+	mType    modelType
 	model.ToolCallingChatModel
 }
 
@@ -99,50 +142,79 @@ func NewChatModel(ctx context.Context, cfg *Config) (*ChatModel, error) {
 		cfg.Provider = defaultProvider
 	}
 
-	if cfg.Provider == defaultProvider && cfg.Model == "" {
+	// "record:<provider>" and "replay:<provider>" run the provider through a cassette, see Config.Cassette
+	provider := cfg.Provider
+	conf := *cfg
+	mode, name, isCassette := strings.Cut(provider, ":")
+	if isCassette {
+		provider = name
+		conf.HTTPClient, err = newCassetteClient(cassetteMode(mode), cfg.Cassette, cfg.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+		if cassetteMode(mode) == cassetteReplay && conf.APIKey == "" {
+			// requests are answered from the cassette, the key is never checked
+			conf.APIKey = "replay"
+		}
+	}
+
+	if provider == defaultProvider && cfg.Model == "" {
 		cfg.Model = defaultModel
+		conf.Model = defaultModel
 	}
 
-	if cfg.Provider == defaultProvider && cfg.APIKey == "" {
-		cfg.APIKey, err = getDefaultArkAPIKey()
+	if provider == defaultProvider && conf.APIKey == "" {
+		cfg.APIKey, err = veauth.GetDefaultArkAPIKey()
 		if err != nil {
 			return nil, fmt.Errorf("volcengine provider: failed to get default Ark API key: %w", err)
 		}
+		conf.APIKey = cfg.APIKey
 	}
 
-	provider := cfg.Provider
 	mType, ok := providerPrefixToModelType[provider]
+	preset, isPreset := LookupEndpointPreset(provider)
+	if !ok && isPreset {
+		mType, ok = openaiModelType, true
+	}
 	if !ok {
 		return nil, fmt.Errorf("not support provider %s", provider)
 	}
+	if err := cfg.Reasoning.validate(); err != nil {
+		return nil, err
+	}
 
 	switch mType {
 	case arkModelType:
-		arkCfg := cfg.toArkConfig()
+		arkCfg := conf.toArkConfig()
 		cModel, err = ark.NewChatModel(ctx, arkCfg)
 		if err != nil {
 			return nil, err
 		}
 	case arkBotModelType:
-		arkBotCfg := cfg.toArkBotConfig()
+		arkBotCfg := conf.toArkBotConfig()
 		cModel, err = arkbot.NewChatModel(ctx, arkBotCfg)
 		if err != nil {
 			return nil, err
 		}
 	case deepSeekModelType:
-		deepseekCfg := cfg.toDeepSeekConfig()
+		deepseekCfg := conf.toDeepSeekConfig()
 		cModel, err = deepseek.NewChatModel(ctx, deepseekCfg)
 		if err != nil {
 			return nil, err
 		}
 	case claudeModelType:
-		claudeCfg := cfg.toClaudeConfig()
+		claudeCfg := conf.toClaudeConfig()
 		cModel, err = claude.NewChatModel(ctx, claudeCfg)
 		if err != nil {
 			return nil, err
 		}
+	case bedrockModelType:
+		cModel, err = newBedrockChatModel(ctx, conf.toBedrockConfig())
+		if err != nil {
+			return nil, err
+		}
 	case geminiModelType:
-		geminiCfg, err := cfg.toGeminiConfig(ctx)
+		geminiCfg, err := conf.toGeminiConfig(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -151,35 +223,47 @@ func NewChatModel(ctx context.Context, cfg *Config) (*ChatModel, error) {
 			return nil, err
 		}
 	case ollamaModelType:
-		ollamaCfg := cfg.toOllamaConfig()
+		ollamaCfg := conf.toOllamaConfig()
 		cModel, err = ollama.NewChatModel(ctx, ollamaCfg)
 		if err != nil {
 			return nil, err
 		}
 	case azureOpenaiModelType:
-		openaiCfg := cfg.toOpenAIConfig()
+		openaiCfg := conf.toOpenAIConfig()
 		openaiCfg.ByAzure = true
 		cModel, err = openai.NewChatModel(ctx, openaiCfg)
 		if err != nil {
 			return nil, err
 		}
 	case openaiModelType:
-		openaiCfg := cfg.toOpenAIConfig()
+		openaiCfg := conf.toOpenAIConfig()
+		if isPreset {
+			// the providers of the presets are served with the OpenAI client, see EndpointPreset
+			openaiCfg, err = conf.toOpenAICompatibleConfig(provider, preset)
+			if err != nil {
+				return nil, err
+			}
+		}
 		cModel, err = openai.NewChatModel(ctx, openaiCfg)
 		if err != nil {
 			return nil, err
 		}
 	case qwenModelType:
-		qwenCfg := cfg.toQwenConfig()
+		qwenCfg := conf.toQwenConfig()
 		cModel, err = qwen.NewChatModel(ctx, qwenCfg)
 		if err != nil {
 			return nil, err
 		}
+	case mockModelType:
+		cModel = NewMockChatModel(cfg.Mock)
 	default:
 		return nil, fmt.Errorf("invalid model type: %s", mType)
 	}
 	return &ChatModel{
 		cfg:                  cfg,
+		conf:                 &conf,
+		provider:             provider,
+		mType:                mType,
 		ToolCallingChatModel: cModel,
 	}, nil
 }
@@ -192,12 +276,22 @@ func (c *ChatModel) GetType() string {
 	return typer.GetType()
 }
 
+// WithTools returns a ChatModel bound to tools, with the same config
+func (c *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	cModel, err := c.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatModel{cfg: c.cfg, conf: c.conf, provider: c.provider, mType: c.mType, ToolCallingChatModel: cModel}, nil
+}
+
 func (c *Config) toArkConfig() *ark.ChatModelConfig {
 
 	cfg := &ark.ChatModelConfig{
-		APIKey:  c.APIKey,
-		Model:   c.Model,
-		BaseURL: c.BaseURL,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
 	}
 	if c.MaxTokens != nil {
 		cfg.MaxTokens = c.MaxTokens
@@ -211,6 +305,7 @@ func (c *Config) toArkConfig() *ark.ChatModelConfig {
 	if c.Stop != nil {
 		cfg.Stop = c.Stop
 	}
+	cfg.Thinking, cfg.ReasoningEffort = c.Reasoning.toArk()
 
 	return cfg
 }
@@ -218,9 +313,10 @@ func (c *Config) toArkConfig() *ark.ChatModelConfig {
 func (c *Config) toArkBotConfig() *arkbot.Config {
 
 	cfg := &arkbot.Config{
-		APIKey:  c.APIKey,
-		Model:   c.Model,
-		BaseURL: c.BaseURL,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
 	}
 
 	if c.MaxTokens != nil {
@@ -245,9 +341,10 @@ func (c *Config) toArkBotConfig() *arkbot.Config {
 func (c *Config) toDeepSeekConfig() *deepseek.ChatModelConfig {
 
 	cfg := &deepseek.ChatModelConfig{
-		APIKey:  c.APIKey,
-		Model:   c.Model,
-		BaseURL: c.BaseURL,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
 	}
 
 	if c.MaxTokens != nil {
@@ -270,8 +367,9 @@ func (c *Config) toDeepSeekConfig() *deepseek.ChatModelConfig {
 
 func (c *Config) toClaudeConfig() *claude.Config {
 	cfg := &claude.Config{
-		APIKey: c.APIKey,
-		Model:  c.Model,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		HTTPClient: c.HTTPClient,
 	}
 	if c.BaseURL != "" {
 		cfg.BaseURL = &c.BaseURL
@@ -288,13 +386,25 @@ func (c *Config) toClaudeConfig() *claude.Config {
 	if c.Stop != nil {
 		cfg.StopSequences = c.Stop
 	}
+	cfg.Thinking = c.Reasoning.toClaude()
 
 	return cfg
 }
 
+// toBedrockConfig returns the config of the models of Bedrock, see newBedrockChatModel
+func (c *Config) toBedrockConfig() *claude.Config {
+	cfg := c.toClaudeConfig()
+	cfg.Region = c.Region
+	cfg.AccessKey = c.AccessKey
+	cfg.SecretAccessKey = c.SecretKey
+	cfg.SessionToken = c.SessionToken
+	return cfg
+}
+
 func (c *Config) toGeminiConfig(ctx context.Context) (*gemini.Config, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: c.APIKey,
+		APIKey:     c.APIKey,
+		HTTPClient: c.HTTPClient,
 		HTTPOptions: genai.HTTPOptions{
 			BaseURL: c.BaseURL,
 		},
@@ -316,6 +426,7 @@ func (c *Config) toGeminiConfig(ctx context.Context) (*gemini.Config, error) {
 	if c.TopP != nil {
 		cfg.TopP = c.TopP
 	}
+	cfg.ThinkingConfig = c.Reasoning.toGemini()
 	return cfg, nil
 
 }
@@ -323,8 +434,9 @@ func (c *Config) toGeminiConfig(ctx context.Context) (*gemini.Config, error) {
 func (c *Config) toOllamaConfig() *ollama.ChatModelConfig {
 
 	cfg := &ollama.ChatModelConfig{
-		BaseURL: c.BaseURL,
-		Model:   c.Model,
+		BaseURL:    c.BaseURL,
+		Model:      c.Model,
+		HTTPClient: c.HTTPClient,
 	}
 	var options = &ollama.Options{}
 	if c.MaxTokens != nil {
@@ -340,14 +452,16 @@ func (c *Config) toOllamaConfig() *ollama.ChatModelConfig {
 		options.Stop = c.Stop
 	}
 	cfg.Options = options
+	cfg.Thinking = c.Reasoning.toOllama()
 	return cfg
 }
 
 func (c *Config) toOpenAIConfig() *openai.ChatModelConfig {
 	cfg := &openai.ChatModelConfig{
-		APIKey:  c.APIKey,
-		Model:   c.Model,
-		BaseURL: c.BaseURL,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
 	}
 
 	if c.MaxTokens != nil {
@@ -362,14 +476,16 @@ func (c *Config) toOpenAIConfig() *openai.ChatModelConfig {
 	if c.Stop != nil {
 		cfg.Stop = c.Stop
 	}
+	cfg.ReasoningEffort = c.Reasoning.toOpenAI()
 	return cfg
 }
 
 func (c *Config) toQwenConfig() *qwen.ChatModelConfig {
 	cfg := &qwen.ChatModelConfig{
-		APIKey:  c.APIKey,
-		Model:   c.Model,
-		BaseURL: c.BaseURL,
+		APIKey:     c.APIKey,
+		Model:      c.Model,
+		BaseURL:    c.BaseURL,
+		HTTPClient: c.HTTPClient,
 	}
 	if c.MaxTokens != nil {
 		cfg.MaxTokens = c.MaxTokens
@@ -383,52 +499,11 @@ func (c *Config) toQwenConfig() *qwen.ChatModelConfig {
 	if c.Stop != nil {
 		cfg.Stop = c.Stop
 	}
+	cfg.EnableThinking = c.Reasoning.toQwen()
 	return cfg
 }
 
-func getDefaultArkAPIKey() (string, error) {
-	ak := os.Getenv("VOLCENGINE_ACCESS_KEY")
-	sk := os.Getenv("VOLCENGINE_SECRET_KEY")
-	sessionToken := ""
-	if ak == "" || sk == "" {
-		credential, err := getCredentialFromVefaasIam()
-		if err != nil {
-			return "", err
-		}
-		ak = credential.AccessKeyId
-		sk = credential.SecretAccessKey
-		sessionToken = credential.SessionToken
-	}
-	apiKey, err := veauth.GetArkAPIKey(ak, sk, veauth.WithSessionToken(sessionToken))
-	if err != nil {
-		return "", err
-	}
-	return apiKey, err
-
-}
-
+// VefaasIamCridentialPath is the file of the IAM credential mounted in the VeFaaS functions.
+//
+// Deprecated: the default credential is resolved by veauth.GetDefaultArkAPIKey.
 const VefaasIamCridentialPath = "/var/run/secrets/iam/credential"
-
-type veIAMCredential struct {
-	AccessKeyId     string `json:"access_key_id"`
-	SecretAccessKey string `json:"secret_access_key"`
-	SessionToken    string `json:"session_token"`
-}
-
-func getCredentialFromVefaasIam() (*veIAMCredential, error) {
-	if _, err := os.Stat(VefaasIamCridentialPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("get Volcengine access key or secret key from environment variables failed, and VeFaaS IAM file (path=%s) does not exist. Please check your configuration", VefaasIamCridentialPath)
-	}
-	file, err := os.Open(VefaasIamCridentialPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open VeFaaS IAM credential file: %v", err)
-	}
-	defer file.Close()
-
-	var credDict veIAMCredential
-	if err := json.NewDecoder(file).Decode(&credDict); err != nil {
-		return nil, fmt.Errorf("failed to decode JSON from VeFaaS IAM credential file: %v", err)
-	}
-	return &credDict, nil
-
-}
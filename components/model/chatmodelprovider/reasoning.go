@@ -0,0 +1,211 @@
+package chatmodelprovider
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/cloudwego/eino-ext/components/model/claude"
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+	arkModel "github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+	"google.golang.org/genai"
+)
+
+// ReasoningExtraKey is the key of the reasoning of the model in schema.Message.Extra, see GetReasoning
+const ReasoningExtraKey = "_agentkit_reasoning"
+
+// ReasoningEffort is the effort of the model reasoning before answering
+type ReasoningEffort string
+
+const (
+	ReasoningEffortLow    ReasoningEffort = "low"
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	ReasoningEffortHigh   ReasoningEffort = "high"
+)
+
+// reasoningBudgets are the token budgets of the efforts, for the providers budgeting the reasoning in tokens
+var reasoningBudgets = map[ReasoningEffort]int{
+	ReasoningEffortLow:    1024,
+	ReasoningEffortMedium: 4096,
+	ReasoningEffortHigh:   16384,
+}
+
+// ReasoningConfig configures the reasoning of the models thinking before answering,
+// translated to the options of the provider. The options a provider does not support are ignored.
+type ReasoningConfig struct {
+	// Enabled turns the reasoning on or off, for the "volcengine", "anthropic", "bedrock", "gemini",
+	// "dashscope" and "ollama" providers. Default is the default of the model, the reasoning is on
+	// when Effort or BudgetTokens is set for the providers where it is off by default, e.g. "anthropic".
+	// The reasoning of the "deepseek" provider is chosen with the model, e.g. "deepseek-reasoner".
+	Enabled *bool
+	// Effort is the reasoning effort, sent as is to the "volcengine", "openai", "azure", "ollama" and
+	// OpenAI-compatible providers, and as a token budget to the "anthropic", "bedrock" and "gemini" providers.
+	Effort ReasoningEffort
+	// BudgetTokens is the max number of reasoning tokens of the "anthropic", "bedrock" and "gemini" providers,
+	// it overrides the budget of Effort. Anthropic requires at least 1024 tokens, and less than Config.MaxTokens.
+	BudgetTokens int
+	// IncludeInOutput returns the reasoning in the messages, see GetReasoning.
+	// Otherwise it is removed from ReasoningContent, except for the provider extras sent back with the messages,
+	// e.g. the signed thinking of Anthropic. Gemini only returns its thoughts when it is set.
+	IncludeInOutput bool
+}
+
+// GetReasoning returns the reasoning of the model output by a ChatModel, whatever the provider
+func GetReasoning(msg *schema.Message) (string, bool) {
+	if msg == nil || msg.Extra == nil {
+		return "", false
+	}
+	reasoning, ok := msg.Extra[ReasoningExtraKey].(string)
+	return reasoning, ok
+}
+
+func (r *ReasoningConfig) validate() error {
+	if r == nil {
+		return nil
+	}
+	if _, ok := reasoningBudgets[r.Effort]; r.Effort != "" && !ok {
+		return fmt.Errorf("invalid reasoning effort: %s", r.Effort)
+	}
+	if r.BudgetTokens < 0 {
+		return fmt.Errorf("invalid reasoning budget: %d", r.BudgetTokens)
+	}
+	return nil
+}
+
+// enabled returns whether the reasoning is turned on or off, and false if it is left to the model
+func (r *ReasoningConfig) enabled() (enabled, set bool) {
+	if r == nil {
+		return false, false
+	}
+	if r.Enabled != nil {
+		return *r.Enabled, true
+	}
+	if r.Effort != "" || r.BudgetTokens > 0 {
+		return true, true
+	}
+	return false, false
+}
+
+// budget returns the token budget of the reasoning, 0 if it is not set
+func (r *ReasoningConfig) budget() int {
+	if r == nil {
+		return 0
+	}
+	if r.BudgetTokens > 0 {
+		return r.BudgetTokens
+	}
+	return reasoningBudgets[r.Effort]
+}
+
+// includeInOutput returns whether the reasoning is returned, as it is when Config.Reasoning is not set
+func (r *ReasoningConfig) includeInOutput() bool {
+	return r == nil || r.IncludeInOutput
+}
+
+func (r *ReasoningConfig) toArk() (*arkModel.Thinking, *arkModel.ReasoningEffort) {
+	var thinking *arkModel.Thinking
+	if enabled, set := r.enabled(); set {
+		thinking = &arkModel.Thinking{Type: arkModel.ThinkingTypeDisabled}
+		if enabled {
+			thinking.Type = arkModel.ThinkingTypeEnabled
+		}
+	}
+	var effort *arkModel.ReasoningEffort
+	if r != nil && r.Effort != "" {
+		e := arkModel.ReasoningEffort(r.Effort)
+		effort = &e
+	}
+	return thinking, effort
+}
+
+func (r *ReasoningConfig) toClaude() *claude.Thinking {
+	enabled, set := r.enabled()
+	if !set {
+		return nil
+	}
+	thinking := &claude.Thinking{Enable: enabled}
+	if enabled {
+		thinking.BudgetTokens = r.budget()
+		if thinking.BudgetTokens == 0 {
+			thinking.BudgetTokens = reasoningBudgets[ReasoningEffortLow]
+		}
+	}
+	return thinking
+}
+
+func (r *ReasoningConfig) toGemini() *genai.ThinkingConfig {
+	if r == nil {
+		return nil
+	}
+	cfg := &genai.ThinkingConfig{IncludeThoughts: r.IncludeInOutput}
+	if enabled, set := r.enabled(); set && !enabled {
+		// a zero budget turns the thinking off, IncludeThoughts is then rejected
+		cfg.IncludeThoughts = false
+		cfg.ThinkingBudget = new(int32)
+	} else if budget := r.budget(); budget > 0 {
+		b := int32(budget)
+		cfg.ThinkingBudget = &b
+	}
+	if *cfg == (genai.ThinkingConfig{}) {
+		return nil
+	}
+	return cfg
+}
+
+func (r *ReasoningConfig) toOpenAI() openai.ReasoningEffortLevel {
+	if r == nil {
+		return ""
+	}
+	return openai.ReasoningEffortLevel(r.Effort)
+}
+
+func (r *ReasoningConfig) toQwen() *bool {
+	if enabled, set := r.enabled(); set {
+		return &enabled
+	}
+	return nil
+}
+
+func (r *ReasoningConfig) toOllama() *ollama.ThinkValue {
+	enabled, set := r.enabled()
+	if !set {
+		return nil
+	}
+	if enabled && r.Effort != "" {
+		// the effort is only accepted by the models reasoning at levels, e.g. gpt-oss
+		return &ollama.ThinkValue{Value: string(r.Effort)}
+	}
+	return &ollama.ThinkValue{Value: enabled}
+}
+
+// normalizeReasoning returns the message, or the chunk, with its reasoning under ReasoningExtraKey,
+// or without its reasoning if it is not included in the output.
+// All the providers return the reasoning in ReasoningContent, whatever its name in their API.
+func (c *ChatModel) normalizeReasoning(msg *schema.Message) *schema.Message {
+	if msg == nil {
+		return nil
+	}
+	_, normalized := msg.Extra[ReasoningExtraKey]
+	if msg.ReasoningContent == "" && !normalized {
+		return msg
+	}
+	// the message is shared with the callbacks of the model
+	out := *msg
+	out.Extra = maps.Clone(msg.Extra)
+	if out.Extra == nil {
+		out.Extra = make(map[string]any)
+	}
+	if c.cfg.Reasoning.includeInOutput() {
+		if out.ReasoningContent != "" {
+			out.Extra[ReasoningExtraKey] = out.ReasoningContent
+		}
+		return &out
+	}
+	out.ReasoningContent = ""
+	delete(out.Extra, ReasoningExtraKey)
+	if len(out.Extra) == 0 {
+		out.Extra = nil
+	}
+	return &out
+}
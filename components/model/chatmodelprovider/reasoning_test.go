@@ -0,0 +1,144 @@
+package chatmodelprovider
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	arkModel "github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+)
+
+func TestReasoningConfig(t *testing.T) {
+	ctx := t.Context()
+	off := false
+
+	_, err := NewChatModel(ctx, &Config{Provider: "mock", Reasoning: &ReasoningConfig{Effort: "max"}})
+	assert.ErrorContains(t, err, "invalid reasoning effort: max")
+
+	// the reasoning is left to the model without config
+	c := &Config{Model: "m"}
+	assert.Nil(t, c.toArkConfig().Thinking)
+	assert.Nil(t, c.toClaudeConfig().Thinking)
+	assert.Nil(t, c.toQwenConfig().EnableThinking)
+	assert.Empty(t, c.toOpenAIConfig().ReasoningEffort)
+
+	c.Reasoning = &ReasoningConfig{Effort: ReasoningEffortHigh}
+	arkCfg := c.toArkConfig()
+	assert.Equal(t, arkModel.ThinkingTypeEnabled, arkCfg.Thinking.Type)
+	assert.Equal(t, arkModel.ReasoningEffortHigh, *arkCfg.ReasoningEffort)
+	assert.Equal(t, "high", string(c.toOpenAIConfig().ReasoningEffort))
+	assert.Equal(t, "high", c.toOllamaConfig().Thinking.Value)
+	assert.Equal(t, 16384, c.toClaudeConfig().Thinking.BudgetTokens)
+	assert.True(t, c.toBedrockConfig().Thinking.Enable)
+
+	// the budget overrides the one of the effort
+	c.Reasoning = &ReasoningConfig{Effort: ReasoningEffortLow, BudgetTokens: 2000, IncludeInOutput: true}
+	c.APIKey = "key"
+	geminiCfg, err := c.toGeminiConfig(ctx)
+	assert.Nil(t, err)
+	assert.True(t, geminiCfg.ThinkingConfig.IncludeThoughts)
+	assert.Equal(t, int32(2000), *geminiCfg.ThinkingConfig.ThinkingBudget)
+	assert.Equal(t, 2000, c.toClaudeConfig().Thinking.BudgetTokens)
+
+	c.Reasoning = &ReasoningConfig{Enabled: &off, IncludeInOutput: true}
+	geminiCfg, err = c.toGeminiConfig(ctx)
+	assert.Nil(t, err)
+	assert.False(t, geminiCfg.ThinkingConfig.IncludeThoughts)
+	assert.Equal(t, int32(0), *geminiCfg.ThinkingConfig.ThinkingBudget)
+	assert.Equal(t, arkModel.ThinkingTypeDisabled, c.toArkConfig().Thinking.Type)
+	assert.False(t, *c.toQwenConfig().EnableThinking)
+	assert.Equal(t, false, c.toOllamaConfig().Thinking.Value)
+}
+
+func TestReasoningOutput(t *testing.T) {
+	ctx := t.Context()
+	input := []*schema.Message{schema.UserMessage("capital of France?")}
+
+	// Anthropic returns thinking blocks
+	claudeVendor := newFakeVendor(t, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",`+
+		`"content":[{"type":"thinking","thinking":"France is in Europe.","signature":"sig"},{"type":"text","text":"Paris"}],`+
+		`"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":20}}`)
+	maxTokens := 8192
+	cm, err := NewChatModel(ctx, &Config{
+		Provider:   "anthropic",
+		APIKey:     "key",
+		Model:      "claude-sonnet-4-20250514",
+		MaxTokens:  &maxTokens,
+		HTTPClient: claudeVendor.client(),
+		Reasoning:  &ReasoningConfig{Effort: ReasoningEffortMedium, IncludeInOutput: true},
+	})
+	assert.Nil(t, err)
+	out, err := cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", out.Content)
+	reasoning, ok := GetReasoning(out)
+	assert.True(t, ok)
+	assert.Equal(t, "France is in Europe.", reasoning)
+	_, body := claudeVendor.last()
+	assert.Equal(t, map[string]any{"type": "enabled", "budget_tokens": float64(4096)}, body["thinking"])
+
+	// DeepSeek R1 served by vLLM returns reasoning_content
+	r1Vendor := newFakeVendor(t, `{"id":"1","object":"chat.completion","model":"m","choices":[{"index":0,`+
+		`"message":{"role":"assistant","content":"Paris","reasoning_content":"France is in Europe."},"finish_reason":"stop"}],`+
+		`"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`)
+	cm, err = NewChatModel(ctx, &Config{Provider: "vllm", Model: "deepseek-ai/DeepSeek-R1", HTTPClient: r1Vendor.client()})
+	assert.Nil(t, err)
+	out, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	reasoning, _ = GetReasoning(out)
+	assert.Equal(t, "France is in Europe.", reasoning)
+	assert.Equal(t, "France is in Europe.", out.ReasoningContent)
+
+	cm, err = NewChatModel(ctx, &Config{
+		Provider:   "vllm",
+		Model:      "deepseek-ai/DeepSeek-R1",
+		HTTPClient: r1Vendor.client(),
+		Reasoning:  &ReasoningConfig{Effort: ReasoningEffortLow},
+	})
+	assert.Nil(t, err)
+	out, err = cm.Generate(ctx, input)
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", out.Content)
+	assert.Empty(t, out.ReasoningContent)
+	_, ok = GetReasoning(out)
+	assert.False(t, ok)
+	_, body = r1Vendor.last()
+	assert.Equal(t, "low", body["reasoning_effort"])
+}
+
+func TestReasoningStream(t *testing.T) {
+	ctx := t.Context()
+	answer := &schema.Message{Role: schema.Assistant, Content: "Paris", ReasoningContent: "France is in Europe."}
+	mock := &MockConfig{Responses: []*MockResponse{{Chunks: []*schema.Message{
+		{Role: schema.Assistant, ReasoningContent: "France "},
+		{Role: schema.Assistant, ReasoningContent: "is in Europe."},
+		{Role: schema.Assistant, Content: "Paris"},
+	}}, {Message: answer}}}
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Mock: mock})
+	assert.Nil(t, err)
+
+	sr, err := cm.Stream(ctx, []*schema.Message{schema.UserMessage("capital of France?")})
+	assert.Nil(t, err)
+	var chunks []*schema.Message
+	for {
+		chunk, err := sr.Recv()
+		if err != nil {
+			break
+		}
+		chunks = append(chunks, chunk)
+	}
+	reasoning, ok := GetReasoning(chunks[0])
+	assert.True(t, ok)
+	assert.Equal(t, "France ", reasoning)
+	out, err := schema.ConcatMessages(chunks)
+	assert.Nil(t, err)
+	reasoning, _ = GetReasoning(out)
+	assert.Equal(t, "France is in Europe.", reasoning)
+
+	// the scripted answer is left as is
+	out, err = cm.Generate(ctx, nil)
+	assert.Nil(t, err)
+	reasoning, _ = GetReasoning(out)
+	assert.Equal(t, "France is in Europe.", reasoning)
+	assert.Nil(t, answer.Extra)
+}
@@ -0,0 +1,590 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/model/ark"
+	"github.com/cloudwego/eino-ext/components/model/deepseek"
+	"github.com/cloudwego/eino-ext/components/model/gemini"
+	"github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	arkModel "github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
+)
+
+// ErrInvalidStructuredOutput is returned by GenerateStructured when no answer matches the schema within the retries
+var ErrInvalidStructuredOutput = errors.New("invalid structured output")
+
+// StructuredMode is the mechanism constraining the model to answer with JSON
+type StructuredMode string
+
+const (
+	// StructuredModeAuto picks the best mechanism of the provider and of the model capabilities.
+	StructuredModeAuto StructuredMode = ""
+	// StructuredModeNative uses the JSON mode of the provider, e.g. the response_format of OpenAI,
+	// the response schema of Gemini, or the json_schema response format of Ark.
+	// The endpoints only accepting JSON objects get the schema in the prompt, see EndpointPreset.ResponseFormat.
+	StructuredModeNative StructuredMode = "native"
+	// StructuredModeTool forces the model to call a tool whose parameters are the schema.
+	StructuredModeTool StructuredMode = "tool"
+	// StructuredModePrompt describes the schema in the prompt, and repairs the answer, e.g. without code fences.
+	StructuredModePrompt StructuredMode = "prompt"
+)
+
+const defaultStructuredRetries = 2
+
+type structuredOption struct {
+	mode        StructuredMode
+	retries     int
+	name        string
+	description string
+	modelOpts   []model.Option
+}
+
+// StructuredOptionFn is a function type for configuring GenerateStructured
+type StructuredOptionFn func(*structuredOption)
+
+// WithStructuredMode sets the mechanism constraining the answer, default is StructuredModeAuto
+func WithStructuredMode(mode StructuredMode) StructuredOptionFn {
+	return func(o *structuredOption) {
+		o.mode = mode
+	}
+}
+
+// WithStructuredRetries sets the number of calls retried when the answer does not match the schema, default is 2
+func WithStructuredRetries(retries int) StructuredOptionFn {
+	return func(o *structuredOption) {
+		o.retries = retries
+	}
+}
+
+// WithStructuredName sets the name of the schema, or of the forced tool, default is the name of the type
+func WithStructuredName(name, description string) StructuredOptionFn {
+	return func(o *structuredOption) {
+		o.name = name
+		o.description = description
+	}
+}
+
+// WithStructuredModelOptions sets the options of the calls to the model
+func WithStructuredModelOptions(opts ...model.Option) StructuredOptionFn {
+	return func(o *structuredOption) {
+		o.modelOpts = opts
+	}
+}
+
+// StructuredValidator is implemented by the types checking their own value after the schema validation,
+// an error is sent back to the model and the call retried
+type StructuredValidator interface {
+	Validate() error
+}
+
+// structuredValue wraps the types which are not objects, as JSON modes and tools expect an object
+type structuredValue[T any] struct {
+	Value T `json:"value"`
+}
+
+// GenerateStructured generates an answer of type T with the chat model.
+// The JSON Schema of T is derived from its json and jsonschema tags, and sent with the best mechanism of the provider,
+// see StructuredMode. The answer is validated against the schema, and with StructuredValidator if T implements it.
+// Invalid answers are sent back to the model with the validation error, ErrInvalidStructuredOutput is returned
+// once the retries are used.
+func GenerateStructured[T any](ctx context.Context, cm *ChatModel, input []*schema.Message, opts ...StructuredOptionFn) (T, error) {
+	var zero T
+	o := &structuredOption{retries: defaultStructuredRetries}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	typ := reflect.TypeFor[T]()
+	wrapped := indirect(typ).Kind() != reflect.Struct
+	if wrapped {
+		typ = reflect.TypeFor[structuredValue[T]]()
+	}
+	js := (&jsonschema.Reflector{Anonymous: true, DoNotReference: true, ExpandedStruct: true}).ReflectFromType(typ)
+	js.Version = ""
+	if o.name == "" {
+		o.name = schemaName(reflect.TypeFor[T]())
+	}
+	s, err := newStructured(js, o.name, o.description)
+	if err != nil {
+		return zero, err
+	}
+
+	mode := o.mode
+	if mode == StructuredModeAuto {
+		mode = cm.structuredMode()
+	}
+	m, msgs, modelOpts, err := cm.structuredCall(ctx, s, mode, input)
+	if err != nil {
+		return zero, err
+	}
+	modelOpts = append(append([]model.Option{}, o.modelOpts...), modelOpts...)
+
+	for attempt := 0; ; attempt++ {
+		out, err := m.Generate(ctx, msgs, modelOpts...)
+		if err != nil {
+			return zero, err
+		}
+		raw, callID := s.answer(out, mode)
+		var v T
+		if wrapped {
+			var w structuredValue[T]
+			err = s.decode(raw, &w)
+			v = w.Value
+		} else {
+			err = s.decode(raw, &v)
+		}
+		if err == nil {
+			if validator, ok := any(v).(StructuredValidator); ok {
+				err = validator.Validate()
+			}
+		}
+		if err == nil {
+			return v, nil
+		}
+		if attempt >= o.retries {
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrInvalidStructuredOutput, attempt+1, err)
+		}
+
+		feedback := fmt.Sprintf("The answer is invalid: %v. Answer again, with only the corrected JSON.", err)
+		msgs = append(msgs, out)
+		if callID != "" {
+			msgs = append(msgs, schema.ToolMessage(feedback, callID))
+		} else {
+			msgs = append(msgs, schema.UserMessage(feedback))
+		}
+	}
+}
+
+// structuredMode returns the best mechanism of the provider, falling back on the capabilities of the model
+func (c *ChatModel) structuredMode() StructuredMode {
+	if caps, ok := c.Capabilities(); ok && !caps.JSONMode {
+		if caps.ToolCalling {
+			return StructuredModeTool
+		}
+		return StructuredModePrompt
+	}
+	switch c.mType {
+	case openaiModelType, azureOpenaiModelType, qwenModelType, geminiModelType, arkModelType, ollamaModelType, deepSeekModelType:
+		return StructuredModeNative
+	case claudeModelType, bedrockModelType:
+		return StructuredModeTool
+	default:
+		return StructuredModePrompt
+	}
+}
+
+// structuredCall returns the model, the messages and the options of the calls constraining the answer with mode
+func (c *ChatModel) structuredCall(ctx context.Context, s *structured, mode StructuredMode, input []*schema.Message) (
+	model.ToolCallingChatModel, []*schema.Message, []model.Option, error) {
+	msgs := append([]*schema.Message{}, input...)
+	switch mode {
+	case StructuredModeTool:
+		m, err := c.WithTools([]*schema.ToolInfo{{
+			Name:        s.name,
+			Desc:        s.toolDesc(),
+			ParamsOneOf: schema.NewParamsOneOfByJSONSchema(s.js),
+		}})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return m, msgs, []model.Option{model.WithToolChoice(schema.ToolChoiceForced)}, nil
+	case StructuredModePrompt:
+		return c, s.withInstruction(msgs), nil, nil
+	case StructuredModeNative:
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid structured mode: %s", mode)
+	}
+
+	switch c.mType {
+	case openaiModelType, azureOpenaiModelType, qwenModelType:
+		preset, isPreset := LookupEndpointPreset(c.provider)
+		if isPreset && preset.merge(c.cfg.Endpoint).ResponseFormat == responseFormatJSONObject {
+			// the endpoint only supports JSON objects, the schema is described in the prompt
+			return c, s.withInstruction(msgs), []model.Option{openai.WithExtraFields(map[string]any{
+				"response_format": map[string]any{"type": responseFormatJSONObject},
+			})}, nil
+		}
+		return c, msgs, []model.Option{openai.WithExtraFields(map[string]any{"response_format": map[string]any{
+			"type": responseFormatJSONSchema,
+			"json_schema": map[string]any{
+				"name":        s.name,
+				"description": s.description,
+				"schema":      s.raw,
+				"strict":      strictSchema(s.raw),
+			},
+		}})}, nil
+	case geminiModelType:
+		return c, msgs, []model.Option{gemini.WithResponseJSONSchema(s.js)}, nil
+	}
+
+	// the other providers set the response format in their config, a model is built with it
+	var (
+		cModel model.ToolCallingChatModel
+		err    error
+	)
+	switch c.mType {
+	case arkModelType:
+		cfg := c.conf.toArkConfig()
+		cfg.ResponseFormat = &ark.ResponseFormat{
+			Type: arkModel.ResponseFormatJSONSchema,
+			JSONSchema: &arkModel.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:        s.name,
+				Description: s.description,
+				Schema:      s.raw,
+				Strict:      strictSchema(s.raw),
+			},
+		}
+		cModel, err = ark.NewChatModel(ctx, cfg)
+	case ollamaModelType:
+		cfg := c.conf.toOllamaConfig()
+		cfg.Format, err = json.Marshal(s.raw)
+		if err == nil {
+			cModel, err = ollama.NewChatModel(ctx, cfg)
+		}
+	case deepSeekModelType:
+		// DeepSeek only supports JSON objects, the schema is described in the prompt
+		cfg := c.conf.toDeepSeekConfig()
+		cfg.ResponseFormatType = deepseek.ResponseFormatTypeJSONObject
+		cModel, err = deepseek.NewChatModel(ctx, cfg)
+		msgs = s.withInstruction(msgs)
+	default:
+		return nil, nil, nil, fmt.Errorf("provider %s has no native structured output", c.provider)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &ChatModel{cfg: c.cfg, conf: c.conf, provider: c.provider, mType: c.mType, ToolCallingChatModel: cModel}, msgs, nil, nil
+}
+
+// structured is the schema of a GenerateStructured call
+type structured struct {
+	name        string
+	description string
+	js          *jsonschema.Schema
+	raw         map[string]any // js decoded, for the providers and the validation
+}
+
+func newStructured(js *jsonschema.Schema, name, description string) (*structured, error) {
+	data, err := json.Marshal(js)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	s := &structured{name: name, description: description, js: js}
+	if err = json.Unmarshal(data, &s.raw); err != nil {
+		return nil, fmt.Errorf("failed to decode schema: %w", err)
+	}
+	return s, nil
+}
+
+func (s *structured) toolDesc() string {
+	if s.description != "" {
+		return s.description
+	}
+	return "Answer with the " + s.name
+}
+
+// withInstruction adds the schema to the system messages of msgs
+func (s *structured) withInstruction(msgs []*schema.Message) []*schema.Message {
+	data, _ := json.Marshal(s.raw)
+	instruction := schema.SystemMessage(fmt.Sprintf(
+		"Answer with a single JSON value matching the JSON Schema below, without any other text.\n%s", data))
+	i := 0
+	for i < len(msgs) && msgs[i].Role == schema.System {
+		i++
+	}
+	return append(append(append([]*schema.Message{}, msgs[:i]...), instruction), msgs[i:]...)
+}
+
+// answer returns the JSON of the answer, with the id of the tool call carrying it in the tool mode
+func (s *structured) answer(out *schema.Message, mode StructuredMode) (string, string) {
+	if mode == StructuredModeTool {
+		for _, tc := range out.ToolCalls {
+			if tc.Function.Name == s.name {
+				return tc.Function.Arguments, tc.ID
+			}
+		}
+	}
+	return out.Content, ""
+}
+
+// decode repairs the JSON of the answer, validates it against the schema and decodes it into v
+func (s *structured) decode(answer string, v any) error {
+	raw := extractJSON(answer)
+	if raw == "" {
+		return errors.New("the answer has no JSON")
+	}
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return fmt.Errorf("the answer is not valid JSON: %w", err)
+	}
+	if err := validateJSON(s.raw, value, "$"); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("failed to decode the answer: %w", err)
+	}
+	return nil
+}
+
+// extractJSON returns the JSON of the answer, without the code fences and the text around it
+func extractJSON(answer string) string {
+	answer = strings.TrimSpace(answer)
+	if start := strings.Index(answer, "```"); start >= 0 {
+		fenced := answer[start+3:]
+		if nl := strings.IndexByte(fenced, '\n'); nl >= 0 {
+			fenced = fenced[nl+1:]
+		}
+		if end := strings.Index(fenced, "```"); end >= 0 {
+			answer = strings.TrimSpace(fenced[:end])
+		}
+	}
+	if json.Valid([]byte(answer)) {
+		return answer
+	}
+	start := strings.IndexAny(answer, "{[")
+	if start < 0 {
+		return answer
+	}
+	closing := "}"
+	if answer[start] == '[' {
+		closing = "]"
+	}
+	if end := strings.LastIndex(answer, closing); end > start {
+		return answer[start : end+1]
+	}
+	return answer[start:]
+}
+
+// validateJSON validates the decoded JSON value v against the keywords of the schemas derived from Go types
+func validateJSON(s map[string]any, v any, path string) error {
+	if t, ok := s["type"]; ok && !matchesType(t, v) {
+		return fmt.Errorf("%s: expected %v, got %s", path, t, jsonType(v))
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, v, enum)
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, v) {
+		return fmt.Errorf("%s: expected %v, got %v", path, c, v)
+	}
+	for _, sub := range schemas(s["allOf"]) {
+		if err := validateJSON(sub, v, path); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		subs := schemas(s[key])
+		if len(subs) == 0 {
+			continue
+		}
+		var err error
+		for _, sub := range subs {
+			if err = validateJSON(sub, v, path); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := s["properties"].(map[string]any)
+		for _, name := range schemaStrings(s["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k].(map[string]any); ok {
+				if err := validateJSON(ps, v[k], path+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+			case map[string]any:
+				if err := validateJSON(ap, v[k], path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		if n, ok := s["minItems"].(float64); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s: expected at least %v items, got %d", path, n, len(v))
+		}
+		if n, ok := s["maxItems"].(float64); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s: expected at most %v items, got %d", path, n, len(v))
+		}
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateJSON(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := float64(len([]rune(v)))
+		if min, ok := s["minLength"].(float64); ok && n < min {
+			return fmt.Errorf("%s: expected at least %v characters", path, min)
+		}
+		if max, ok := s["maxLength"].(float64); ok && n > max {
+			return fmt.Errorf("%s: expected at most %v characters", path, max)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %q: %w", path, pattern, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: %q does not match %q", path, v, pattern)
+			}
+		}
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && v < min {
+			return fmt.Errorf("%s: %v is less than %v", path, v, min)
+		}
+		if max, ok := s["maximum"].(float64); ok && v > max {
+			return fmt.Errorf("%s: %v is greater than %v", path, v, max)
+		}
+		if min, ok := s["exclusiveMinimum"].(float64); ok && v <= min {
+			return fmt.Errorf("%s: %v is not greater than %v", path, v, min)
+		}
+		if max, ok := s["exclusiveMaximum"].(float64); ok && v >= max {
+			return fmt.Errorf("%s: %v is not less than %v", path, v, max)
+		}
+	}
+	return nil
+}
+
+func matchesType(t any, v any) bool {
+	types := schemaStrings(t)
+	if name, ok := t.(string); ok {
+		types = []string{name}
+	}
+	actual := jsonType(v)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func schemas(v any) []map[string]any {
+	items, _ := v.([]any)
+	ret := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(map[string]any); ok {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func schemaStrings(v any) []string {
+	items, _ := v.([]any)
+	ret := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// strictSchema reports whether the schema is accepted by the strict mode of OpenAI,
+// which requires all the properties of the objects, and no additional properties
+func strictSchema(s map[string]any) bool {
+	if props, ok := s["properties"].(map[string]any); ok {
+		if ap, _ := s["additionalProperties"].(bool); ap || s["additionalProperties"] == nil {
+			return false
+		}
+		if len(schemaStrings(s["required"])) != len(props) {
+			return false
+		}
+		for _, p := range props {
+			if ps, ok := p.(map[string]any); ok && !strictSchema(ps) {
+				return false
+			}
+		}
+	}
+	if items, ok := s["items"].(map[string]any); ok {
+		return strictSchema(items)
+	}
+	return true
+}
+
+var invalidSchemaName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// schemaName returns the name of the schema of the type, as accepted by the providers
+func schemaName(t reflect.Type) string {
+	name := indirect(t).Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	name = invalidSchemaName.ReplaceAllString(name, "_")
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,151 @@
+package chatmodelprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+type weather struct {
+	City        string   `json:"city" jsonschema:"description=Name of the city"`
+	Temperature float64  `json:"temperature"`
+	Unit        string   `json:"unit" jsonschema:"enum=celsius,enum=fahrenheit"`
+	Alerts      []string `json:"alerts,omitempty"`
+}
+
+func (w weather) Validate() error {
+	if w.Unit == "celsius" && w.Temperature < -90 {
+		return errors.New("temperature is below the coldest record")
+	}
+	return nil
+}
+
+func TestGenerateStructured(t *testing.T) {
+	ctx := t.Context()
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Mock: &MockConfig{Responses: []*MockResponse{
+		MockText(`{"city": "Paris", "temperature": 21}`),
+		MockText(`{"city": "Paris", "temperature": -100, "unit": "celsius"}`),
+		MockText("Here is the weather:\n```json\n{\"city\": \"Paris\", \"temperature\": 21, \"unit\": \"celsius\"}\n```"),
+	}}})
+	assert.Nil(t, err)
+	mock := cm.ToolCallingChatModel.(*MockChatModel)
+
+	input := []*schema.Message{schema.SystemMessage("be brief"), schema.UserMessage("weather in Paris?")}
+	w, err := GenerateStructured[weather](ctx, cm, input)
+	assert.Nil(t, err)
+	assert.Equal(t, weather{City: "Paris", Temperature: 21, Unit: "celsius"}, w)
+
+	calls := mock.Calls()
+	assert.Len(t, calls, 3)
+	assert.Equal(t, "be brief", calls[0].Input[0].Content)
+	assert.Contains(t, calls[0].Input[1].Content, `"required":["city","temperature","unit"]`)
+	assert.Contains(t, calls[1].Input[4].Content, "missing required property \"unit\"")
+	assert.Contains(t, calls[2].Input[6].Content, "below the coldest record")
+	assert.Len(t, input, 2)
+
+	_, err = GenerateStructured[weather](ctx, cm, input, WithStructuredRetries(0))
+	assert.ErrorIs(t, err, ErrMockExhausted)
+}
+
+func TestGenerateStructuredModes(t *testing.T) {
+	ctx := t.Context()
+	cm, err := NewChatModel(ctx, &Config{Provider: "mock", Mock: &MockConfig{Responses: []*MockResponse{
+		MockToolCall("weather", `{"city": "Rome", "temperature": 25, "unit": "celsius", "wind": 3}`),
+		MockToolCall("weather", `{"city": "Rome", "temperature": 25, "unit": "celsius"}`),
+		MockText(`{"value": ["Paris", 3]}`),
+		MockText(`{"value": ["Paris", "Rome"]}`),
+		MockText(`not json`),
+	}}})
+	assert.Nil(t, err)
+	mock := cm.ToolCallingChatModel.(*MockChatModel)
+
+	w, err := GenerateStructured[*weather](ctx, cm, []*schema.Message{schema.UserMessage("weather in Rome?")},
+		WithStructuredMode(StructuredModeTool))
+	assert.Nil(t, err)
+	assert.Equal(t, "Rome", w.City)
+	calls := mock.Calls()
+	assert.Equal(t, "weather", calls[0].Tools[0].Name)
+	assert.Equal(t, schema.Tool, calls[1].Input[2].Role)
+	assert.Equal(t, "call_weather", calls[1].Input[2].ToolCallID)
+	assert.Contains(t, calls[1].Input[2].Content, "unexpected property \"wind\"")
+
+	// the types which are not objects are wrapped in a value property
+	cities, err := GenerateStructured[[]string](ctx, cm, []*schema.Message{schema.UserMessage("capitals?")})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Paris", "Rome"}, cities)
+
+	_, err = GenerateStructured[[]string](ctx, cm, []*schema.Message{schema.UserMessage("capitals?")}, WithStructuredRetries(0))
+	assert.ErrorIs(t, err, ErrInvalidStructuredOutput)
+}
+
+func TestGenerateStructuredNative(t *testing.T) {
+	ctx := t.Context()
+	var format map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		_ = json.Unmarshal(body, &req)
+		format, _ = req["response_format"].(map[string]any)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"{\"city\":\"Paris\",\"temperature\":21,\"unit\":\"celsius\"}"},` +
+			`"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":10,"total_tokens":20}}`))
+	}))
+	defer srv.Close()
+
+	cm, err := NewChatModel(ctx, &Config{Provider: "openai", APIKey: "key", BaseURL: srv.URL, Model: "gpt-4o"})
+	assert.Nil(t, err)
+	w, err := GenerateStructured[weather](ctx, cm, []*schema.Message{schema.UserMessage("weather in Paris?")})
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", w.City)
+	assert.Equal(t, "json_schema", format["type"])
+	js := format["json_schema"].(map[string]any)
+	assert.Equal(t, "weather", js["name"])
+	// alerts is optional, which the strict mode does not accept
+	assert.Equal(t, false, js["strict"])
+	assert.Equal(t, false, js["schema"].(map[string]any)["additionalProperties"])
+}
+
+func TestGenerateStructuredJSONObject(t *testing.T) {
+	ctx := t.Context()
+	f := newFakeVendor(t, `{"id":"1","object":"chat.completion","model":"glm-4-plus","choices":[{"index":0,`+
+		`"message":{"role":"assistant","content":"{\"city\":\"Paris\",\"temperature\":21,\"unit\":\"celsius\"}"},`+
+		`"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":10,"total_tokens":20}}`)
+
+	// Zhipu only accepts the json_object response format
+	cm, err := NewChatModel(ctx, &Config{Provider: "zhipu", APIKey: "key", Model: "glm-4-plus", HTTPClient: f.client()})
+	assert.Nil(t, err)
+	w, err := GenerateStructured[weather](ctx, cm, []*schema.Message{schema.UserMessage("weather in Paris?")})
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", w.City)
+	_, body := f.last()
+	assert.Equal(t, map[string]any{"type": "json_object"}, body["response_format"])
+	msgs := body["messages"].([]any)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, "system", msgs[0].(map[string]any)["role"])
+	assert.Contains(t, msgs[0].(map[string]any)["content"], `"city"`)
+
+	// the endpoint of the config overrides the response format of the preset
+	cm, err = NewChatModel(ctx, &Config{
+		Provider:   "zhipu",
+		APIKey:     "key",
+		Model:      "glm-4-plus",
+		HTTPClient: f.client(),
+		Endpoint:   &EndpointPreset{ResponseFormat: "json_schema"},
+	})
+	assert.Nil(t, err)
+	_, err = GenerateStructured[weather](ctx, cm, []*schema.Message{schema.UserMessage("weather in Paris?")})
+	assert.Nil(t, err)
+	_, body = f.last()
+	assert.Equal(t, "json_schema", body["response_format"].(map[string]any)["type"])
+	assert.Len(t, body["messages"], 1)
+
+	_, err = NewChatModel(ctx, &Config{Provider: "zhipu", Model: "glm-4-plus", Endpoint: &EndpointPreset{ResponseFormat: "xml"}})
+	assert.ErrorContains(t, err, "invalid response format: xml")
+}
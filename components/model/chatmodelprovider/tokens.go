@@ -0,0 +1,327 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// TokenCounter counts the tokens of messages and tools as sent to a model
+type TokenCounter interface {
+	CountTokens(ctx context.Context, msgs []*schema.Message, tools []*schema.ToolInfo) (int, error)
+}
+
+// TokenCounterFunc is a function implementing TokenCounter
+type TokenCounterFunc func(ctx context.Context, msgs []*schema.Message, tools []*schema.ToolInfo) (int, error)
+
+func (f TokenCounterFunc) CountTokens(ctx context.Context, msgs []*schema.Message, tools []*schema.ToolInfo) (int, error) {
+	return f(ctx, msgs, tools)
+}
+
+// CountTokens counts the tokens of messages and tools as sent to the model, with Config.Tokenizer if set,
+// otherwise with an estimator calibrated on the tokenizer of the provider.
+// Estimates are meant to be within about 10% of the usage reported by the provider for text,
+// media parts are counted with a fixed number of tokens whatever their size.
+func (c *ChatModel) CountTokens(ctx context.Context, msgs []*schema.Message, tools []*schema.ToolInfo) (int, error) {
+	if c.cfg.Tokenizer != nil {
+		return c.cfg.Tokenizer.CountTokens(ctx, msgs, tools)
+	}
+	e, ok := tokenEstimators[c.mType]
+	if !ok {
+		e = defaultTokenEstimator
+	}
+	return e.CountTokens(ctx, msgs, tools)
+}
+
+// tokenEstimator estimates the tokens of the tokenizer of a provider from the number of characters
+type tokenEstimator struct {
+	charsPerToken   float64 // Characters per token of text other than CJK
+	tokensPerCJK    float64 // Tokens per CJK character
+	messageOverhead int     // Tokens of the role and separators of each message
+	toolOverhead    int     // Tokens of the wrapping of each tool definition
+	mediaTokens     int     // Tokens of an image, audio, video or file part
+}
+
+var defaultTokenEstimator = tokenEstimator{charsPerToken: 3.8, tokensPerCJK: 1, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000}
+
+// tokenEstimators are calibrated on the tokenizers, and the documented ratios, of each provider
+var tokenEstimators = map[modelType]tokenEstimator{
+	openaiModelType:      {charsPerToken: 4, tokensPerCJK: 0.8, messageOverhead: 3, toolOverhead: 8, mediaTokens: 765},
+	azureOpenaiModelType: {charsPerToken: 4, tokensPerCJK: 0.8, messageOverhead: 3, toolOverhead: 8, mediaTokens: 765},
+	claudeModelType:      {charsPerToken: 3.5, tokensPerCJK: 1.1, messageOverhead: 4, toolOverhead: 12, mediaTokens: 1600},
+	bedrockModelType:     {charsPerToken: 3.5, tokensPerCJK: 1.1, messageOverhead: 4, toolOverhead: 12, mediaTokens: 1600},
+	geminiModelType:      {charsPerToken: 4, tokensPerCJK: 0.7, messageOverhead: 4, toolOverhead: 8, mediaTokens: 258},
+	deepSeekModelType:    {charsPerToken: 3.3, tokensPerCJK: 0.6, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000},
+	arkModelType:         {charsPerToken: 3.5, tokensPerCJK: 0.65, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000},
+	arkBotModelType:      {charsPerToken: 3.5, tokensPerCJK: 0.65, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000},
+	qwenModelType:        {charsPerToken: 3.5, tokensPerCJK: 0.65, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000},
+	ollamaModelType:      {charsPerToken: 3.8, tokensPerCJK: 1, messageOverhead: 4, toolOverhead: 8, mediaTokens: 1000},
+}
+
+func (e tokenEstimator) CountTokens(_ context.Context, msgs []*schema.Message, tools []*schema.ToolInfo) (int, error) {
+	// the answer of the model is primed with the assistant role
+	total := 3
+	for _, m := range msgs {
+		if m == nil {
+			continue
+		}
+		total += e.messageOverhead + e.text(m.Content) + e.text(m.Name)
+		for _, tc := range m.ToolCalls {
+			total += e.messageOverhead + e.text(tc.Function.Name) + e.text(tc.Function.Arguments)
+		}
+		for _, p := range m.MultiContent {
+			total += e.part(p.Type, p.Text)
+		}
+		for _, p := range m.UserInputMultiContent {
+			total += e.part(p.Type, p.Text)
+		}
+		for _, p := range m.AssistantGenMultiContent {
+			total += e.part(p.Type, p.Text)
+		}
+	}
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		total += e.toolOverhead + e.text(t.Name) + e.text(t.Desc)
+		params, err := t.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert parameters of tool[%s]: %w", t.Name, err)
+		}
+		if params != nil {
+			b, err := json.Marshal(params)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal parameters of tool[%s]: %w", t.Name, err)
+			}
+			total += e.text(string(b))
+		}
+	}
+	return total, nil
+}
+
+func (e tokenEstimator) part(typ schema.ChatMessagePartType, text string) int {
+	if typ == schema.ChatMessagePartTypeText {
+		return e.text(text)
+	}
+	return e.mediaTokens
+}
+
+func (e tokenEstimator) text(s string) int {
+	if s == "" {
+		return 0
+	}
+	var cjk, other int
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return int(math.Ceil(float64(cjk)*e.tokensPerCJK + float64(other)/e.charsPerToken))
+}
+
+// ErrContextWindowExceeded is returned by TrimMessages when the messages that must be kept don't fit the window
+var ErrContextWindowExceeded = errors.New("messages exceed the context window")
+
+// SummarizeFunc summarizes the messages dropped by TrimMessages into a message inserted in their place
+type SummarizeFunc func(ctx context.Context, msgs []*schema.Message) (*schema.Message, error)
+
+type trimOption struct {
+	maxTokens     int
+	outputReserve *int
+	tools         []*schema.ToolInfo
+	summarize     SummarizeFunc
+}
+
+// TrimOptionFn is a function type for configuring TrimMessages
+type TrimOptionFn func(*trimOption)
+
+// WithTrimMaxTokens sets the max number of input tokens of the trimmed messages.
+// Default is the context length of the model minus the output reserve, see ChatModel.Capabilities.
+func WithTrimMaxTokens(n int) TrimOptionFn {
+	return func(o *trimOption) {
+		o.maxTokens = n
+	}
+}
+
+// WithTrimOutputReserve sets the number of tokens of the context window kept for the answer of the model.
+// Default is Config.MaxTokens if set, otherwise the max output tokens of the model.
+func WithTrimOutputReserve(n int) TrimOptionFn {
+	return func(o *trimOption) {
+		o.outputReserve = &n
+	}
+}
+
+// WithTrimTools sets the tools sent along the messages, whose definitions take up part of the window
+func WithTrimTools(tools ...*schema.ToolInfo) TrimOptionFn {
+	return func(o *trimOption) {
+		o.tools = tools
+	}
+}
+
+// WithSummarizer summarizes the dropped messages instead of discarding them, see NewModelSummarizer
+func WithSummarizer(fn SummarizeFunc) TrimOptionFn {
+	return func(o *trimOption) {
+		o.summarize = fn
+	}
+}
+
+// TrimMessages drops the oldest messages so that msgs fit the context window of the model.
+// The leading system messages and the last message are always kept, and an assistant message calling tools
+// is dropped together with the tool results answering it, so that no tool call is left without its result.
+// The messages kept after the system prompt start with a user message, which may be the summary of WithSummarizer.
+// It returns msgs unchanged if they fit, and ErrContextWindowExceeded if the kept messages don't fit.
+func (c *ChatModel) TrimMessages(ctx context.Context, msgs []*schema.Message, opts ...TrimOptionFn) ([]*schema.Message, error) {
+	o := &trimOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxTokens <= 0 {
+		caps, ok := c.Capabilities()
+		if !ok || caps.ContextLength <= 0 {
+			return nil, fmt.Errorf("context window of model[%s] is unknown, set it with WithTrimMaxTokens", c.cfg.Model)
+		}
+		reserve := caps.MaxOutputTokens
+		if c.cfg.MaxTokens != nil {
+			reserve = *c.cfg.MaxTokens
+		}
+		if o.outputReserve != nil {
+			reserve = *o.outputReserve
+		}
+		o.maxTokens = caps.ContextLength - reserve
+	}
+	return TrimMessages(ctx, c, msgs, o.maxTokens, opts...)
+}
+
+// TrimMessages drops the oldest messages so that msgs, counted by counter, take at most maxTokens,
+// see ChatModel.TrimMessages. WithTrimMaxTokens and WithTrimOutputReserve are ignored.
+func TrimMessages(ctx context.Context, counter TokenCounter, msgs []*schema.Message, maxTokens int, opts ...TrimOptionFn) ([]*schema.Message, error) {
+	o := &trimOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	n, err := counter.CountTokens(ctx, msgs, o.tools)
+	if err != nil {
+		return nil, err
+	}
+	if n <= maxTokens {
+		return msgs, nil
+	}
+
+	system := 0
+	for system < len(msgs) && msgs[system].Role == schema.System {
+		system++
+	}
+	head, units := msgs[:system], trimUnits(msgs[system:])
+	keep := func(dropped int, summary *schema.Message) []*schema.Message {
+		kept := append([]*schema.Message{}, head...)
+		if summary != nil {
+			kept = append(kept, summary)
+		}
+		return append(kept, flatten(units[dropped:])...)
+	}
+	// candidates returns the numbers of units to drop, from the fewest, that leave a user message first after
+	// the system prompt, as some providers require, e.g. Anthropic. A summary of the user role comes first itself.
+	candidates := func(from int, summaryFirst bool) []int {
+		var ret []int
+		for dropped := from; dropped < len(units); dropped++ {
+			if summaryFirst || units[dropped][0].Role == schema.User {
+				ret = append(ret, dropped)
+			}
+		}
+		return ret
+	}
+	// fewest returns the fewest units to drop among candidates for the kept messages to fit, with a binary search
+	// as the kept messages only shrink when more units are dropped
+	fewest := func(candidates []int, summary *schema.Message) (int, bool, error) {
+		found, ok := 0, false
+		lo, hi := 0, len(candidates)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			n, err := counter.CountTokens(ctx, keep(candidates[mid], summary), o.tools)
+			if err != nil {
+				return 0, false, err
+			}
+			if n <= maxTokens {
+				found, ok, hi = candidates[mid], true, mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		return found, ok, nil
+	}
+
+	// the fewest units to drop are found without summary, the summary is asked for once,
+	// then the following units are dropped too if it doesn't fit, without being summarized
+	dropped, ok, err := fewest(candidates(1, o.summarize != nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: the kept messages take over %d tokens", ErrContextWindowExceeded, maxTokens)
+	}
+	if o.summarize == nil {
+		return keep(dropped, nil), nil
+	}
+	summary, err := o.summarize(ctx, flatten(units[:dropped]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize messages: %w", err)
+	}
+	if dropped, ok, err = fewest(candidates(dropped, summary.Role == schema.User), summary); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: the kept messages and their summary take over %d tokens", ErrContextWindowExceeded, maxTokens)
+	}
+	return keep(dropped, summary), nil
+}
+
+// trimUnits groups the messages dropped together: an assistant message calling tools and the tool results
+// following it, or a single message. The last message is its own unit, so that it is always kept.
+func trimUnits(msgs []*schema.Message) [][]*schema.Message {
+	var units [][]*schema.Message
+	for i := 0; i < len(msgs); i++ {
+		unit := []*schema.Message{msgs[i]}
+		if msgs[i].Role == schema.Assistant && len(msgs[i].ToolCalls) > 0 {
+			for i+1 < len(msgs) && msgs[i+1].Role == schema.Tool {
+				i++
+				unit = append(unit, msgs[i])
+			}
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+func flatten(units [][]*schema.Message) []*schema.Message {
+	var msgs []*schema.Message
+	for _, u := range units {
+		msgs = append(msgs, u...)
+	}
+	return msgs
+}
+
+// summaryPrompt is the instruction given to the model summarizing the dropped messages
+const summaryPrompt = "Summarize the conversation above in a few sentences, keeping the facts, decisions and open questions needed to continue it."
+
+// NewModelSummarizer returns a SummarizeFunc asking m to summarize the dropped messages.
+// The summary is inserted as a user message, after the system prompt.
+func NewModelSummarizer(m model.BaseChatModel) SummarizeFunc {
+	return func(ctx context.Context, msgs []*schema.Message) (*schema.Message, error) {
+		input := make([]*schema.Message, 0, len(msgs)+1)
+		input = append(input, msgs...)
+		input = append(input, schema.UserMessage(summaryPrompt))
+		out, err := m.Generate(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return schema.UserMessage("Summary of the earlier conversation:\n" + out.Content), nil
+	}
+}
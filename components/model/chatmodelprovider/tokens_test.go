@@ -0,0 +1,117 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTokens(t *testing.T) {
+	ctx := t.Context()
+	cm, err := NewChatModel(ctx, &Config{Provider: "openai", Model: "gpt-4o"})
+	assert.Nil(t, err)
+
+	english, err := cm.CountTokens(ctx, []*schema.Message{schema.UserMessage(strings.Repeat("word ", 400))}, nil)
+	assert.Nil(t, err)
+	assert.InDelta(t, 500, english, 20)
+
+	chinese, err := cm.CountTokens(ctx, []*schema.Message{schema.UserMessage(strings.Repeat("你好", 500))}, nil)
+	assert.Nil(t, err)
+	assert.InDelta(t, 800, chinese, 20)
+
+	tool := &schema.ToolInfo{
+		Name: "get_weather",
+		Desc: "Get the weather of a city",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"city": {Type: schema.String, Desc: "Name of the city", Required: true},
+		}),
+	}
+	withTools, err := cm.CountTokens(ctx, []*schema.Message{schema.UserMessage(strings.Repeat("word ", 400))}, []*schema.ToolInfo{tool})
+	assert.Nil(t, err)
+	assert.Greater(t, withTools, english+20)
+
+	// the estimators depend on the provider
+	ds, err := NewChatModel(ctx, &Config{Provider: "deepseek", APIKey: "api-key", Model: "deepseek-chat"})
+	assert.Nil(t, err)
+	n, err := ds.CountTokens(ctx, []*schema.Message{schema.UserMessage(strings.Repeat("你好", 500))}, nil)
+	assert.Nil(t, err)
+	assert.Less(t, n, chinese)
+
+	cm, err = NewChatModel(ctx, &Config{Provider: "openai", Tokenizer: TokenCounterFunc(
+		func(_ context.Context, msgs []*schema.Message, _ []*schema.ToolInfo) (int, error) { return 42, nil })})
+	assert.Nil(t, err)
+	n, err = cm.CountTokens(ctx, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, n)
+}
+
+func TestTrimMessages(t *testing.T) {
+	ctx := t.Context()
+	// a message is a token
+	counter := TokenCounterFunc(func(_ context.Context, msgs []*schema.Message, _ []*schema.ToolInfo) (int, error) {
+		return len(msgs), nil
+	})
+	call := schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "get_weather"}}})
+	msgs := []*schema.Message{
+		schema.SystemMessage("system"),
+		schema.UserMessage("weather?"),
+		call,
+		schema.ToolMessage("sunny", "1"),
+		schema.AssistantMessage("it's sunny", nil),
+		schema.UserMessage("thanks"),
+	}
+
+	trimmed, err := TrimMessages(ctx, counter, msgs, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, msgs, trimmed)
+
+	trimmed, err = TrimMessages(ctx, counter, msgs, 5)
+	assert.Nil(t, err)
+	// the tool call is dropped with its result, and the kept messages start with a user message
+	assert.Equal(t, []*schema.Message{msgs[0], msgs[5]}, trimmed)
+
+	var summarized []*schema.Message
+	summarize := func(_ context.Context, dropped []*schema.Message) (*schema.Message, error) {
+		summarized = dropped
+		return schema.UserMessage("summary"), nil
+	}
+	trimmed, err = TrimMessages(ctx, counter, msgs, 4, WithSummarizer(summarize))
+	assert.Nil(t, err)
+	assert.Equal(t, []*schema.Message{msgs[0], schema.UserMessage("summary"), msgs[4], msgs[5]}, trimmed)
+	assert.Equal(t, msgs[1:4], summarized)
+
+	// the summary is asked for once, the messages dropped to make room for it are not summarized
+	var long []*schema.Message
+	for i := 0; i < 50; i++ {
+		long = append(long, schema.UserMessage("question"), schema.AssistantMessage("answer", nil))
+	}
+	calls := 0
+	trimmed, err = TrimMessages(ctx, counter, long, 10, WithSummarizer(func(_ context.Context, dropped []*schema.Message) (*schema.Message, error) {
+		calls++
+		summarized = dropped
+		return schema.AssistantMessage("summary", nil), nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Len(t, summarized, 90)
+	// the summary is not a user message, so that the kept messages after it start with one
+	assert.Equal(t, append([]*schema.Message{schema.AssistantMessage("summary", nil)}, long[92:]...), trimmed)
+
+	_, err = TrimMessages(ctx, counter, msgs, 1)
+	assert.ErrorIs(t, err, ErrContextWindowExceeded)
+
+	cm, err := NewChatModel(ctx, &Config{Provider: "openai", Model: "gpt-4o", Tokenizer: counter,
+		Capabilities: &Capabilities{ContextLength: 10, MaxOutputTokens: 6}})
+	assert.Nil(t, err)
+	trimmed, err = cm.TrimMessages(ctx, msgs)
+	assert.Nil(t, err)
+	assert.Equal(t, []*schema.Message{msgs[0], msgs[5]}, trimmed)
+
+	cm, err = NewChatModel(ctx, &Config{Provider: "openai", Model: "unknown"})
+	assert.Nil(t, err)
+	_, err = cm.TrimMessages(ctx, msgs)
+	assert.Error(t, err)
+}
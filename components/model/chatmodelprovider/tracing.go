@@ -0,0 +1,173 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	callbackutils "github.com/cloudwego/eino/utils/callbacks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/eino-contrib/agentkit-ve/components/model/chatmodelprovider"
+
+type tracingOption struct {
+	tracerProvider trace.TracerProvider
+}
+
+// TracingOptionFn is a function type for configuring the tracing handler
+type TracingOptionFn func(*tracingOption)
+
+// WithTracerProvider sets the tracer provider used by the tracing handler.
+// Default is the global tracer provider, see otel.GetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) TracingOptionFn {
+	return func(o *tracingOption) {
+		o.tracerProvider = tp
+	}
+}
+
+// NewTracingHandler returns an eino callback handler that records an OpenTelemetry span for every chat model call.
+// Spans carry the provider, model name, token usage and finish reason following the gen_ai semantic conventions.
+// Register it globally with callbacks.AppendGlobalHandlers, or per run with compose.WithCallbacks.
+func NewTracingHandler(opts ...TracingOptionFn) callbacks.Handler {
+	o := &tracingOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	t := &modelTracer{tracer: o.tracerProvider.Tracer(tracerName)}
+
+	return callbackutils.NewHandlerHelper().ChatModel(&callbackutils.ModelCallbackHandler{
+		OnStart:               t.onStart,
+		OnEnd:                 t.onEnd,
+		OnEndWithStreamOutput: t.onEndWithStreamOutput,
+		OnError:               t.onError,
+	}).Handler()
+}
+
+type modelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t *modelTracer) onStart(ctx context.Context, info *callbacks.RunInfo, input *model.CallbackInput) context.Context {
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.system", info.Type),
+	}
+	name := "chat"
+	if input != nil && input.Config != nil {
+		attrs = append(attrs, attribute.String("gen_ai.request.model", input.Config.Model))
+		name = "chat " + input.Config.Model
+		if input.Config.MaxTokens > 0 {
+			attrs = append(attrs, attribute.Int("gen_ai.request.max_tokens", input.Config.MaxTokens))
+		}
+	}
+	if input != nil {
+		attrs = append(attrs, attribute.Int("gen_ai.request.message_count", len(input.Messages)), attribute.Int("gen_ai.request.tool_count", len(input.Tools)))
+	}
+	ctx, _ = t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (t *modelTracer) onEnd(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+	span := trace.SpanFromContext(ctx)
+	recordModelOutput(span, output)
+	span.End()
+	return ctx
+}
+
+func (t *modelTracer) onEndWithStreamOutput(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+	span := trace.SpanFromContext(ctx)
+	go func() {
+		defer output.Close()
+		defer span.End()
+
+		var chunks []*model.CallbackOutput
+		for {
+			chunk, err := output.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+		recordModelOutput(span, concatCallbackOutputs(chunks))
+	}()
+	return ctx
+}
+
+func (t *modelTracer) onError(ctx context.Context, _ *callbacks.RunInfo, err error) context.Context {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+	return ctx
+}
+
+func recordModelOutput(span trace.Span, output *model.CallbackOutput) {
+	if output == nil {
+		return
+	}
+	if output.Config != nil && output.Config.Model != "" {
+		span.SetAttributes(attribute.String("gen_ai.response.model", output.Config.Model))
+	}
+	usage := output.TokenUsage
+	if output.Message != nil && output.Message.ResponseMeta != nil {
+		meta := output.Message.ResponseMeta
+		if meta.FinishReason != "" {
+			span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{meta.FinishReason}))
+		}
+		if usage == nil && meta.Usage != nil {
+			usage = &model.TokenUsage{
+				PromptTokens:     meta.Usage.PromptTokens,
+				CompletionTokens: meta.Usage.CompletionTokens,
+				TotalTokens:      meta.Usage.TotalTokens,
+			}
+		}
+	}
+	if usage != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+			attribute.Int("gen_ai.usage.cached_tokens", usage.PromptTokenDetails.CachedTokens),
+		)
+	}
+}
+
+// concatCallbackOutputs merges streamed callback output chunks, keeping the last reported config and usage
+func concatCallbackOutputs(chunks []*model.CallbackOutput) *model.CallbackOutput {
+	ret := &model.CallbackOutput{}
+	var msgs []*schema.Message
+	for _, c := range chunks {
+		if c == nil {
+			continue
+		}
+		if c.Message != nil {
+			msgs = append(msgs, c.Message)
+		}
+		if c.Config != nil {
+			ret.Config = c.Config
+		}
+		if c.TokenUsage != nil {
+			ret.TokenUsage = c.TokenUsage
+		}
+	}
+	if len(msgs) > 0 {
+		if m, err := schema.ConcatMessages(msgs); err == nil {
+			ret.Message = m
+		}
+	}
+	return ret
+}
@@ -0,0 +1,81 @@
+package chatmodelprovider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	handler := NewTracingHandler(WithTracerProvider(tp))
+	info := &callbacks.RunInfo{Type: "OpenAI", Component: "ChatModel"}
+
+	ctx := handler.OnStart(t.Context(), info, &model.CallbackInput{
+		Messages: []*schema.Message{schema.UserMessage("hi")},
+		Config:   &model.Config{Model: "gpt-4o"},
+	})
+	handler.OnEnd(ctx, info, &model.CallbackOutput{
+		Message: &schema.Message{
+			Role:         schema.Assistant,
+			Content:      "hello",
+			ResponseMeta: &schema.ResponseMeta{FinishReason: "stop"},
+		},
+		TokenUsage: &model.TokenUsage{PromptTokens: 3, CompletionTokens: 5, TotalTokens: 8},
+	})
+
+	ctx = handler.OnStart(t.Context(), info, &model.CallbackInput{Config: &model.Config{Model: "gpt-4o"}})
+	handler.OnError(ctx, info, errors.New("boom"))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "chat gpt-4o", spans[0].Name())
+	assert.Equal(t, "OpenAI", attrs["gen_ai.system"].AsString())
+	assert.Equal(t, "gpt-4o", attrs["gen_ai.request.model"].AsString())
+	assert.Equal(t, int64(3), attrs["gen_ai.usage.input_tokens"].AsInt64())
+	assert.Equal(t, int64(5), attrs["gen_ai.usage.output_tokens"].AsInt64())
+	assert.Equal(t, []string{"stop"}, attrs["gen_ai.response.finish_reasons"].AsStringSlice())
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+}
+
+func TestTracingHandlerStream(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	handler := NewTracingHandler(WithTracerProvider(tp))
+	info := &callbacks.RunInfo{Type: "Ark", Component: "ChatModel"}
+
+	ctx := handler.OnStart(t.Context(), info, &model.CallbackInput{Config: &model.Config{Model: "doubao"}})
+	sr, sw := schema.Pipe[callbacks.CallbackOutput](2)
+	sw.Send(&model.CallbackOutput{Message: schema.AssistantMessage("hel", nil)}, nil)
+	sw.Send(&model.CallbackOutput{
+		Message:    &schema.Message{Role: schema.Assistant, Content: "lo", ResponseMeta: &schema.ResponseMeta{FinishReason: "length"}},
+		TokenUsage: &model.TokenUsage{PromptTokens: 1, CompletionTokens: 2},
+	}, nil)
+	sw.Close()
+	handler.OnEndWithStreamOutput(ctx, info, sr)
+
+	assert.Eventually(t, func() bool { return len(recorder.Ended()) == 1 }, time.Second, 10*time.Millisecond)
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, int64(2), attrs["gen_ai.usage.output_tokens"].AsInt64())
+	assert.Equal(t, []string{"length"}, attrs["gen_ai.response.finish_reasons"].AsStringSlice())
+}
@@ -0,0 +1,440 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	callbackutils "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// Usage is the token usage and the cost of chat model calls, normalized across providers
+type Usage struct {
+	// Calls is the number of chat model calls.
+	Calls int
+	// InputTokens is the number of input tokens, including the cached ones.
+	InputTokens int
+	// CachedInputTokens is the number of input tokens read from the prompt cache of the provider.
+	CachedInputTokens int
+	// OutputTokens is the number of output tokens, including the reasoning ones.
+	OutputTokens int
+	// ReasoningTokens is the number of reasoning tokens, when the provider reports them apart from the output tokens.
+	ReasoningTokens int
+	// TotalTokens is the number of input and output tokens.
+	TotalTokens int
+	// Cost is the cost of the calls, in the currency of the price table, zero for models without a price.
+	Cost float64
+}
+
+func (u *Usage) add(o Usage) {
+	u.Calls += o.Calls
+	u.InputTokens += o.InputTokens
+	u.CachedInputTokens += o.CachedInputTokens
+	u.OutputTokens += o.OutputTokens
+	u.ReasoningTokens += o.ReasoningTokens
+	u.TotalTokens += o.TotalTokens
+	u.Cost += o.Cost
+}
+
+// Price is the price of a model per million tokens
+type Price struct {
+	Input       float64
+	CachedInput float64 // Price of the cached input tokens, the input price if zero
+	Output      float64
+}
+
+// UsageLevel is the granularity at which usage is aggregated and budgets are enforced
+type UsageLevel string
+
+const (
+	UsageLevelRequest UsageLevel = "request"
+	UsageLevelTask    UsageLevel = "task"
+	UsageLevelTenant  UsageLevel = "tenant"
+)
+
+// UsageScope attributes the chat model calls made with a context to a request, a task and a tenant.
+// Empty fields are not aggregated.
+type UsageScope struct {
+	RequestID string
+	TaskID    string
+	Tenant    string
+}
+
+func (s UsageScope) key(level UsageLevel) string {
+	switch level {
+	case UsageLevelRequest:
+		return s.RequestID
+	case UsageLevelTask:
+		return s.TaskID
+	case UsageLevelTenant:
+		return s.Tenant
+	}
+	return ""
+}
+
+var usageLevels = []UsageLevel{UsageLevelRequest, UsageLevelTask, UsageLevelTenant}
+
+// usageScopeKey is the context key of the usage scope
+type usageScopeKey struct{}
+
+// WithUsageScope returns a context attributing the chat model calls made with it to scope
+func WithUsageScope(ctx context.Context, scope UsageScope) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// UsageScopeFrom returns the usage scope set by WithUsageScope
+func UsageScopeFrom(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(UsageScope)
+	return scope
+}
+
+// Budget caps the usage of a request, a task or a tenant, zero fields are unlimited
+type Budget struct {
+	MaxTokens int
+	MaxCost   float64
+}
+
+func (b Budget) exceeded(u Usage) bool {
+	return (b.MaxTokens > 0 && u.TotalTokens >= b.MaxTokens) || (b.MaxCost > 0 && u.Cost >= b.MaxCost)
+}
+
+// BudgetFunc returns the budget of a request, a task or a tenant, false if unlimited
+type BudgetFunc func(level UsageLevel, key string) (Budget, bool)
+
+// ErrBudgetExceeded is returned by UsageTracker.Check, and by the calls of a ChatModel configured with the tracker,
+// once a budget is used up
+var ErrBudgetExceeded = errors.New("usage budget exceeded")
+
+// defaultUsageIdleTTL is how long the usage of an idle request or task is kept by default
+const defaultUsageIdleTTL = time.Hour
+
+type usageOption struct {
+	prices   map[string]Price
+	scopeFn  func(ctx context.Context) UsageScope
+	budgets  map[UsageLevel]Budget
+	budgetFn BudgetFunc
+	idleTTL  time.Duration
+}
+
+// UsageOptionFn is a function type for configuring the usage tracker
+type UsageOptionFn func(*usageOption)
+
+// WithPrices sets the price table, keyed by model name prefix, the longest prefix of the model name wins.
+// Models without a price are counted with a zero cost.
+func WithPrices(prices map[string]Price) UsageOptionFn {
+	return func(o *usageOption) {
+		o.prices = prices
+	}
+}
+
+// WithUsageScopeFunc sets the function attributing the calls made with a context to a request, a task and a tenant.
+// Default is UsageScopeFrom. Use it to read the ids set by the server, e.g. a2a.RequestID and a2a.TaskID.
+func WithUsageScopeFunc(fn func(ctx context.Context) UsageScope) UsageOptionFn {
+	return func(o *usageOption) {
+		o.scopeFn = fn
+	}
+}
+
+// WithBudget caps the usage of each request, task or tenant, depending on level
+func WithBudget(level UsageLevel, b Budget) UsageOptionFn {
+	return func(o *usageOption) {
+		o.budgets[level] = b
+	}
+}
+
+// WithBudgetFunc sets the budgets of each request, task or tenant, e.g. by the plan of the tenant.
+// It overrides WithBudget for the keys it returns a budget for.
+func WithBudgetFunc(fn BudgetFunc) UsageOptionFn {
+	return func(o *usageOption) {
+		o.budgetFn = fn
+	}
+}
+
+// WithUsageIdleTTL drops the usage of the requests and tasks without calls for ttl, default is an hour.
+// Their budget is reset, as by Forget. The usage of tenants is kept, zero keeps the usage of requests and tasks too.
+func WithUsageIdleTTL(ttl time.Duration) UsageOptionFn {
+	return func(o *usageOption) {
+		o.idleTTL = ttl
+	}
+}
+
+// UsageTracker aggregates the usage of chat model calls per request, task and tenant, and enforces budgets.
+// Register its Handler with callbacks.AppendGlobalHandlers, or per run with compose.WithCallbacks,
+// and set it in Config.UsageTracker so that calls over budget are rejected.
+type UsageTracker struct {
+	opts *usageOption
+
+	mu        sync.Mutex
+	total     Usage
+	scoped    map[UsageLevel]map[string]*scopedUsage
+	lastSweep time.Time
+}
+
+// scopedUsage is the usage of a request, a task or a tenant
+type scopedUsage struct {
+	Usage
+	lastSeen time.Time // Time of the last call
+}
+
+// NewUsageTracker returns a UsageTracker
+func NewUsageTracker(opts ...UsageOptionFn) *UsageTracker {
+	o := &usageOption{budgets: make(map[UsageLevel]Budget), idleTTL: defaultUsageIdleTTL}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.scopeFn == nil {
+		o.scopeFn = UsageScopeFrom
+	}
+	u := &UsageTracker{opts: o, scoped: make(map[UsageLevel]map[string]*scopedUsage), lastSweep: time.Now()}
+	for _, level := range usageLevels {
+		u.scoped[level] = make(map[string]*scopedUsage)
+	}
+	return u
+}
+
+// Handler returns an eino callback handler recording the usage of every chat model call
+func (u *UsageTracker) Handler() callbacks.Handler {
+	return callbackutils.NewHandlerHelper().ChatModel(&callbackutils.ModelCallbackHandler{
+		OnEnd:                 u.onEnd,
+		OnEndWithStreamOutput: u.onEndWithStreamOutput,
+	}).Handler()
+}
+
+func (u *UsageTracker) onEnd(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+	u.Record(ctx, usageModel(output), normalizeUsage(output))
+	return ctx
+}
+
+func (u *UsageTracker) onEndWithStreamOutput(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+	go func() {
+		defer output.Close()
+		var chunks []*model.CallbackOutput
+		for {
+			chunk, err := output.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				// the usage of failed streams is not reported by providers
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+		out := concatCallbackOutputs(chunks)
+		u.Record(ctx, usageModel(out), normalizeUsage(out))
+	}()
+	return ctx
+}
+
+// Record adds the usage of a call to modelName made with ctx, priced with the price table.
+// It is called by Handler, call it for usage reported outside of eino callbacks.
+func (u *UsageTracker) Record(ctx context.Context, modelName string, usage Usage) {
+	if usage.Calls == 0 {
+		usage.Calls = 1
+	}
+	if usage.Cost == 0 {
+		usage.Cost = u.cost(modelName, usage)
+	}
+	scope := u.opts.scopeFn(ctx)
+	now := time.Now()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.sweep(now)
+	u.total.add(usage)
+	for _, level := range usageLevels {
+		key := scope.key(level)
+		if key == "" {
+			continue
+		}
+		s, ok := u.lookup(level, key, now)
+		if !ok {
+			s = &scopedUsage{}
+			u.scoped[level][key] = s
+		}
+		s.add(usage)
+		s.lastSeen = now
+	}
+}
+
+// lookup returns the usage of a request, a task or a tenant, false if missing or idle for longer than the idle TTL
+func (u *UsageTracker) lookup(level UsageLevel, key string, now time.Time) (*scopedUsage, bool) {
+	s, ok := u.scoped[level][key]
+	if !ok {
+		return nil, false
+	}
+	if level != UsageLevelTenant && u.opts.idleTTL > 0 && now.Sub(s.lastSeen) > u.opts.idleTTL {
+		delete(u.scoped[level], key)
+		return nil, false
+	}
+	return s, true
+}
+
+// sweep drops the usage of the idle requests and tasks, at most once per idle TTL
+func (u *UsageTracker) sweep(now time.Time) {
+	if u.opts.idleTTL <= 0 || now.Sub(u.lastSweep) < u.opts.idleTTL {
+		return
+	}
+	u.lastSweep = now
+	for _, level := range []UsageLevel{UsageLevelRequest, UsageLevelTask} {
+		for key := range u.scoped[level] {
+			u.lookup(level, key, now)
+		}
+	}
+}
+
+// Check returns ErrBudgetExceeded if the request, the task or the tenant of ctx used up its budget
+func (u *UsageTracker) Check(ctx context.Context) error {
+	scope := u.opts.scopeFn(ctx)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, level := range usageLevels {
+		key := scope.key(level)
+		if key == "" {
+			continue
+		}
+		b, ok := u.budget(level, key)
+		if !ok {
+			continue
+		}
+		if s, ok := u.lookup(level, key, time.Now()); ok && b.exceeded(s.Usage) {
+			return fmt.Errorf("%w: %s[%s] used %d tokens and %.4f", ErrBudgetExceeded, level, key, s.TotalTokens, s.Cost)
+		}
+	}
+	return nil
+}
+
+func (u *UsageTracker) budget(level UsageLevel, key string) (Budget, bool) {
+	if u.opts.budgetFn != nil {
+		if b, ok := u.opts.budgetFn(level, key); ok {
+			return b, true
+		}
+	}
+	b, ok := u.opts.budgets[level]
+	return b, ok
+}
+
+// Usage returns the usage of a request, a task or a tenant
+func (u *UsageTracker) Usage(level UsageLevel, key string) Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if s, ok := u.lookup(level, key, time.Now()); ok {
+		return s.Usage
+	}
+	return Usage{}
+}
+
+// Total returns the usage of all the calls
+func (u *UsageTracker) Total() Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.total
+}
+
+// Forget drops the usage of a request, a task or a tenant, e.g. once the request ended, its budget is reset
+func (u *UsageTracker) Forget(level UsageLevel, key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.scoped[level], key)
+}
+
+func (u *UsageTracker) cost(modelName string, usage Usage) float64 {
+	var (
+		price  Price
+		prefix string
+		found  bool
+	)
+	modelName = strings.ToLower(modelName)
+	for p, pr := range u.opts.prices {
+		p = strings.ToLower(p)
+		if strings.HasPrefix(modelName, p) && (!found || len(p) > len(prefix)) {
+			price, prefix, found = pr, p, true
+		}
+	}
+	if !found {
+		return 0
+	}
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	return (float64(usage.InputTokens-usage.CachedInputTokens)*price.Input +
+		float64(usage.CachedInputTokens)*cachedPrice +
+		float64(usage.OutputTokens)*price.Output) / 1e6
+}
+
+func usageModel(output *model.CallbackOutput) string {
+	if output != nil && output.Config != nil {
+		return output.Config.Model
+	}
+	return ""
+}
+
+// normalizeUsage returns the usage of a call as reported by the eino-ext models of every provider.
+// Input tokens include the cached ones for all of them, Claude counts the cache writes as input.
+// Output tokens exclude the reasoning tokens for Gemini only, which reports them in the total.
+func normalizeUsage(output *model.CallbackOutput) Usage {
+	if output == nil {
+		return Usage{}
+	}
+	usage := output.TokenUsage
+	if usage == nil && output.Message != nil && output.Message.ResponseMeta != nil && output.Message.ResponseMeta.Usage != nil {
+		mu := output.Message.ResponseMeta.Usage
+		usage = &model.TokenUsage{
+			PromptTokens:       mu.PromptTokens,
+			PromptTokenDetails: model.PromptTokenDetails{CachedTokens: mu.PromptTokenDetails.CachedTokens},
+			CompletionTokens:   mu.CompletionTokens,
+			TotalTokens:        mu.TotalTokens,
+		}
+	}
+	if usage == nil {
+		return Usage{Calls: 1}
+	}
+	ret := Usage{
+		Calls:             1,
+		InputTokens:       usage.PromptTokens,
+		CachedInputTokens: usage.PromptTokenDetails.CachedTokens,
+		OutputTokens:      usage.CompletionTokens,
+	}
+	if hidden := usage.TotalTokens - usage.PromptTokens - usage.CompletionTokens; hidden > 0 {
+		ret.ReasoningTokens = hidden
+		ret.OutputTokens += hidden
+	}
+	ret.TotalTokens = ret.InputTokens + ret.OutputTokens
+	return ret
+}
+
+func (c *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if c.cfg.UsageTracker != nil {
+		if err := c.cfg.UsageTracker.Check(ctx); err != nil {
+			return nil, err
+		}
+	}
+	out, err := c.ToolCallingChatModel.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.normalizeReasoning(out), nil
+}
+
+func (c *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if c.cfg.UsageTracker != nil {
+		if err := c.cfg.UsageTracker.Check(ctx); err != nil {
+			return nil, err
+		}
+	}
+	sr, err := c.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderWithConvert(sr, func(chunk *schema.Message) (*schema.Message, error) {
+		return c.normalizeReasoning(chunk), nil
+	}), nil
+}
@@ -0,0 +1,106 @@
+package chatmodelprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageTracker(t *testing.T) {
+	tracker := NewUsageTracker(
+		WithPrices(map[string]Price{"gpt-4o": {Input: 2.5, CachedInput: 1.25, Output: 10}, "gpt-4o-mini": {Input: 0.15, Output: 0.6}}),
+		WithBudget(UsageLevelTask, Budget{MaxTokens: 3000}),
+		WithBudgetFunc(func(level UsageLevel, key string) (Budget, bool) {
+			if level == UsageLevelTenant && key == "free" {
+				return Budget{MaxCost: 0.001}, true
+			}
+			return Budget{}, false
+		}),
+	)
+	call := func(ctx context.Context, output *model.CallbackOutput) {
+		ctx = callbacks.InitCallbacks(ctx, &callbacks.RunInfo{Component: components.ComponentOfChatModel}, tracker.Handler())
+		callbacks.OnEnd(ctx, output)
+	}
+
+	ctx := WithUsageScope(t.Context(), UsageScope{RequestID: "r1", TaskID: "t1", Tenant: "acme"})
+	call(ctx, &model.CallbackOutput{
+		Config: &model.Config{Model: "gpt-4o-2024-08-06"},
+		TokenUsage: &model.TokenUsage{
+			PromptTokens: 1000, PromptTokenDetails: model.PromptTokenDetails{CachedTokens: 400}, CompletionTokens: 100, TotalTokens: 1100,
+		},
+	})
+	// Gemini reports the reasoning tokens in the total only, in the response meta
+	call(ctx, &model.CallbackOutput{
+		Config: &model.Config{Model: "gemini-2.5-flash"},
+		Message: &schema.Message{Role: schema.Assistant, ResponseMeta: &schema.ResponseMeta{
+			Usage: &schema.TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 200},
+		}},
+	})
+
+	u := tracker.Usage(UsageLevelTask, "t1")
+	assert.Equal(t, 2, u.Calls)
+	assert.Equal(t, 1100, u.InputTokens)
+	assert.Equal(t, 400, u.CachedInputTokens)
+	assert.Equal(t, 200, u.OutputTokens)
+	assert.Equal(t, 50, u.ReasoningTokens)
+	assert.Equal(t, 1300, u.TotalTokens)
+	assert.InDelta(t, (600*2.5+400*1.25+100*10)/1e6, u.Cost, 1e-9)
+	assert.Equal(t, u, tracker.Usage(UsageLevelTenant, "acme"))
+	assert.Equal(t, u, tracker.Total())
+	assert.NoError(t, tracker.Check(ctx))
+
+	// streams are recorded once consumed
+	sr, sw := schema.Pipe[*model.CallbackOutput](2)
+	sw.Send(&model.CallbackOutput{Config: &model.Config{Model: "gpt-4o-mini"}, Message: schema.AssistantMessage("hi", nil)}, nil)
+	sw.Send(&model.CallbackOutput{TokenUsage: &model.TokenUsage{PromptTokens: 1500, CompletionTokens: 200, TotalTokens: 1700}}, nil)
+	sw.Close()
+	sctx := callbacks.InitCallbacks(ctx, &callbacks.RunInfo{Component: components.ComponentOfChatModel}, tracker.Handler())
+	callbacks.OnEndWithStreamOutput(sctx, sr)
+	assert.Eventually(t, func() bool { return tracker.Total().Calls == 3 }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, tracker.Check(ctx), ErrBudgetExceeded)
+
+	// other tasks of the tenant are not over budget
+	other := WithUsageScope(t.Context(), UsageScope{TaskID: "t2", Tenant: "acme"})
+	assert.NoError(t, tracker.Check(other))
+
+	free := WithUsageScope(t.Context(), UsageScope{Tenant: "free"})
+	call(free, &model.CallbackOutput{Config: &model.Config{Model: "gpt-4o"}, TokenUsage: &model.TokenUsage{PromptTokens: 1000, TotalTokens: 1000}})
+	assert.ErrorIs(t, tracker.Check(free), ErrBudgetExceeded)
+
+	// calls over budget are rejected by the chat model
+	cm, err := NewChatModel(t.Context(), &Config{Provider: "openai", Model: "gpt-4o", UsageTracker: tracker})
+	assert.Nil(t, err)
+	_, err = cm.Generate(free, []*schema.Message{schema.UserMessage("hi")})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	withTools, err := cm.WithTools([]*schema.ToolInfo{{Name: "get_weather", Desc: "Get the weather of a city"}})
+	assert.Nil(t, err)
+	_, err = withTools.Stream(free, []*schema.Message{schema.UserMessage("hi")})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+
+	tracker.Forget(UsageLevelTenant, "free")
+	assert.NoError(t, tracker.Check(free))
+}
+
+func TestUsageIdleTTL(t *testing.T) {
+	tracker := NewUsageTracker(WithUsageIdleTTL(50 * time.Millisecond))
+	usage := Usage{InputTokens: 10, TotalTokens: 10}
+	tracker.Record(WithUsageScope(t.Context(), UsageScope{RequestID: "r1", TaskID: "t1", Tenant: "acme"}), "gpt-4o", usage)
+	assert.Equal(t, 10, tracker.Usage(UsageLevelRequest, "r1").TotalTokens)
+
+	// the usage of idle requests and tasks is dropped, the usage of tenants is kept
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, Usage{}, tracker.Usage(UsageLevelTask, "t1"))
+	tracker.Record(WithUsageScope(t.Context(), UsageScope{RequestID: "r2", Tenant: "acme"}), "gpt-4o", usage)
+	tracker.mu.Lock()
+	assert.Len(t, tracker.scoped[UsageLevelRequest], 1)
+	assert.Empty(t, tracker.scoped[UsageLevelTask])
+	tracker.mu.Unlock()
+	assert.Equal(t, 20, tracker.Usage(UsageLevelTenant, "acme").TotalTokens)
+	assert.Equal(t, 20, tracker.Total().TotalTokens)
+}
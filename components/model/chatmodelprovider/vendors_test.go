@@ -0,0 +1,257 @@
+package chatmodelprovider
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVendor answers the requests sent to any host, and records them
+type fakeVendor struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]any
+}
+
+func newFakeVendor(t *testing.T, answer string) *fakeVendor {
+	f := &fakeVendor{}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(data, &body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r)
+		f.bodies = append(f.bodies, body)
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(answer))
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// client returns a client sending the requests to the fake server, with their original host
+func (f *fakeVendor) client() *http.Client {
+	target, _ := url.Parse(f.srv.URL)
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Header.Set("X-Original-Host", r.URL.Host)
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+func (f *fakeVendor) last() (*http.Request, map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1], f.bodies[len(f.bodies)-1]
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestOpenAICompatibleVendors(t *testing.T) {
+	ctx := t.Context()
+	f := newFakeVendor(t, `{"id":"1","object":"chat.completion","model":"m","choices":[{"index":0,`+
+		`"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}],`+
+		`"usage":{"prompt_tokens":10,"completion_tokens":1,"total_tokens":11}}`)
+	maxTokens := 100
+	for provider, endpoint := range map[string]string{
+		"mistral":  "api.mistral.ai/v1/chat/completions",
+		"groq":     "api.groq.com/openai/v1/chat/completions",
+		"xai":      "api.x.ai/v1/chat/completions",
+		"moonshot": "api.moonshot.cn/v1/chat/completions",
+		"zhipu":    "open.bigmodel.cn/api/paas/v4/chat/completions",
+		"minimax":  "api.minimaxi.com/v1/chat/completions",
+		"hunyuan":  "api.hunyuan.cloud.tencent.com/v1/chat/completions",
+	} {
+		t.Run(provider, func(t *testing.T) {
+			cm, err := NewChatModel(ctx, &Config{Provider: provider, APIKey: "key", Model: "m", MaxTokens: &maxTokens, HTTPClient: f.client()})
+			assert.Nil(t, err)
+			assert.Equal(t, "OpenAI", cm.GetType())
+			out, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of France?")})
+			assert.Nil(t, err)
+			assert.Equal(t, "Paris", out.Content)
+
+			r, body := f.last()
+			assert.Equal(t, endpoint, r.Header.Get("X-Original-Host")+r.URL.Path)
+			assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+			assert.Equal(t, float64(100), body["max_tokens"])
+			assert.NotContains(t, body, "max_completion_tokens")
+		})
+	}
+
+	// BaseURL overrides the default one
+	cm, err := NewChatModel(ctx, &Config{Provider: "groq", APIKey: "key", BaseURL: "https://groq.internal/v1", Model: "m", HTTPClient: f.client()})
+	assert.Nil(t, err)
+	_, err = cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of France?")})
+	assert.Nil(t, err)
+	r, _ := f.last()
+	assert.Equal(t, "groq.internal", r.Header.Get("X-Original-Host"))
+
+	caps, ok := LookupCapabilities("groq", "llama-3.3-70b-versatile")
+	assert.True(t, ok)
+	assert.True(t, caps.ToolCalling)
+}
+
+func TestBedrock(t *testing.T) {
+	ctx := t.Context()
+	// the AWS SDK only accepts a custom CA bundle with its own HTTP client
+	t.Setenv("AWS_CA_BUNDLE", "")
+	f := newFakeVendor(t, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",`+
+		`"content":[{"type":"text","text":"Paris"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":1}}`)
+	maxTokens := 100
+	modelID := "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	cm, err := NewChatModel(ctx, &Config{
+		Provider:   "bedrock",
+		Model:      modelID,
+		MaxTokens:  &maxTokens,
+		Region:     "us-east-1",
+		AccessKey:  "AKIDEXAMPLE",
+		SecretKey:  "secret",
+		HTTPClient: f.client(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Claude", cm.GetType())
+	out, err := cm.Generate(ctx, []*schema.Message{schema.UserMessage("capital of France?")})
+	assert.Nil(t, err)
+	assert.Equal(t, "Paris", out.Content)
+
+	r, body := f.last()
+	assert.Equal(t, "bedrock-runtime.us-east-1.amazonaws.com", r.Header.Get("X-Original-Host"))
+	assert.Equal(t, "/model/"+modelID+"/invoke", r.URL.Path)
+	auth := r.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), auth)
+	assert.Contains(t, auth, "/us-east-1/bedrock/aws4_request")
+	assert.Empty(t, r.Header.Get("X-Api-Key"))
+	assert.NotContains(t, body, "model")
+	assert.Equal(t, "bedrock-2023-05-31", body["anthropic_version"])
+	assert.Equal(t, float64(100), body["max_tokens"])
+
+	caps, ok := cm.Capabilities()
+	assert.True(t, ok)
+	assert.True(t, caps.Reasoning)
+
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", "/missing/config")
+	_, err = NewChatModel(ctx, &Config{Provider: "bedrock", Model: modelID})
+	assert.ErrorContains(t, err, "Region is required")
+
+	t.Setenv("AWS_CA_BUNDLE", "/missing/ca.pem")
+	_, err = NewChatModel(ctx, &Config{Provider: "bedrock", Model: modelID, Region: "us-east-1", HTTPClient: &http.Client{}})
+	assert.ErrorContains(t, err, "failed to load AWS config")
+}
+
+func TestBedrockConverse(t *testing.T) {
+	ctx := t.Context()
+	t.Setenv("AWS_CA_BUNDLE", "")
+	f := newFakeVendor(t, `{"output":{"message":{"role":"assistant","content":[{"text":"Let me check."},`+
+		`{"toolUse":{"toolUseId":"call_1","name":"get_weather","input":{"city":"Paris"}}}]}},`+
+		`"stopReason":"tool_use","usage":{"inputTokens":10,"outputTokens":5,"totalTokens":15}}`)
+	modelID := "us.meta.llama3-3-70b-instruct-v1:0"
+	cm, err := NewChatModel(ctx, &Config{
+		Provider:   "bedrock",
+		Model:      modelID,
+		Region:     "us-east-1",
+		AccessKey:  "AKIDEXAMPLE",
+		SecretKey:  "secret",
+		HTTPClient: f.client(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bedrock", cm.GetType())
+	withTools, err := cm.WithTools([]*schema.ToolInfo{{
+		Name: "get_weather",
+		Desc: "Get the weather of a city",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"city": {Type: schema.String, Required: true},
+		}),
+	}})
+	assert.Nil(t, err)
+	out, err := withTools.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("be brief"),
+		schema.UserMessage("weather in Paris?"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "call_0", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lyon"}`}}}),
+		schema.ToolMessage("sunny", "call_0"),
+		schema.UserMessage("and now?"),
+	}, model.WithMaxTokens(100))
+	assert.Nil(t, err)
+	assert.Equal(t, "Let me check.", out.Content)
+	if assert.Len(t, out.ToolCalls, 1) {
+		assert.Equal(t, "call_1", out.ToolCalls[0].ID)
+		assert.Equal(t, "get_weather", out.ToolCalls[0].Function.Name)
+		assert.JSONEq(t, `{"city":"Paris"}`, out.ToolCalls[0].Function.Arguments)
+	}
+	assert.Equal(t, "tool_use", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 15, out.ResponseMeta.Usage.TotalTokens)
+
+	r, body := f.last()
+	assert.Equal(t, "bedrock-runtime.us-east-1.amazonaws.com", r.Header.Get("X-Original-Host"))
+	assert.Equal(t, "/model/"+modelID+"/converse", r.URL.Path)
+	assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+	assert.Equal(t, []any{map[string]any{"text": "be brief"}}, body["system"])
+	assert.Equal(t, float64(100), body["inferenceConfig"].(map[string]any)["maxTokens"])
+	messages := body["messages"].([]any)
+	if assert.Len(t, messages, 3) {
+		// the tool result and the next user message are sent in one user message
+		last := messages[2].(map[string]any)
+		assert.Equal(t, "user", last["role"])
+		assert.Len(t, last["content"], 2)
+	}
+	tools := body["toolConfig"].(map[string]any)["tools"].([]any)
+	spec := tools[0].(map[string]any)["toolSpec"].(map[string]any)
+	assert.Equal(t, "get_weather", spec["name"])
+	assert.Equal(t, "object", spec["inputSchema"].(map[string]any)["json"].(map[string]any)["type"])
+}
+
+func TestConverseStreamChunk(t *testing.T) {
+	index := int32(1)
+	chunks := []*schema.Message{
+		converseStreamChunk(&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			Delta: &types.ContentBlockDeltaMemberText{Value: "Let me check."},
+		}}),
+		converseStreamChunk(&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: &index,
+			Start:             &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{ToolUseId: aws.String("call_1"), Name: aws.String("get_weather")}},
+		}}),
+		converseStreamChunk(&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: &index,
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`{"city":`)}},
+		}}),
+		converseStreamChunk(&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: &index,
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`"Paris"}`)}},
+		}}),
+		converseStreamChunk(&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}}),
+		converseStreamChunk(&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{InputTokens: aws.Int32(10), OutputTokens: aws.Int32(5), TotalTokens: aws.Int32(15)},
+		}}),
+	}
+	assert.Nil(t, converseStreamChunk(&types.ConverseStreamOutputMemberContentBlockStop{}))
+
+	out, err := schema.ConcatMessages(chunks)
+	assert.Nil(t, err)
+	assert.Equal(t, "Let me check.", out.Content)
+	if assert.Len(t, out.ToolCalls, 1) {
+		assert.Equal(t, "call_1", out.ToolCalls[0].ID)
+		assert.Equal(t, `{"city":"Paris"}`, out.ToolCalls[0].Function.Arguments)
+	}
+	assert.Equal(t, "tool_use", out.ResponseMeta.FinishReason)
+	assert.Equal(t, 15, out.ResponseMeta.Usage.TotalTokens)
+}
@@ -0,0 +1,423 @@
+package a2atool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/eino-contrib/agentkit-ve/client/a2a"
+)
+
+const defaultAgentCardPath = "/.well-known/agent-card.json"
+
+type Config struct {
+	// AgentCardURL is the url of the remote agent card, e.g. "http://host:8000/.well-known/agent-card.json".
+	// The JSON-RPC endpoint is the url without the well-known suffix, use a2a.WithHandlerPath in ClientOptions to change it.
+	AgentCardURL string
+
+	// Name is the tool name, the snake_case agent name by default.
+	Name string
+	// Description is the tool description, built from the agent description and skills by default.
+	Description string
+
+	// Timeout bounds a single tool call, no timeout if zero.
+	Timeout time.Duration
+
+	// ClientOptions configures the underlying A2A client, e.g. authentication.
+	ClientOptions []a2a.OptionFn
+}
+
+// argument names of the generated tool schema
+const (
+	argInput  = "input"
+	argSkill  = "skill"
+	argTaskID = "task_id"
+)
+
+// metadataKeySkillID is the key of the message/send metadata naming the skill of the call,
+// as read by the A2A servers of the repo, e.g. for their per-skill timeouts
+const metadataKeySkillID = "skillId"
+
+type toolArgs struct {
+	Input  string `json:"input"`
+	Skill  string `json:"skill,omitempty"`
+	TaskID string `json:"task_id,omitempty"`
+}
+
+// A2ATool calls a remote A2A agent as a function tool.
+// The tool takes the request text, an optional skill id and, to answer a remote agent waiting for input,
+// the task id returned by the previous call.
+type A2ATool struct {
+	cli     *a2a.Client
+	info    *schema.ToolInfo
+	timeout time.Duration
+}
+
+var (
+	_ tool.InvokableTool  = (*A2ATool)(nil)
+	_ tool.StreamableTool = (*A2ATool)(nil)
+)
+
+// NewTool creates an invokable tool calling the remote agent with message/send
+func NewTool(ctx context.Context, cfg *Config) (tool.InvokableTool, error) {
+	return newA2ATool(ctx, cfg)
+}
+
+// NewStreamableTool creates a streamable tool calling the remote agent with message/stream
+func NewStreamableTool(ctx context.Context, cfg *Config) (tool.StreamableTool, error) {
+	return newA2ATool(ctx, cfg)
+}
+
+func newA2ATool(ctx context.Context, cfg *Config) (*A2ATool, error) {
+	if cfg == nil || cfg.AgentCardURL == "" {
+		return nil, fmt.Errorf("agent card url cannot be empty")
+	}
+
+	baseURL, cardPath, err := splitAgentCardURL(cfg.AgentCardURL)
+	if err != nil {
+		return nil, err
+	}
+	opts := append([]a2a.OptionFn{a2a.WithAgentCardPath(cardPath)}, cfg.ClientOptions...)
+	cli, err := a2a.New(ctx, baseURL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a2a client: %w", err)
+	}
+	card, err := cli.AgentCard(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent card: %w", err)
+	}
+
+	return &A2ATool{
+		cli:     cli,
+		info:    toolInfoFromCard(card, cfg.Name, cfg.Description),
+		timeout: cfg.Timeout,
+	}, nil
+}
+
+func (a *A2ATool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return a.info, nil
+}
+
+func (a *A2ATool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	params, err := a.toSendParams(argumentsInJSON)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := a.cli.SendMessage(ctx, params)
+	if err != nil {
+		return "", a.mapError(ctx, err)
+	}
+	if resp == nil {
+		return "", fmt.Errorf("remote agent[%s] returned an empty response", a.info.Name)
+	}
+	if resp.Message != nil {
+		return partsToText(resp.Message.Parts), nil
+	}
+	if resp.Task == nil {
+		return "", fmt.Errorf("remote agent[%s] returned an empty response", a.info.Name)
+	}
+
+	t := resp.Task
+	if !isSettled(t.Status.State) {
+		// the remote agent answered in non-blocking mode
+		t, err = a.cli.WaitTask(ctx, t.ID)
+		if err != nil {
+			return "", a.mapError(ctx, err)
+		}
+	}
+	return taskOutput(a.info.Name, t)
+}
+
+func (a *A2ATool) StreamableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (*schema.StreamReader[string], error) {
+	params, err := a.toSendParams(argumentsInJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.withTimeout(ctx)
+	stream, err := a.cli.SendMessageStreaming(ctx, params)
+	if err != nil {
+		cancel()
+		return nil, a.mapError(ctx, err)
+	}
+
+	sr, sw := schema.Pipe[string](10)
+	go func() {
+		defer func() {
+			_ = stream.Close()
+			sw.Close()
+			cancel()
+		}()
+		for {
+			event, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				sw.Send("", a.mapError(ctx, err))
+				return
+			}
+			text, err := a.eventOutput(event)
+			if text != "" && sw.Send(text, nil) {
+				return
+			}
+			if err != nil {
+				sw.Send("", err)
+				return
+			}
+		}
+	}()
+	return sr, nil
+}
+
+func (a *A2ATool) toSendParams(argumentsInJSON string) (*models.MessageSendParams, error) {
+	args := &toolArgs{}
+	if err := json.Unmarshal([]byte(argumentsInJSON), args); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal arguments of tool[%s]: %w", a.info.Name, err)
+	}
+	if args.Input == "" {
+		return nil, fmt.Errorf("argument %q of tool[%s] is required", argInput, a.info.Name)
+	}
+
+	params := &models.MessageSendParams{
+		Message: models.Message{
+			Role:      models.RoleUser,
+			MessageID: uuid.NewString(),
+			Parts:     []models.Part{{Kind: models.PartKindText, Text: &args.Input}},
+		},
+	}
+	if args.TaskID != "" {
+		params.Message.TaskID = &args.TaskID
+	}
+	if args.Skill != "" {
+		params.Metadata = map[string]any{metadataKeySkillID: args.Skill}
+	}
+	return params, nil
+}
+
+func (a *A2ATool) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.timeout)
+}
+
+func (a *A2ATool) mapError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("remote agent[%s] timed out after %s: %w", a.info.Name, a.timeout, ctx.Err())
+	}
+	return fmt.Errorf("failed to call remote agent[%s]: %w", a.info.Name, err)
+}
+
+// eventOutput returns the text carried by a streamed event, and an error if the task failed
+func (a *A2ATool) eventOutput(event *models.SendMessageStreamingResponseUnion) (string, error) {
+	switch {
+	case event.Message != nil:
+		return partsToText(event.Message.Parts), nil
+	case event.TaskArtifactUpdateEvent != nil:
+		return partsToText(event.TaskArtifactUpdateEvent.Artifact.Parts), nil
+	case event.Task != nil:
+		out, err := statusOutput(a.info.Name, event.Task.ID, event.Task.Status)
+		if err != nil {
+			return "", err
+		}
+		// the artifacts of the task are streamed as the ones of artifact-update events
+		texts := artifactTexts(event.Task.Artifacts)
+		if out != "" {
+			texts = append(texts, out)
+		}
+		return strings.Join(texts, "\n"), nil
+	case event.TaskStatusUpdateEvent != nil:
+		return statusOutput(a.info.Name, event.TaskStatusUpdateEvent.TaskID, event.TaskStatusUpdateEvent.Status)
+	}
+	return "", nil
+}
+
+// statusOutput renders the task status changes the caller must act on, the outputs themselves are streamed as messages
+func statusOutput(toolName, taskID string, status models.TaskStatus) (string, error) {
+	switch status.State {
+	case models.TaskStateInputRequired, models.TaskStateAuthRequired:
+		return inputRequiredOutput(taskID, status), nil
+	case models.TaskStateFailed, models.TaskStateRejected, models.TaskStateCanceled:
+		return "", taskError(toolName, taskID, status)
+	}
+	return "", nil
+}
+
+// artifactTexts returns the texts of the artifacts, skipping the ones without text
+func artifactTexts(artifacts []*models.Artifact) []string {
+	var texts []string
+	for _, artifact := range artifacts {
+		if artifact != nil {
+			if text := partsToText(artifact.Parts); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return texts
+}
+
+// taskOutput renders a settled task as tool output
+func taskOutput(toolName string, t *models.Task) (string, error) {
+	if t.Status.State != models.TaskStateCompleted {
+		if out, err := statusOutput(toolName, t.ID, t.Status); out != "" || err != nil {
+			return out, err
+		}
+	}
+
+	texts := artifactTexts(t.Artifacts)
+	if len(texts) == 0 && t.Status.Message != nil {
+		texts = append(texts, partsToText(t.Status.Message.Parts))
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+func inputRequiredOutput(taskID string, status models.TaskStatus) string {
+	prompt := ""
+	if status.Message != nil {
+		prompt = partsToText(status.Message.Parts)
+	}
+	return fmt.Sprintf("The agent requires more input. Call the tool again with %s=%q to answer: %s", argTaskID, taskID, prompt)
+}
+
+func taskError(toolName, taskID string, status models.TaskStatus) error {
+	reason := ""
+	if status.Message != nil {
+		reason = ": " + partsToText(status.Message.Parts)
+	}
+	return fmt.Errorf("remote agent[%s] task[%s] %s%s", toolName, taskID, status.State, reason)
+}
+
+func isSettled(state models.TaskState) bool {
+	switch state {
+	case models.TaskStateSubmitted, models.TaskStateWorking:
+		return false
+	}
+	return true
+}
+
+// partsToText renders A2A parts as text, data parts as JSON and file parts as references
+func partsToText(parts []models.Part) string {
+	var sb strings.Builder
+	for _, p := range parts {
+		switch p.Kind {
+		case models.PartKindText:
+			if p.Text != nil {
+				sb.WriteString(*p.Text)
+			}
+		case models.PartKindData:
+			b, err := json.Marshal(p.Data)
+			if err == nil {
+				sb.Write(b)
+			}
+		case models.PartKindFile:
+			if p.File != nil {
+				ref := p.File.Name
+				if p.File.URI != nil {
+					ref = *p.File.URI
+				}
+				sb.WriteString(fmt.Sprintf("[file %s (%s)]", ref, p.File.MimeType))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// toolInfoFromCard derives the tool schema from the agent card
+func toolInfoFromCard(card *models.AgentCard, name, desc string) *schema.ToolInfo {
+	if name == "" {
+		name = toolName(card.Name)
+	}
+	if desc == "" {
+		var sb strings.Builder
+		sb.WriteString(card.Description)
+		if len(card.Skills) > 0 {
+			sb.WriteString("\nSkills:")
+			for _, skill := range card.Skills {
+				sb.WriteString("\n- " + skill.Name)
+				if skill.Description != nil && *skill.Description != "" {
+					sb.WriteString(": " + *skill.Description)
+				}
+				if len(skill.Examples) > 0 {
+					sb.WriteString(" Examples: " + strings.Join(skill.Examples, "; "))
+				}
+			}
+		}
+		desc = sb.String()
+	}
+
+	params := map[string]*schema.ParameterInfo{
+		argInput: {
+			Type:     schema.String,
+			Desc:     "The request sent to the agent in natural language",
+			Required: true,
+		},
+		argTaskID: {
+			Type: schema.String,
+			Desc: "The task id returned when the agent requires more input, set it only to answer that task",
+		},
+	}
+	if len(card.Skills) > 0 {
+		ids := make([]string, 0, len(card.Skills))
+		for _, skill := range card.Skills {
+			ids = append(ids, skill.ID)
+		}
+		params[argSkill] = &schema.ParameterInfo{
+			Type: schema.String,
+			Desc: "The id of the agent skill best matching the request",
+			Enum: ids,
+		}
+	}
+
+	return &schema.ToolInfo{
+		Name:        name,
+		Desc:        desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(params),
+	}
+}
+
+var nonWordRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// toolName converts an agent name into a function name accepted by the model providers
+func toolName(agentName string) string {
+	name := strings.Trim(nonWordRegexp.ReplaceAllString(strings.ToLower(agentName), "_"), "_")
+	if name == "" {
+		return "a2a_agent"
+	}
+	return name
+}
+
+// splitAgentCardURL splits the agent card url into the agent base url and the card path
+func splitAgentCardURL(cardURL string) (baseURL, cardPath string, err error) {
+	u, err := url.Parse(cardURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid agent card url %q: %w", cardURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid agent card url %q: scheme and host are required", cardURL)
+	}
+	if strings.HasSuffix(u.Path, defaultAgentCardPath) {
+		cardPath = defaultAgentCardPath
+		u.Path = strings.TrimSuffix(u.Path, defaultAgentCardPath)
+	} else {
+		cardPath = u.Path
+		u.Path = ""
+	}
+	u.RawPath = ""
+	return u.String(), strings.TrimPrefix(cardPath, "/"), nil
+}
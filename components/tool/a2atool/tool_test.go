@@ -0,0 +1,195 @@
+package a2atool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	einoA2A "github.com/cloudwego/eino-ext/a2a/extension/eino"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino-ext/a2a/transport/jsonrpc"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/stretchr/testify/assert"
+
+	a2aServer "github.com/eino-contrib/agentkit-ve/server/a2a"
+)
+
+type echoAgent struct{}
+
+func (e *echoAgent) Name(_ context.Context) string { return "Echo Agent" }
+
+func (e *echoAgent) Description(_ context.Context) string { return "echoes the input" }
+
+func (e *echoAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	content := input.Messages[len(input.Messages)-1].Content
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage("echo: "+content, nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+// startServer serves the echo agent and returns its agent card url
+func startServer(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	desc := "repeats the input"
+	h := server.New(server.WithHostPorts(addr), server.WithExitWaitTime(0))
+	r, err := jsonrpc.NewRegistrar(ctx, &jsonrpc.ServerConfig{Router: h})
+	assert.NoError(t, err)
+	assert.NoError(t, einoA2A.RegisterServerHandlers(ctx, &echoAgent{}, &einoA2A.ServerConfig{
+		Registrar: r,
+		Skills:    []models.AgentSkill{{ID: "echo", Name: "Echo", Description: &desc, Examples: []string{"hello"}}},
+	}))
+	go h.Spin()
+	t.Cleanup(func() { _ = h.Shutdown(ctx) })
+	assert.Eventually(t, h.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	return "http://" + addr + "/.well-known/agent-card.json"
+}
+
+func TestTool(t *testing.T) {
+	ctx := context.Background()
+	cardURL := startServer(t)
+
+	it, err := NewTool(ctx, &Config{AgentCardURL: cardURL, Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+
+	info, err := it.Info(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo_agent", info.Name)
+	assert.Contains(t, info.Desc, "echoes the input")
+	assert.Contains(t, info.Desc, "- Echo: repeats the input Examples: hello")
+	js, err := info.ParamsOneOf.ToJSONSchema()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{argInput}, js.Required)
+	skill, ok := js.Properties.Get(argSkill)
+	if assert.True(t, ok) {
+		assert.Equal(t, []any{"echo"}, skill.Enum)
+	}
+
+	out, err := it.InvokableRun(ctx, `{"input":"hi","skill":"echo"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo: hi", out)
+
+	_, err = it.InvokableRun(ctx, `{}`)
+	assert.Error(t, err)
+
+	st, err := NewStreamableTool(ctx, &Config{AgentCardURL: cardURL, Name: "echo"})
+	assert.NoError(t, err)
+	sr, err := st.StreamableRun(ctx, `{"input":"yo"}`)
+	assert.NoError(t, err)
+	var chunks []string
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, []string{"echo: yo"}, chunks)
+}
+
+func TestSplitAgentCardURL(t *testing.T) {
+	base, path, err := splitAgentCardURL("https://host:8000/agents/echo/.well-known/agent-card.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://host:8000/agents/echo", base)
+	assert.Equal(t, ".well-known/agent-card.json", path)
+
+	base, path, err = splitAgentCardURL("http://host/card.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://host", base)
+	assert.Equal(t, "card.json", path)
+
+	_, _, err = splitAgentCardURL("/card.json")
+	assert.Error(t, err)
+}
+
+func TestEventOutput(t *testing.T) {
+	a := &A2ATool{info: &schema.ToolInfo{Name: "echo"}}
+	text := func(s string) []models.Part { return []models.Part{{Kind: models.PartKindText, Text: &s}} }
+
+	// the artifacts of a streamed task are output as the ones of artifact-update events
+	out, err := a.eventOutput(&models.SendMessageStreamingResponseUnion{Task: &models.Task{
+		ID:        "t1",
+		Status:    models.TaskStatus{State: models.TaskStateCompleted},
+		Artifacts: []*models.Artifact{{ArtifactID: "a1", Parts: text("report")}, {ArtifactID: "a2", Parts: text("summary")}},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "report\nsummary", out)
+
+	out, err = a.eventOutput(&models.SendMessageStreamingResponseUnion{TaskArtifactUpdateEvent: &models.TaskArtifactUpdateEvent{
+		TaskID:   "t1",
+		Artifact: models.Artifact{ArtifactID: "a1", Parts: text("report")},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "report", out)
+
+	_, err = a.eventOutput(&models.SendMessageStreamingResponseUnion{Task: &models.Task{
+		ID:        "t1",
+		Status:    models.TaskStatus{State: models.TaskStateFailed},
+		Artifacts: []*models.Artifact{{ArtifactID: "a1", Parts: text("report")}},
+	}})
+	assert.Error(t, err)
+}
+
+// sleepyAgent answers once its context is done, or after a second
+type sleepyAgent struct{}
+
+func (s *sleepyAgent) Name(_ context.Context) string { return "Sleepy Agent" }
+
+func (s *sleepyAgent) Description(_ context.Context) string { return "answers slowly" }
+
+func (s *sleepyAgent) Run(ctx context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		defer gen.Close()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			gen.Send(adk.EventFromMessage(schema.AssistantMessage("done", nil), nil, schema.Assistant, ""))
+		}
+	}()
+	return iter
+}
+
+func TestToolSkillTimeout(t *testing.T) {
+	ctx := context.Background()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	s := a2aServer.New()
+	assert.NoError(t, s.RegisterAgent(ctx, &sleepyAgent{}, a2aServer.WithSkills(
+		models.AgentSkill{ID: "quick", Name: "Quick"}, models.AgentSkill{ID: "slow", Name: "Slow"})))
+	h := server.New(server.WithHostPorts(addr), server.WithExitWaitTime(0))
+	assert.NoError(t, s.Mount(ctx, h, a2aServer.WithSkillTimeout("quick", 50*time.Millisecond)))
+	go h.Spin()
+	t.Cleanup(func() { _ = h.Shutdown(ctx) })
+	assert.Eventually(t, h.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	it, err := NewTool(ctx, &Config{AgentCardURL: "http://" + addr + "/.well-known/agent-card.json"})
+	assert.NoError(t, err)
+
+	// the server bounds the run with the timeout of the skill sent by the tool
+	start := time.Now()
+	_, err = it.InvokableRun(ctx, `{"input":"hi","skill":"quick"}`)
+	assert.ErrorContains(t, err, "agent run timed out after 50ms")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	out, err := it.InvokableRun(ctx, `{"input":"hi","skill":"slow"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", out)
+}
@@ -0,0 +1,51 @@
+package veauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// vefaasIAMCredentialPath is the file of the IAM credential mounted in the VeFaaS functions
+const vefaasIAMCredentialPath = "/var/run/secrets/iam/credential"
+
+type vefaasIAMCredential struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+}
+
+// GetDefaultArkAPIKey returns the Ark API key of the default credential: the VOLCENGINE_ACCESS_KEY and
+// VOLCENGINE_SECRET_KEY env vars if set, or the IAM credential of the VeFaaS function
+func GetDefaultArkAPIKey(opts ...OptionFn) (string, error) {
+	ak := os.Getenv("VOLCENGINE_ACCESS_KEY")
+	sk := os.Getenv("VOLCENGINE_SECRET_KEY")
+	if ak != "" && sk != "" {
+		return GetArkAPIKey(ak, sk, opts...)
+	}
+
+	credential, err := getVefaasIAMCredential()
+	if err != nil {
+		return "", err
+	}
+	opts = append(opts, WithSessionToken(credential.SessionToken))
+	return GetArkAPIKey(credential.AccessKeyID, credential.SecretAccessKey, opts...)
+}
+
+func getVefaasIAMCredential() (*vefaasIAMCredential, error) {
+	file, err := os.Open(vefaasIAMCredentialPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("get Volcengine access key or secret key from environment variables failed, and VeFaaS IAM file (path=%s) does not exist. Please check your configuration", vefaasIAMCredentialPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open VeFaaS IAM credential file: %w", err)
+	}
+	defer file.Close()
+
+	credential := &vefaasIAMCredential{}
+	if err := json.NewDecoder(file).Decode(credential); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON from VeFaaS IAM credential file: %w", err)
+	}
+	return credential, nil
+}
@@ -0,0 +1,155 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ArtifactStore keeps the contents of the files sent to the agent, so that large files are passed to the agent
+// by URI instead of inline in its input messages. Agents and tools read the files back with Open.
+// Implementations must be safe for concurrent use.
+type ArtifactStore interface {
+	// Put stores content under key and returns the URI of the stored file, it may replace an existing file with the same key
+	Put(ctx context.Context, key, mimeType string, content io.Reader) (uri string, err error)
+	// Open returns the content of a file stored by Put, the caller must close it
+	Open(ctx context.Context, uri string) (io.ReadCloser, error)
+}
+
+// NewDirArtifactStore returns an ArtifactStore keeping the files in dir, with "file://" URIs.
+// The directory is created if missing.
+func NewDirArtifactStore(dir string) (ArtifactStore, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve artifact dir: %w", err)
+	}
+	if err = os.MkdirAll(abs, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create artifact dir: %w", err)
+	}
+	return &dirArtifactStore{dir: abs}, nil
+}
+
+type dirArtifactStore struct {
+	dir string
+}
+
+func (s *dirArtifactStore) Put(_ context.Context, key, _ string, content io.Reader) (string, error) {
+	name := filepath.Base(filepath.Clean("/" + key))
+	// write then rename, so that readers never see a partial file
+	tmp, err := os.CreateTemp(s.dir, ".artifact-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create artifact file: %w", err)
+	}
+	if _, err = io.Copy(tmp, content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write artifact file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write artifact file: %w", err)
+	}
+	p := filepath.Join(s.dir, name)
+	if err = os.Rename(tmp.Name(), p); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write artifact file: %w", err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String(), nil
+}
+
+func (s *dirArtifactStore) Open(_ context.Context, uri string) (io.ReadCloser, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return nil, fmt.Errorf("invalid artifact uri: %s", uri)
+	}
+	p := filepath.FromSlash(u.Path)
+	if filepath.Dir(p) != s.dir {
+		return nil, fmt.Errorf("artifact uri outside of the store: %s", uri)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	return f, nil
+}
+
+// S3Client is the subset of an S3-compatible object storage client used by NewS3ArtifactStore.
+// Adapt the client of your storage to it, e.g. AWS S3, MinIO or TOS, or use NewInMemoryS3Client.
+type S3Client interface {
+	PutObject(ctx context.Context, bucket, key, contentType string, body io.Reader) error
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// NewS3ArtifactStore returns an ArtifactStore keeping the files in bucket under prefix, with "s3://bucket/key" URIs
+func NewS3ArtifactStore(client S3Client, bucket, prefix string) ArtifactStore {
+	return &s3ArtifactStore{client: client, bucket: bucket, prefix: prefix}
+}
+
+type s3ArtifactStore struct {
+	client S3Client
+	bucket string
+	prefix string
+}
+
+func (s *s3ArtifactStore) Put(ctx context.Context, key, mimeType string, content io.Reader) (string, error) {
+	key = path.Join(s.prefix, key)
+	if err := s.client.PutObject(ctx, s.bucket, key, mimeType, content); err != nil {
+		return "", fmt.Errorf("failed to put object[%s]: %w", key, err)
+	}
+	return "s3://" + s.bucket + "/" + key, nil
+}
+
+func (s *s3ArtifactStore) Open(ctx context.Context, uri string) (io.ReadCloser, error) {
+	key, ok := strings.CutPrefix(uri, "s3://"+s.bucket+"/")
+	if !ok {
+		return nil, fmt.Errorf("artifact uri outside of the store: %s", uri)
+	}
+	r, err := s.client.GetObject(ctx, s.bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object[%s]: %w", key, err)
+	}
+	return r, nil
+}
+
+// ErrObjectNotFound is returned by the client of NewInMemoryS3Client for unknown objects
+var ErrObjectNotFound = errors.New("object not found")
+
+// NewInMemoryS3Client returns an in-process stand-in for an S3-compatible storage, for tests and local development.
+// Objects are kept in memory and lost when the process exits.
+func NewInMemoryS3Client() S3Client {
+	return &inMemoryS3Client{objects: make(map[string][]byte)}
+}
+
+type inMemoryS3Client struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+func (c *inMemoryS3Client) PutObject(_ context.Context, bucket, key, _ string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[bucket+"/"+key] = data
+	return nil
+}
+
+func (c *inMemoryS3Client) GetObject(_ context.Context, bucket, key string) (io.ReadCloser, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	data, ok := c.objects[bucket+"/"+key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,274 @@
+package a2a
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	einoA2A "github.com/cloudwego/eino-ext/a2a/extension/eino"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// messageConvertor converts A2A messages into agent input messages.
+// File parts become multimodal parts of the message, their content is passed inline
+// or kept in the artifact store and passed by URI.
+type messageConvertor struct {
+	store      ArtifactStore // nil if file contents are passed inline
+	inlineSize int64         // Max size in bytes of the file contents passed inline when a store is set
+}
+
+func (c *messageConvertor) toSchemaMessages(ctx context.Context, messages []*models.Message) ([]adk.Message, error) {
+	ret := make([]adk.Message, 0, len(messages))
+	for _, m := range messages {
+		msg, err := c.toSchemaMessage(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, msg)
+	}
+	return ret, nil
+}
+
+// toSchemaMessage converts an A2A message into an agent input message.
+// The text of the parts is the content of the message if all parts are text or data parts,
+// user messages get multimodal parts otherwise, and agent messages keep their text and media parts.
+func (c *messageConvertor) toSchemaMessage(ctx context.Context, m *models.Message) (*schema.Message, error) {
+	if m == nil {
+		return nil, nil
+	}
+	ret := &schema.Message{Role: schema.User}
+	if m.Role == models.RoleAgent {
+		ret.Role = schema.Assistant
+	}
+	parts, allText, err := c.toInputParts(ctx, m.Parts)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case allText:
+		var sb strings.Builder
+		for _, p := range parts {
+			sb.WriteString(p.Text)
+		}
+		ret.Content = sb.String()
+	case ret.Role == schema.User:
+		ret.UserInputMultiContent = parts
+	default:
+		ret.AssistantGenMultiContent = toOutputParts(parts)
+	}
+
+	einoA2A.SetMessageID(ret, m.MessageID)
+	if m.ContextID != nil {
+		einoA2A.SetContextID(ret, *m.ContextID)
+	}
+	if m.TaskID != nil {
+		einoA2A.SetTaskID(ret, *m.TaskID)
+	}
+	for k, v := range m.Metadata {
+		ret.Extra[k] = v
+	}
+	return ret, nil
+}
+
+// toInputParts converts A2A parts, data parts become text parts holding their JSON
+func (c *messageConvertor) toInputParts(ctx context.Context, parts []models.Part) ([]schema.MessageInputPart, bool, error) {
+	ret := make([]schema.MessageInputPart, 0, len(parts))
+	allText := true
+	for _, part := range parts {
+		switch part.Kind {
+		case models.PartKindText:
+			if part.Text != nil {
+				ret = append(ret, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: *part.Text})
+			}
+		case models.PartKindData:
+			b, err := json.Marshal(part.Data)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to marshal data part: %w", err)
+			}
+			ret = append(ret, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: string(b)})
+		case models.PartKindFile:
+			if part.File == nil {
+				continue
+			}
+			allText = false
+			p, err := c.fileToInputPart(ctx, part.File)
+			if err != nil {
+				return nil, false, err
+			}
+			ret = append(ret, p)
+		}
+	}
+	return ret, allText, nil
+}
+
+// fileToInputPart converts a file part into an image, audio, video or file part depending on its MIME type.
+// Contents larger than the inline size are put in the artifact store and passed by URI.
+func (c *messageConvertor) fileToInputPart(ctx context.Context, f *models.FileContent) (schema.MessageInputPart, error) {
+	common := schema.MessagePartCommon{MIMEType: f.MimeType}
+	if f.Name != "" {
+		common.Extra = map[string]any{"name": f.Name}
+	}
+	switch {
+	case c.stored(f):
+		uri, err := c.storeFile(ctx, f)
+		if err != nil {
+			return schema.MessageInputPart{}, err
+		}
+		common.URL = &uri
+	case f.Bytes != nil:
+		data := *f.Bytes
+		common.Base64Data = &data
+	case f.URI != nil:
+		uri := *f.URI
+		common.URL = &uri
+	}
+
+	switch mediaType(f.MimeType) {
+	case "image":
+		return schema.MessageInputPart{Type: schema.ChatMessagePartTypeImageURL, Image: &schema.MessageInputImage{MessagePartCommon: common}}, nil
+	case "audio":
+		return schema.MessageInputPart{Type: schema.ChatMessagePartTypeAudioURL, Audio: &schema.MessageInputAudio{MessagePartCommon: common}}, nil
+	case "video":
+		return schema.MessageInputPart{Type: schema.ChatMessagePartTypeVideoURL, Video: &schema.MessageInputVideo{MessagePartCommon: common}}, nil
+	}
+	return schema.MessageInputPart{Type: schema.ChatMessagePartTypeFileURL, File: &schema.MessageInputFile{MessagePartCommon: common}}, nil
+}
+
+// stored reports whether the content of a file part is put in the artifact store rather than passed inline
+func (c *messageConvertor) stored(f *models.FileContent) bool {
+	return f.Bytes != nil && c.store != nil && decodedSize(*f.Bytes) > c.inlineSize
+}
+
+// storeFile puts the content of a file part in the artifact store and returns its URI
+func (c *messageConvertor) storeFile(ctx context.Context, f *models.FileContent) (string, error) {
+	uri, err := c.store.Put(ctx, artifactKey(*f.Bytes, f.MimeType), f.MimeType,
+		base64.NewDecoder(base64.StdEncoding, strings.NewReader(*f.Bytes)))
+	if err != nil {
+		return "", fmt.Errorf("failed to store file %q: %w", f.Name, err)
+	}
+	return uri, nil
+}
+
+// middleware puts the contents of the large file parts of the messages sent by message/send and message/stream
+// in the artifact store and replaces them with their URI, before the task is created,
+// so that the contents are neither kept in the task history nor passed to the agent.
+func (c *messageConvertor) middleware(ctx context.Context, rc *app.RequestContext) {
+	req, ok := parseRPCRequest(rc)
+	if !ok || !isTaskMethod(req.Method) {
+		rc.Next(ctx)
+		return
+	}
+	params, changed, err := c.storeFiles(ctx, req.Params)
+	if err != nil {
+		abortWithRPCError(rc, consts.StatusInternalServerError, req, &rpcError{Code: ErrCodeInternal, Message: err.Error()})
+		return
+	}
+	if changed {
+		body := map[string]json.RawMessage{}
+		if err = json.Unmarshal(rc.Request.Body(), &body); err == nil {
+			body["params"] = params
+			if data, err := json.Marshal(body); err == nil {
+				rc.Request.SetBody(data)
+				req.Params = params
+			}
+		}
+	}
+	rc.Next(ctx)
+}
+
+// storeFiles returns the params with the stored file parts of the message passed by URI.
+// The other fields are kept as sent, the params are returned unchanged if they are not decoded.
+func (c *messageConvertor) storeFiles(ctx context.Context, params json.RawMessage) (json.RawMessage, bool, error) {
+	var (
+		fields  map[string]json.RawMessage
+		message map[string]json.RawMessage
+		parts   []map[string]json.RawMessage
+	)
+	if json.Unmarshal(params, &fields) != nil || json.Unmarshal(fields["message"], &message) != nil ||
+		json.Unmarshal(message["parts"], &parts) != nil {
+		// left to the handler to answer
+		return params, false, nil
+	}
+	changed := false
+	for i, part := range parts {
+		f := &models.FileContent{}
+		if part["file"] == nil || json.Unmarshal(part["file"], f) != nil || !c.stored(f) {
+			continue
+		}
+		uri, err := c.storeFile(ctx, f)
+		if err != nil {
+			return nil, false, fmt.Errorf("file part %d: %w", i, err)
+		}
+		f.Bytes, f.URI = nil, &uri
+		if part["file"], err = json.Marshal(f); err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+	if !changed {
+		return params, false, nil
+	}
+	var err error
+	if message["parts"], err = json.Marshal(parts); err != nil {
+		return nil, false, err
+	}
+	if fields["message"], err = json.Marshal(message); err != nil {
+		return nil, false, err
+	}
+	if params, err = json.Marshal(fields); err != nil {
+		return nil, false, err
+	}
+	return params, true, nil
+}
+
+// toOutputParts converts the parts of an agent message, the files other than media are dropped
+func toOutputParts(parts []schema.MessageInputPart) []schema.MessageOutputPart {
+	ret := make([]schema.MessageOutputPart, 0, len(parts))
+	for _, p := range parts {
+		switch {
+		case p.Type == schema.ChatMessagePartTypeText:
+			ret = append(ret, schema.MessageOutputPart{Type: p.Type, Text: p.Text})
+		case p.Image != nil:
+			ret = append(ret, schema.MessageOutputPart{Type: p.Type, Image: &schema.MessageOutputImage{MessagePartCommon: p.Image.MessagePartCommon}})
+		case p.Audio != nil:
+			ret = append(ret, schema.MessageOutputPart{Type: p.Type, Audio: &schema.MessageOutputAudio{MessagePartCommon: p.Audio.MessagePartCommon}})
+		case p.Video != nil:
+			ret = append(ret, schema.MessageOutputPart{Type: p.Type, Video: &schema.MessageOutputVideo{MessagePartCommon: p.Video.MessagePartCommon}})
+		}
+	}
+	return ret
+}
+
+// mediaType returns the lower case top-level type of a MIME type, e.g. "image" for "image/png"
+func mediaType(mimeType string) string {
+	t, _, _ := strings.Cut(mimeType, "/")
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+// decodedSize returns the size of the content encoded in base64 by s
+func decodedSize(s string) int64 {
+	n := int64(len(s)) / 4 * 3
+	if rem := len(s) % 4; rem > 1 {
+		n += int64(rem - 1)
+	}
+	return n - int64(len(s)-len(strings.TrimRight(s, "=")))
+}
+
+// artifactKey names a file content after its hash, so that the same file is stored once
+func artifactKey(encoded, mimeType string) string {
+	sum := sha256.Sum256([]byte(encoded))
+	key := hex.EncodeToString(sum[:])
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		key += exts[0]
+	}
+	return key
+}
@@ -0,0 +1,172 @@
+package a2a
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// corsOption holds the CORS policy of the A2A handlers
+type corsOption struct {
+	AllowOrigins     []string      // Origins allowed to call the agent, "*" allows any origin
+	AllowMethods     []string      // Methods allowed in preflight requests
+	AllowHeaders     []string      // Request headers allowed in preflight requests
+	ExposeHeaders    []string      // Response headers readable by browsers
+	AllowCredentials bool          // Whether browsers send cookies and HTTP authentication to the agent
+	MaxAge           time.Duration // How long browsers cache preflight responses
+}
+
+// CORSOptionFn is a function type for configuring the CORS policy using the functional options pattern
+type CORSOptionFn func(*corsOption)
+
+// WithCORSAllowOrigins sets the origins allowed to call the agent, e.g. "https://playground.example.com".
+// "*" allows any origin, and "https://*.example.com" allows the subdomains of example.com.
+// Default is any origin.
+func WithCORSAllowOrigins(origins ...string) CORSOptionFn {
+	return func(o *corsOption) {
+		o.AllowOrigins = origins
+	}
+}
+
+// WithCORSAllowMethods sets the methods allowed in preflight requests, default is GET, POST and OPTIONS
+func WithCORSAllowMethods(methods ...string) CORSOptionFn {
+	return func(o *corsOption) {
+		o.AllowMethods = methods
+	}
+}
+
+// WithCORSAllowHeaders sets the request headers allowed in preflight requests.
+// Default is Content-Type, Authorization, Accept, Last-Event-ID, X-Request-ID, traceparent and tracestate.
+func WithCORSAllowHeaders(headers ...string) CORSOptionFn {
+	return func(o *corsOption) {
+		o.AllowHeaders = headers
+	}
+}
+
+// WithCORSExposeHeaders sets the response headers readable by browsers, default is X-Request-ID and Retry-After
+func WithCORSExposeHeaders(headers ...string) CORSOptionFn {
+	return func(o *corsOption) {
+		o.ExposeHeaders = headers
+	}
+}
+
+// WithCORSAllowCredentials lets browsers send cookies and HTTP authentication to the agent.
+// The allowed origins must be listed, as browsers reject credentials for any origin.
+func WithCORSAllowCredentials(allow bool) CORSOptionFn {
+	return func(o *corsOption) {
+		o.AllowCredentials = allow
+	}
+}
+
+// WithCORSMaxAge sets how long browsers cache preflight responses, default is 10 minutes
+func WithCORSMaxAge(maxAge time.Duration) CORSOptionFn {
+	return func(o *corsOption) {
+		o.MaxAge = maxAge
+	}
+}
+
+func newCORSOption(opts []CORSOptionFn) *corsOption {
+	o := &corsOption{
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "OPTIONS"},
+		AllowHeaders:  []string{"Content-Type", "Authorization", "Accept", "Last-Event-ID", RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders: []string{RequestIDHeader, "Retry-After"},
+		MaxAge:        10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin of a request from origin, empty if the origin is not allowed.
+// The origin is echoed rather than "*" when credentials are allowed, as browsers require.
+func (o *corsOption) allowOrigin(origin string) string {
+	for _, allowed := range o.AllowOrigins {
+		switch {
+		case allowed == "*":
+			if o.AllowCredentials {
+				return origin
+			}
+			return "*"
+		case strings.EqualFold(allowed, origin):
+			return origin
+		case strings.Contains(allowed, "://*."):
+			scheme, domain, _ := strings.Cut(allowed, "://*")
+			if strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, domain) {
+				return origin
+			}
+		}
+	}
+	return ""
+}
+
+// middleware answers the preflight requests and adds the CORS headers to the responses.
+// It runs before the other middlewares, since preflight requests never carry credentials.
+// The headers are set before the handler runs, so that streamed responses carry them too.
+func (o *corsOption) middleware(ctx context.Context, c *app.RequestContext) {
+	origin := string(c.Request.Header.Peek("Origin"))
+	if origin == "" {
+		c.Next(ctx)
+		return
+	}
+	c.Response.Header.Add("Vary", "Origin")
+	allowed := o.allowOrigin(origin)
+	preflight := string(c.Method()) == consts.MethodOptions && len(c.Request.Header.Peek("Access-Control-Request-Method")) > 0
+	if allowed == "" {
+		if preflight {
+			c.AbortWithStatus(consts.StatusForbidden)
+			return
+		}
+		c.Next(ctx)
+		return
+	}
+
+	c.Response.Header.Set("Access-Control-Allow-Origin", allowed)
+	if o.AllowCredentials {
+		c.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		if len(o.ExposeHeaders) > 0 {
+			c.Response.Header.Set("Access-Control-Expose-Headers", strings.Join(o.ExposeHeaders, ", "))
+		}
+		c.Next(ctx)
+		return
+	}
+
+	c.Response.Header.Set("Access-Control-Allow-Methods", strings.Join(o.AllowMethods, ", "))
+	c.Response.Header.Set("Access-Control-Allow-Headers", strings.Join(o.AllowHeaders, ", "))
+	if o.MaxAge > 0 {
+		c.Response.Header.Set("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+	}
+	c.AbortWithStatus(consts.StatusNoContent)
+}
+
+// agentCardCORS is the CORS policy of the agent card, readable by any origin without credentials
+var agentCardCORS = &corsOption{
+	AllowOrigins:  []string{"*"},
+	AllowMethods:  []string{"GET", "OPTIONS"},
+	AllowHeaders:  []string{"Accept", RequestIDHeader, "traceparent", "tracestate"},
+	ExposeHeaders: []string{RequestIDHeader},
+	MaxAge:        10 * time.Minute,
+}
+
+// agentCardRoutes registers the agent card on its own group, so that it is served without the middlewares of the handler
+type agentCardRoutes struct {
+	route.IRoutes
+	card route.IRoutes
+}
+
+func (r *agentCardRoutes) GET(path string, handlers ...app.HandlerFunc) route.IRoutes {
+	return r.card.GET(path, handlers...)
+}
+
+// noContent handles the preflight routes, the CORS middleware answers them before
+func noContent(_ context.Context, c *app.RequestContext) {
+	c.AbortWithStatus(consts.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package a2a
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+// requireAuth rejects the requests without an Authorization header
+func requireAuth(ctx context.Context, c *app.RequestContext) {
+	if len(c.Request.Header.Peek("Authorization")) == 0 {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Next(ctx)
+}
+
+func TestCORS(t *testing.T) {
+	h := newTestEngine(t, New(), WithMiddlewares(requireAuth),
+		WithCORS(WithCORSAllowOrigins("https://app.example.com", "https://*.example.org"), WithCORSAllowCredentials(true)))
+
+	// preflight requests are answered before authentication
+	resp := ut.PerformRequest(h.Engine, "OPTIONS", "/", nil,
+		ut.Header{Key: "Origin", Value: "https://app.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "POST"},
+		ut.Header{Key: "Access-Control-Request-Headers", Value: "content-type, authorization"}).Result()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+	assert.Equal(t, "https://app.example.com", string(resp.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, "true", string(resp.Header.Peek("Access-Control-Allow-Credentials")))
+	assert.Contains(t, string(resp.Header.Peek("Access-Control-Allow-Headers")), "Authorization")
+	assert.Equal(t, "600", string(resp.Header.Peek("Access-Control-Max-Age")))
+
+	resp = ut.PerformRequest(h.Engine, "OPTIONS", "/", nil,
+		ut.Header{Key: "Origin", Value: "https://evil.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "POST"}).Result()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+	assert.Empty(t, resp.Header.Peek("Access-Control-Allow-Origin"))
+
+	resp = ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hello"),
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+		ut.Header{Key: "Authorization", Value: "Bearer token"},
+		ut.Header{Key: "Origin", Value: "https://a.example.org"}).Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "https://a.example.org", string(resp.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Contains(t, string(resp.Header.Peek("Access-Control-Expose-Headers")), RequestIDHeader)
+
+	// the agent card is public
+	resp = ut.PerformRequest(h.Engine, "GET", "/.well-known/agent-card.json", nil,
+		ut.Header{Key: "Origin", Value: "https://other.example.net"}).Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, "*", string(resp.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Contains(t, string(resp.Body()), `"name":"echo"`)
+	resp = ut.PerformRequest(h.Engine, "OPTIONS", "/.well-known/agent-card.json", nil,
+		ut.Header{Key: "Origin", Value: "https://other.example.net"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "GET"}).Result()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	// without CORS, the agent card stays behind the middlewares
+	h = newTestEngine(t, New(), WithMiddlewares(requireAuth))
+	resp = ut.PerformRequest(h.Engine, "GET", "/.well-known/agent-card.json", nil).Result()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode())
+}
+
+func TestCORSStream(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &echoAgent{}))
+	addr := runTestServer(t, s, WithCORS())
+
+	body := `{"jsonrpc":"2.0","id":"1","method":"message/stream","params":{"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"hello"}]}}}`
+	req, err := http.NewRequest("POST", "http://"+addr+"/", strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://app.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+	events, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(events), `"completed"`)
+}
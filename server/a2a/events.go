@@ -0,0 +1,321 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// metadataKeyOfInterrupted marks an interrupted task, it must stay in sync with the eino-ext a2a extension
+// so that the next message on the task resumes the agent from its checkpoint.
+const metadataKeyOfInterrupted = "_a2a_eino_adk_interrupted"
+
+// eventConvertor converts the adk event stream of an agent run into A2A response events.
+// It follows the default convertor of the eino-ext a2a extension and adds the server-level hooks on top of it.
+type eventConvertor struct {
+	agentName       string
+	tracer          trace.Tracer            // nil if tracing is disabled
+	sessions        SessionStore            // nil if sessions are disabled
+	checkPoints     compose.CheckPointStore // Checkpoints of the interrupted runs
+	interruptPrompt InterruptPromptFunc
+	logger          *requestLogger // nil if logging is disabled
+	toolCallEvents  bool           // Whether tool calls and tool results are sent to the client
+	tasks           *taskRegistry  // Runs in progress, canceled by tasks/cancel
+}
+
+func (e *eventConvertor) convert(ctx context.Context, iter *adk.AsyncIterator[*adk.AgentEvent], writer func(p models.ResponseEvent) error) (err error) {
+	if e.tracer != nil {
+		var span trace.Span
+		ctx, span = e.tracer.Start(ctx, "invoke_agent "+e.agentName,
+			trace.WithAttributes(attribute.String("gen_ai.operation.name", "invoke_agent"), attribute.String("gen_ai.agent.name", e.agentName)))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+	outcome, auditErr := OutcomeCompleted, error(nil)
+	if e.logger != nil {
+		start := time.Now()
+		defer func() {
+			if err != nil {
+				outcome, auditErr = OutcomeFailed, err
+			}
+			e.logger.auditRun(ctx, e.agentName, start, outcome, auditErr)
+		}()
+	}
+	// the run is registered once the agent runs, so that the runs failing to start are never left registered
+	if run := taskRunFrom(ctx); run != nil {
+		e.tasks.start(run)
+		defer e.tasks.end(run)
+	}
+
+	// the events are received in another goroutine, so that the run ends when its context is done
+	// even if the agent doesn't stop
+	events := make(chan *adk.AgentEvent)
+	go func() {
+		defer close(events)
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		var event *adk.AgentEvent
+		var ok bool
+		select {
+		case event, ok = <-events:
+		case <-ctx.Done():
+			outcome, auditErr = e.expired(ctx)
+			return e.writeExpired(ctx, writer)
+		}
+		if !ok {
+			if ctx.Err() != nil {
+				// the agent stopped on the done context
+				outcome, auditErr = e.expired(ctx)
+				return e.writeExpired(ctx, writer)
+			}
+			if err = saveSessionTurn(ctx, e.sessions); err != nil {
+				return err
+			}
+			var metadata map[string]any
+			if run := taskRunFrom(ctx); run != nil && run.resumed {
+				// the task is no longer interrupted, its next message starts a new run
+				metadata = map[string]any{metadataKeyOfInterrupted: false}
+				if d, ok := e.checkPoints.(checkPointDeleter); ok {
+					if err = d.Delete(ctx, run.taskID); err != nil {
+						return fmt.Errorf("failed to delete checkpoint: %w", err)
+					}
+				}
+			}
+			// send final status update
+			return writer(models.ResponseEvent{
+				TaskStatusUpdateEventContent: &models.TaskStatusUpdateEventContent{
+					Status: models.TaskStatus{
+						State:     models.TaskStateCompleted,
+						Timestamp: time.Now().Format(time.RFC3339),
+					},
+					Final:    true,
+					Metadata: metadata,
+				},
+			})
+		}
+		if event.Err != nil {
+			if ctx.Err() != nil {
+				outcome, auditErr = e.expired(ctx)
+				return e.writeExpired(ctx, writer)
+			}
+			return fmt.Errorf("failed to execute agent: %w", event.Err)
+		}
+
+		interrupted, err := e.convertEvent(ctx, event, writer)
+		if err != nil {
+			return err
+		}
+		if interrupted {
+			outcome = OutcomeInputRequired
+			return saveSessionTurn(ctx, e.sessions)
+		}
+	}
+}
+
+// expired returns the outcome and the cause of a run whose context is done
+func (e *eventConvertor) expired(ctx context.Context) (string, error) {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return OutcomeFailed, cause
+	}
+	return OutcomeCanceled, cause
+}
+
+// writeExpired ends the task of a run whose context is done: failed if the run timed out, canceled otherwise,
+// e.g. by tasks/cancel. The status message tells the client why.
+func (e *eventConvertor) writeExpired(ctx context.Context, writer func(p models.ResponseEvent) error) error {
+	cause := context.Cause(ctx)
+	status := canceledStatus("task canceled")
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		text := "agent run timed out"
+		if run := taskRunFrom(ctx); run != nil && run.timeout > 0 {
+			text = fmt.Sprintf("agent run timed out after %s", run.timeout)
+		}
+		status = models.TaskStatus{
+			State:     models.TaskStateFailed,
+			Message:   newAgentMessage(models.Part{Kind: models.PartKindText, Text: &text}),
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+	case !errors.Is(cause, errTaskCanceled):
+		status = canceledStatus(fmt.Sprintf("task canceled: %v", cause))
+	}
+	return writer(models.ResponseEvent{
+		TaskStatusUpdateEventContent: &models.TaskStatusUpdateEventContent{
+			Status:   status,
+			Final:    true,
+			Metadata: map[string]any{metadataKeyOfInterrupted: false},
+		},
+	})
+}
+
+func (e *eventConvertor) convertEvent(ctx context.Context, event *adk.AgentEvent, writer func(p models.ResponseEvent) error) (interrupted bool, err error) {
+	if event.Action != nil && event.Action.TransferToAgent != nil {
+		text := fmt.Sprintf("transfer from agent[%s] to agent[%s]", event.AgentName, event.Action.TransferToAgent.DestAgentName)
+		return false, writer(models.ResponseEvent{
+			TaskStatusUpdateEventContent: &models.TaskStatusUpdateEventContent{
+				Status: models.TaskStatus{
+					State:     models.TaskStateWorking,
+					Message:   newAgentMessage(models.Part{Kind: models.PartKindText, Text: &text}),
+					Timestamp: time.Now().Format(time.RFC3339),
+				},
+			},
+		})
+	}
+
+	if event.Action != nil && event.Action.Interrupted != nil {
+		text, err := e.interruptPrompt(ctx, event.Action.Interrupted)
+		if err != nil {
+			return false, fmt.Errorf("failed to convert interrupted info: %w", err)
+		}
+		return true, writer(models.ResponseEvent{
+			TaskStatusUpdateEventContent: &models.TaskStatusUpdateEventContent{
+				Status: models.TaskStatus{
+					State:     models.TaskStateInputRequired,
+					Message:   newAgentMessage(models.Part{Kind: models.PartKindText, Text: &text}),
+					Timestamp: time.Now().Format(time.RFC3339),
+				},
+				Metadata: map[string]any{metadataKeyOfInterrupted: true},
+			},
+		})
+	}
+
+	if event.Output != nil && event.Output.MessageOutput != nil {
+		m, err := event.Output.MessageOutput.GetMessage()
+		if err != nil {
+			return false, fmt.Errorf("failed to get message: %w", err)
+		}
+		recordRunOutput(ctx, m)
+		if e.toolCallEvents {
+			if parts := toolCallParts(m, event.Output.MessageOutput.ToolName); len(parts) > 0 {
+				if err = writer(models.ResponseEvent{
+					TaskStatusUpdateEventContent: &models.TaskStatusUpdateEventContent{
+						Status: models.TaskStatus{
+							State:     models.TaskStateWorking,
+							Message:   newAgentMessage(parts...),
+							Timestamp: time.Now().Format(time.RFC3339),
+						},
+					},
+				}); err != nil {
+					return false, err
+				}
+				if m.Role == schema.Tool {
+					return false, nil
+				}
+			}
+		}
+		parts := messageToParts(m)
+		if len(parts) == 0 {
+			return false, nil
+		}
+		return false, writer(models.ResponseEvent{Message: newAgentMessage(parts...)})
+	}
+
+	return false, nil
+}
+
+func newAgentMessage(parts ...models.Part) *models.Message {
+	return &models.Message{
+		Role:      models.RoleAgent,
+		MessageID: uuid.NewString(),
+		Parts:     parts,
+	}
+}
+
+// messageToParts converts the content of an agent output message into A2A parts,
+// reasoning content and tool calls are not sent to the client.
+func messageToParts(m *schema.Message) []models.Part {
+	if m == nil {
+		return nil
+	}
+	if len(m.Content) > 0 {
+		text := m.Content
+		return []models.Part{{Kind: models.PartKindText, Text: &text}}
+	}
+
+	ret := make([]models.Part, 0, len(m.MultiContent))
+	for _, content := range m.MultiContent {
+		switch content.Type {
+		case schema.ChatMessagePartTypeText:
+			text := content.Text
+			ret = append(ret, models.Part{Kind: models.PartKindText, Text: &text})
+		case schema.ChatMessagePartTypeImageURL:
+			if content.ImageURL != nil {
+				ret = append(ret, toFilePart(content.ImageURL.MIMEType, content.ImageURL.URL))
+			}
+		case schema.ChatMessagePartTypeAudioURL:
+			if content.AudioURL != nil {
+				ret = append(ret, toFilePart(content.AudioURL.MIMEType, content.AudioURL.URL))
+			}
+		case schema.ChatMessagePartTypeVideoURL:
+			if content.VideoURL != nil {
+				ret = append(ret, toFilePart(content.VideoURL.MIMEType, content.VideoURL.URL))
+			}
+		case schema.ChatMessagePartTypeFileURL:
+			if content.FileURL != nil {
+				ret = append(ret, toFilePart(content.FileURL.MIMEType, content.FileURL.URL))
+			}
+		}
+	}
+	return ret
+}
+
+// toolCallParts converts the tool calls of an assistant message, or the result of a tool message, into data parts
+func toolCallParts(m *schema.Message, toolName string) []models.Part {
+	if m == nil {
+		return nil
+	}
+	if m.Role == schema.Tool {
+		if toolName == "" {
+			toolName = m.ToolName
+		}
+		return []models.Part{{Kind: models.PartKindData, Data: map[string]any{
+			"type": "tool_result", "id": m.ToolCallID, "name": toolName, "content": m.Content,
+		}}}
+	}
+	ret := make([]models.Part, 0, len(m.ToolCalls))
+	for _, tc := range m.ToolCalls {
+		ret = append(ret, models.Part{Kind: models.PartKindData, Data: map[string]any{
+			"type": "tool_call", "id": tc.ID, "name": tc.Function.Name, "arguments": tc.Function.Arguments,
+		}})
+	}
+	return ret
+}
+
+func toFilePart(mimeType, uri string) models.Part {
+	p := models.Part{Kind: models.PartKindFile, File: &models.FileContent{MimeType: mimeType}}
+	if strings.HasPrefix(uri, "http") || strings.HasPrefix(uri, "ftp") {
+		p.File.URI = &uri
+	} else {
+		p.File.Bytes = &uri
+	}
+	return p
+}
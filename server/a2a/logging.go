@@ -0,0 +1,209 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header carrying the request id, taken from the request if set and echoed in the response
+const RequestIDHeader = "X-Request-ID"
+
+// Outcomes of an agent run in the audit records
+const (
+	OutcomeCompleted     = "completed"
+	OutcomeInputRequired = "input-required"
+	OutcomeFailed        = "failed"
+	OutcomeCanceled      = "canceled"
+)
+
+// RedactFunc returns the text of a message as written to the logs, e.g. with personal data masked.
+// Returning an empty string leaves the content out of the logs.
+type RedactFunc func(ctx context.Context, text string) string
+
+// requestIDKey is the context key of the request id
+type requestIDKey struct{}
+
+// RequestID returns the id of the A2A request handled with ctx, empty if logging is disabled.
+// Agents and tools can log it to correlate their logs with the access and audit logs of the server.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger writes the access log of each request, and the audit record of each agent run
+type requestLogger struct {
+	logger    *slog.Logger  // Access logs and audit records, nil if disabled
+	audit     *slog.Logger  // JSON lines audit sink, nil if disabled
+	redact    RedactFunc    // Redacts the message contents of audit records, contents are left out if nil
+	callerKey CallerKeyFunc // Identifies the caller of a request
+}
+
+// newRequestLogger returns nil if neither a logger nor an audit sink is configured
+func newRequestLogger(runOpts *runOption) *requestLogger {
+	if runOpts.Logger == nil && runOpts.AuditLog == nil {
+		return nil
+	}
+	l := &requestLogger{
+		logger:    runOpts.Logger,
+		redact:    runOpts.LogRedactor,
+		callerKey: runOpts.CallerKeyFunc,
+	}
+	if runOpts.AuditLog != nil {
+		l.audit = slog.New(slog.NewJSONHandler(runOpts.AuditLog, nil))
+	}
+	if l.callerKey == nil {
+		l.callerKey = defaultCallerKey
+	}
+	return l
+}
+
+// middleware assigns a request id to the request and writes its access log once handled.
+// It runs before the middlewares of the server, so that rejected requests are logged too,
+// hence the caller is identified from the request as received.
+func (l *requestLogger) middleware(ctx context.Context, c *app.RequestContext) {
+	start := time.Now()
+	requestID := string(c.Request.Header.Peek(RequestIDHeader))
+	if requestID == "" || len(requestID) > 128 {
+		requestID = uuid.NewString()
+	}
+	c.Response.Header.Set(RequestIDHeader, requestID)
+
+	run := &taskRun{requestID: requestID, caller: l.callerKey(ctx, c)}
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	c.Next(context.WithValue(ctx, taskRunKey{}, run))
+
+	if l.logger == nil {
+		return
+	}
+	status := c.Response.StatusCode()
+	attrs := []slog.Attr{
+		slog.String("request_id", requestID),
+		slog.String("caller", run.caller),
+		slog.String("http.method", string(c.Method())),
+		slog.String("http.path", string(c.Path())),
+		slog.Int("http.status", status),
+		slog.Duration("latency", time.Since(start)),
+	}
+	level := slog.LevelInfo
+	if req, ok := parseRPCRequest(c); ok {
+		attrs = append(attrs, slog.String("rpc.method", req.Method), slog.Any("rpc.id", req.ID))
+		taskID, contextID := requestTaskIDs(c, req, run)
+		if taskID != "" {
+			attrs = append(attrs, slog.String("task_id", taskID))
+		}
+		if contextID != "" {
+			attrs = append(attrs, slog.String("context_id", contextID))
+		}
+	}
+	if rpcErr := parseRPCResponseError(c); rpcErr != nil {
+		attrs = append(attrs, slog.Int64("rpc.error_code", rpcErr.Code), slog.String("rpc.error", rpcErr.Message))
+		level = slog.LevelWarn
+	}
+	if status >= 500 {
+		level = slog.LevelError
+	} else if status >= 400 {
+		level = slog.LevelWarn
+	}
+	l.logger.LogAttrs(ctx, level, "a2a request", attrs...)
+}
+
+// requestTaskIDs returns the task and the context of a request, from its agent run if any,
+// from its params otherwise, or from the task returned by a non-streaming response
+func requestTaskIDs(c *app.RequestContext, req *rpcRequest, run *taskRun) (taskID, contextID string) {
+	taskID, contextID = run.ids()
+	if taskID != "" {
+		return taskID, contextID
+	}
+	params := struct {
+		ID      string `json:"id"`
+		Message struct {
+			TaskID    string `json:"taskId"`
+			ContextID string `json:"contextId"`
+		} `json:"message"`
+	}{}
+	_ = json.Unmarshal(req.Params, &params)
+	taskID, contextID = params.ID, params.Message.ContextID
+	if params.Message.TaskID != "" {
+		taskID = params.Message.TaskID
+	}
+	if taskID == "" && isJSONResponse(c) {
+		resp := struct {
+			Result struct {
+				Kind      string `json:"kind"`
+				ID        string `json:"id"`
+				ContextID string `json:"contextId"`
+			} `json:"result"`
+		}{}
+		if err := json.Unmarshal(c.Response.Body(), &resp); err == nil && resp.Result.Kind == "task" {
+			taskID, contextID = resp.Result.ID, resp.Result.ContextID
+		}
+	}
+	return taskID, contextID
+}
+
+// auditRun writes the audit record of an agent run once it ends
+func (l *requestLogger) auditRun(ctx context.Context, agentName string, start time.Time, outcome string, runErr error) {
+	attrs := []slog.Attr{slog.String("agent", agentName)}
+	if run := taskRunFrom(ctx); run != nil {
+		attrs = append(attrs,
+			slog.String("request_id", run.requestID),
+			slog.String("caller", run.caller),
+			slog.String("task_id", run.taskID),
+			slog.String("context_id", run.contextID),
+			slog.Bool("resumed", run.resumed),
+		)
+		if l.redact != nil {
+			if input := l.redactMessages(ctx, []*schema.Message{run.input}); input != "" {
+				attrs = append(attrs, slog.String("input", input))
+			}
+			if output := l.redactMessages(ctx, run.output); output != "" {
+				attrs = append(attrs, slog.String("output", output))
+			}
+		}
+	}
+	attrs = append(attrs, slog.String("outcome", outcome), slog.Duration("latency", time.Since(start)))
+	level := slog.LevelInfo
+	if runErr != nil {
+		attrs = append(attrs, slog.String("error", runErr.Error()))
+		level = slog.LevelError
+	}
+
+	if l.logger != nil {
+		l.logger.LogAttrs(ctx, level, "a2a agent run", attrs...)
+	}
+	if l.audit != nil {
+		l.audit.LogAttrs(ctx, level, "a2a agent run", attrs...)
+	}
+}
+
+// redactMessages returns the redacted text contents of messages, one line per message
+func (l *requestLogger) redactMessages(ctx context.Context, messages []*schema.Message) string {
+	var texts []string
+	for _, m := range messages {
+		if m == nil {
+			continue
+		}
+		text := m.Content
+		for _, part := range m.UserInputMultiContent {
+			if part.Type == schema.ChatMessagePartTypeText && part.Text != "" {
+				text = strings.TrimPrefix(text+"\n"+part.Text, "\n")
+			}
+		}
+		for _, part := range m.MultiContent {
+			if part.Type == schema.ChatMessagePartTypeText && part.Text != "" {
+				text = strings.TrimPrefix(text+"\n"+part.Text, "\n")
+			}
+		}
+		if text = l.redact(ctx, text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
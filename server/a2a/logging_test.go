@@ -0,0 +1,91 @@
+package a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		record := map[string]any{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogging(t *testing.T) {
+	ctx := context.Background()
+	logs, audit := &bytes.Buffer{}, &bytes.Buffer{}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &approvalAgent{}))
+	h := newTestEngine(t, s,
+		WithLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+		WithAuditLog(audit),
+		WithLogRedactor(func(_ context.Context, text string) string {
+			return strings.ReplaceAll(text, "Bob", "***")
+		}),
+		WithMiddlewares(func(ctx context.Context, c *app.RequestContext) {
+			if len(c.Request.Header.Peek("X-Deny")) > 0 {
+				c.AbortWithStatus(403)
+				return
+			}
+			c.Next(ctx)
+		}),
+	)
+
+	out := sendOnTask(t, h, "", "pay Bob")
+	taskID := out.Result.ID
+	out = sendOnTask(t, h, taskID, "yes")
+	assert.Equal(t, "completed", out.Result.Status.State)
+
+	body := sendMessageRequest(t, "hi")
+	resp := ut.PerformRequest(h.Engine, "POST", "/", body,
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+		ut.Header{Key: RequestIDHeader, Value: "req-1"},
+		ut.Header{Key: "X-Deny", Value: "1"}).Result()
+	assert.Equal(t, 403, resp.StatusCode())
+	assert.Equal(t, "req-1", string(resp.Header.Peek(RequestIDHeader)))
+
+	records := decodeLogLines(t, audit)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "a2a agent run", records[0]["msg"])
+		assert.Equal(t, "payer", records[0]["agent"])
+		assert.Equal(t, OutcomeInputRequired, records[0]["outcome"])
+		assert.Equal(t, "pay ***", records[0]["input"])
+		assert.Equal(t, taskID, records[0]["task_id"])
+		assert.Equal(t, OutcomeCompleted, records[1]["outcome"])
+		assert.Equal(t, true, records[1]["resumed"])
+		assert.Equal(t, "payment approved", records[1]["output"])
+		assert.NotEqual(t, records[0]["request_id"], records[1]["request_id"])
+		assert.NotEmpty(t, records[1]["caller"])
+	}
+
+	var access []map[string]any
+	for _, record := range decodeLogLines(t, logs) {
+		if record["msg"] == "a2a request" {
+			access = append(access, record)
+		}
+	}
+	if assert.Len(t, access, 3) {
+		assert.Equal(t, "message/send", access[0]["rpc.method"])
+		assert.Equal(t, taskID, access[0]["task_id"])
+		assert.Equal(t, float64(200), access[0]["http.status"])
+		assert.Equal(t, records[0]["request_id"], access[0]["request_id"])
+		assert.Equal(t, "req-1", access[2]["request_id"])
+		assert.Equal(t, float64(403), access[2]["http.status"])
+		assert.Equal(t, "WARN", access[2]["level"])
+	}
+}
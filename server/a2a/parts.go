@@ -0,0 +1,102 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// JSON-RPC error codes of rejected file parts
+const (
+	ErrCodeInvalidParams           int64 = -32602 // A file part is over the size limit
+	ErrCodeContentTypeNotSupported int64 = -32005 // A file part has a MIME type not allowed, as defined by A2A
+	ErrCodeInternal                int64 = -32603 // A file part could not be put in the artifact store
+)
+
+// fileLimits rejects the messages whose file parts are too large or of a MIME type not allowed
+type fileLimits struct {
+	maxSize      int64    // Max decoded size in bytes of a file part, unlimited if zero
+	allowedTypes []string // Allowed MIME types, e.g. "image/*", any if empty
+}
+
+// newFileLimits returns nil if no limit is configured
+func newFileLimits(agentOpts *agentOption) *fileLimits {
+	if agentOpts.MaxFileSize <= 0 && len(agentOpts.AllowedMIMETypes) == 0 {
+		return nil
+	}
+	return &fileLimits{maxSize: agentOpts.MaxFileSize, allowedTypes: agentOpts.AllowedMIMETypes}
+}
+
+// allowed reports whether the MIME type of a file part matches one of the allowed types,
+// a file part without MIME type is treated as "application/octet-stream"
+func (l *fileLimits) allowed(mimeType string) bool {
+	if len(l.allowedTypes) == 0 {
+		return true
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	for _, t := range l.allowedTypes {
+		t = strings.ToLower(t)
+		if t == "*/*" || t == mimeType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok && mediaType(mimeType) == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// middleware checks the file parts of the messages sent by message/send and message/stream,
+// before the task is created, so that rejected messages never reach the agent
+func (l *fileLimits) middleware(ctx context.Context, c *app.RequestContext) {
+	req, ok := parseRPCRequest(c)
+	if !ok || !isTaskMethod(req.Method) {
+		c.Next(ctx)
+		return
+	}
+	params := struct {
+		Message struct {
+			Parts []struct {
+				Kind string `json:"kind"`
+				File *struct {
+					Name     string  `json:"name"`
+					MimeType string  `json:"mimeType"`
+					Bytes    *string `json:"bytes"`
+				} `json:"file"`
+			} `json:"parts"`
+		} `json:"message"`
+	}{}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		// left to the handler to answer
+		c.Next(ctx)
+		return
+	}
+	for i, part := range params.Message.Parts {
+		if part.Kind != "file" || part.File == nil {
+			continue
+		}
+		if !l.allowed(part.File.MimeType) {
+			abortWithRPCError(c, consts.StatusUnsupportedMediaType, req, &rpcError{
+				Code:    ErrCodeContentTypeNotSupported,
+				Message: fmt.Sprintf("file part %d: MIME type %q is not supported", i, part.File.MimeType),
+			})
+			return
+		}
+		if l.maxSize > 0 && part.File.Bytes != nil && decodedSize(*part.File.Bytes) > l.maxSize {
+			abortWithRPCError(c, consts.StatusRequestEntityTooLarge, req, &rpcError{
+				Code:    ErrCodeInvalidParams,
+				Message: fmt.Sprintf("file part %d: %q is larger than %d bytes", i, part.File.Name, l.maxSize),
+			})
+			return
+		}
+	}
+	c.Next(ctx)
+}
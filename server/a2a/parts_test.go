@@ -0,0 +1,171 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// partsAgent describes the parts of its last input message
+type partsAgent struct{}
+
+func (p *partsAgent) Name(_ context.Context) string { return "parts" }
+
+func (p *partsAgent) Description(_ context.Context) string { return "describes the input parts" }
+
+func (p *partsAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	m := input.Messages[len(input.Messages)-1]
+	var desc []string
+	if m.Content != "" {
+		desc = append(desc, "text:"+m.Content)
+	}
+	for _, part := range m.UserInputMultiContent {
+		var common schema.MessagePartCommon
+		switch {
+		case part.Image != nil:
+			common = part.Image.MessagePartCommon
+		case part.File != nil:
+			common = part.File.MessagePartCommon
+		default:
+			desc = append(desc, string(part.Type)+":"+part.Text)
+			continue
+		}
+		if common.URL != nil {
+			desc = append(desc, fmt.Sprintf("%s:%s:url:%s", part.Type, common.MIMEType, *common.URL))
+		} else if common.Base64Data != nil {
+			desc = append(desc, fmt.Sprintf("%s:%s:base64:%s", part.Type, common.MIMEType, *common.Base64Data))
+		}
+	}
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage(strings.Join(desc, "|"), nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func sendParts(t *testing.T, h *server.Hertz, parts ...map[string]any) *protocol.Response {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": "1", "method": "message/send",
+		"params": map[string]any{"message": map[string]any{"role": "user", "messageId": "m1", "parts": parts}},
+	})
+	assert.NoError(t, err)
+	return ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+}
+
+func filePart(mimeType, content string) map[string]any {
+	return map[string]any{"kind": "file", "file": map[string]any{
+		"name": "f", "mimeType": mimeType, "bytes": base64.StdEncoding.EncodeToString([]byte(content)),
+	}}
+}
+
+func replyText(t *testing.T, resp *protocol.Response) string {
+	t.Helper()
+	out := &taskResult{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	return statusText(out)
+}
+
+func TestFileLimits(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &partsAgent{},
+		WithMaxFileSize(8), WithAllowedMIMETypes("image/*", "text/plain")))
+	h := newTestEngine(t, s)
+
+	resp := sendParts(t, h, filePart("application/pdf", "%PDF"))
+	assert.Equal(t, 415, resp.StatusCode())
+	assert.Equal(t, ErrCodeContentTypeNotSupported, rpcErrorCode(t, resp))
+
+	resp = sendParts(t, h, filePart("image/png", "too large image"))
+	assert.Equal(t, 413, resp.StatusCode())
+	assert.Equal(t, ErrCodeInvalidParams, rpcErrorCode(t, resp))
+
+	resp = sendParts(t, h, map[string]any{"kind": "text", "text": "look"}, filePart("image/png", "png"))
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.Equal(t, "text:look|image_url:image/png:base64:cG5n", replyText(t, resp))
+
+	// data parts are passed as JSON text
+	resp = sendParts(t, h, map[string]any{"kind": "data", "data": map[string]any{"city": "Paris"}})
+	assert.Equal(t, `text:{"city":"Paris"}`, replyText(t, resp))
+}
+
+func TestArtifactStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewS3ArtifactStore(NewInMemoryS3Client(), "bucket", "uploads")
+	tasks := NewInMemoryTaskStore(0)
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &partsAgent{},
+		WithArtifactStore(store), WithInlineFileSize(4), WithTaskStore(tasks)))
+	h := newTestEngine(t, s)
+
+	resp := sendParts(t, h, filePart("image/png", "png"), filePart("text/plain", "large text"))
+	out := &taskResult{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	small, large, _ := strings.Cut(statusText(out), "|")
+	assert.Equal(t, "image_url:image/png:base64:cG5n", small)
+	uri, ok := strings.CutPrefix(large, "file_url:text/plain:url:")
+	if assert.True(t, ok, large) {
+		assert.True(t, strings.HasPrefix(uri, "s3://bucket/uploads/"))
+		r, err := store.Open(ctx, uri)
+		if assert.NoError(t, err) {
+			content, _ := io.ReadAll(r)
+			_ = r.Close()
+			assert.Equal(t, "large text", string(content))
+		}
+	}
+
+	// the task history keeps the URI of the stored file rather than its content
+	task, ok, err := tasks.Get(ctx, out.Result.ID)
+	assert.NoError(t, err)
+	if assert.True(t, ok) && assert.NotEmpty(t, task.History) {
+		parts := task.History[0].Parts
+		if assert.Len(t, parts, 2) {
+			assert.Equal(t, "cG5n", *parts[0].File.Bytes)
+			assert.Nil(t, parts[1].File.Bytes)
+			if assert.NotNil(t, parts[1].File.URI) {
+				assert.Equal(t, uri, *parts[1].File.URI)
+			}
+		}
+	}
+
+	// a file failing to be stored rejects the message
+	s = New()
+	assert.NoError(t, s.RegisterAgent(ctx, &partsAgent{}, WithArtifactStore(failingArtifactStore{}), WithInlineFileSize(4)))
+	resp = sendParts(t, newTestEngine(t, s), filePart("text/plain", "large text"))
+	assert.Equal(t, 500, resp.StatusCode())
+	assert.Equal(t, ErrCodeInternal, rpcErrorCode(t, resp))
+
+	dirStore, err := NewDirArtifactStore(t.TempDir())
+	assert.NoError(t, err)
+	uri, err = dirStore.Put(ctx, "../a.txt", "text/plain", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "file:///"))
+	r, err := dirStore.Open(ctx, uri)
+	if assert.NoError(t, err) {
+		content, _ := io.ReadAll(r)
+		_ = r.Close()
+		assert.Equal(t, "hello", string(content))
+	}
+	_, err = dirStore.Open(ctx, "file:///etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestDecodedSize(t *testing.T) {
+	for _, s := range []string{"", "a", "ab", "abc", "abcd", "abcde"} {
+		encoded := base64.StdEncoding.EncodeToString([]byte(s))
+		assert.Equal(t, int64(len(s)), decodedSize(encoded), s)
+		assert.Equal(t, int64(len(s)), decodedSize(strings.TrimRight(encoded, "=")), s)
+	}
+}
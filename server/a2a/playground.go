@@ -0,0 +1,48 @@
+package a2a
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+//go:embed playground.html
+var playgroundPage string
+
+// playgroundConfig tells the playground page where the A2A handlers are, relative to the router of the page
+type playgroundConfig struct {
+	Path          string `json:"path"`
+	HandlerPath   string `json:"handlerPath"`
+	AgentCardPath string `json:"agentCardPath"`
+}
+
+// mountPlayground serves the playground page at path of router.
+// The page is served without the A2A middlewares, it sends the Authorization header entered by the user to the agent.
+func (s *Server) mountPlayground(router route.IRoutes, path string) error {
+	cfg := playgroundConfig{
+		Path:          "/" + strings.Trim(path, "/"),
+		HandlerPath:   s.opts.HandlerPath,
+		AgentCardPath: ".well-known/agent-card.json",
+	}
+	if s.opts.AgentCardPath != nil {
+		cfg.AgentCardPath = *s.opts.AgentCardPath
+	}
+	// json.Marshal escapes <, > and &, so the config can't close the script element
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal playground config: %w", err)
+	}
+	page := []byte(strings.Replace(playgroundPage, "/*PLAYGROUND_CONFIG*/null", string(b), 1))
+
+	router.GET(cfg.Path, func(_ context.Context, c *app.RequestContext) {
+		c.Response.Header.Set("Cache-Control", "no-store")
+		c.Data(consts.StatusOK, "text/html; charset=utf-8", page)
+	})
+	return nil
+}
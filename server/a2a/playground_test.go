@@ -0,0 +1,67 @@
+package a2a
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+// weatherAgent calls a weather tool before answering
+type weatherAgent struct{}
+
+func (w *weatherAgent) Name(_ context.Context) string { return "weather" }
+
+func (w *weatherAgent) Description(_ context.Context) string { return "tells the weather" }
+
+func (w *weatherAgent) Run(_ context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	call := schema.ToolCall{ID: "call-1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage("", []schema.ToolCall{call}), nil, schema.Assistant, ""))
+	gen.Send(adk.EventFromMessage(schema.ToolMessage("sunny", "call-1"), nil, schema.Tool, "get_weather"))
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage("It is sunny in Paris", nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func TestPlayground(t *testing.T) {
+	h := newTestEngine(t, New())
+	resp := ut.PerformRequest(h.Engine, "GET", "/playground", nil).Result()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &echoAgent{}, WithHandlerPath("/rpc")))
+	h = newTestEngine(t, s, WithPlayground("/debug/playground/"), WithMiddlewares(requireAuth))
+	resp = ut.PerformRequest(h.Engine, "GET", "/debug/playground", nil).Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Contains(t, string(resp.Header.ContentType()), "text/html")
+	assert.Contains(t, string(resp.Body()),
+		`const config = {"path":"/debug/playground","handlerPath":"/rpc","agentCardPath":".well-known/agent-card.json"};`)
+}
+
+func TestToolCallEvents(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &weatherAgent{}))
+	addr := runTestServer(t, s, WithToolCallEvents(true))
+
+	body := `{"jsonrpc":"2.0","id":"1","method":"message/stream","params":{"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"weather?"}]}}}`
+	resp, err := http.Post("http://"+addr+"/", "application/json", strings.NewReader(body))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	events, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(events), `"type":"tool_call"`)
+	assert.Contains(t, string(events), `"name":"get_weather"`)
+	assert.Contains(t, string(events), `"type":"tool_result"`)
+	assert.Contains(t, string(events), `It is sunny in Paris`)
+	// the tool result is only sent as a tool event
+	assert.Equal(t, 1, strings.Count(string(events), `sunny"`))
+}
@@ -0,0 +1,71 @@
+package a2a
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+const rpcRequestKey = "_agentkit_a2a_rpc_request"
+
+// rpcRequest is the subset of a JSON-RPC request inspected by the server middlewares
+type rpcRequest struct {
+	ID     any             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// rpcError is the error object of a JSON-RPC response
+type rpcError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// parseRPCRequest decodes the JSON-RPC request carried by c.
+// The decoded request is cached on the request context, so middlewares can call it repeatedly.
+// It returns false if the request is not a JSON-RPC call.
+func parseRPCRequest(c *app.RequestContext) (*rpcRequest, bool) {
+	if v, ok := c.Get(rpcRequestKey); ok {
+		req, ok := v.(*rpcRequest)
+		return req, ok && req != nil
+	}
+	var req *rpcRequest
+	if string(c.Method()) == "POST" {
+		r := &rpcRequest{}
+		if err := json.Unmarshal(c.Request.Body(), r); err == nil && r.Method != "" {
+			req = r
+		}
+	}
+	c.Set(rpcRequestKey, req)
+	return req, req != nil
+}
+
+// parseRPCResponseError extracts the error of a non-streaming JSON-RPC response written to c.
+// It returns nil if the response succeeded or is a stream.
+func parseRPCResponseError(c *app.RequestContext) *rpcError {
+	if !isJSONResponse(c) {
+		return nil
+	}
+	resp := struct {
+		Error *rpcError `json:"error"`
+	}{}
+	if err := json.Unmarshal(c.Response.Body(), &resp); err != nil {
+		return nil
+	}
+	return resp.Error
+}
+
+func isJSONResponse(c *app.RequestContext) bool {
+	return strings.HasPrefix(string(c.Response.Header.ContentType()), "application/json")
+}
+
+// abortWithRPCError aborts the request with a JSON-RPC error response answering req
+func abortWithRPCError(c *app.RequestContext, status int, req *rpcRequest, rpcErr *rpcError) {
+	c.AbortWithStatusJSON(status, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"error":   rpcErr,
+	})
+}
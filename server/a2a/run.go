@@ -0,0 +1,201 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+// resumeInputSessionKey is the adk session value holding the message a client answered an interrupt with
+const resumeInputSessionKey = "_a2a_resume_input"
+
+// InterruptPromptFunc returns the text asking the client for input when the agent interrupts,
+// sent in the message of the input-required task status
+type InterruptPromptFunc func(ctx context.Context, info *adk.InterruptInfo) (string, error)
+
+// GetResumeInput returns the message the client answered an interrupt with, when the agent is resumed.
+// It is meant to be called from the Resume method of agents, or from tools rerun after an interrupt.
+func GetResumeInput(ctx context.Context) (*schema.Message, bool) {
+	v, ok := adk.GetSessionValue(ctx, resumeInputSessionKey)
+	if !ok {
+		return nil, false
+	}
+	m, ok := v.(*schema.Message)
+	return m, ok
+}
+
+// taskRunKey is the context key of the current agent run
+type taskRunKey struct{}
+
+// taskRun holds the state of the agent run of a request, shared by the handlers given to the eino-ext a2a extension.
+// It is put in the request context by the middleware of taskRegistry, as the handlers only share the context of the request.
+// The run may go on after the request returns, so the ids read by the request are guarded by mu.
+type taskRun struct {
+	requestID string            // Id of the request, empty if logging is disabled
+	caller    string            // Caller of the request, empty if logging is disabled
+	mu        sync.Mutex        // Guards taskID and contextID
+	taskID    string            // Task of the run, also the checkpoint id of the run
+	contextID string            // Context of the task, the contextId sent by the client if any
+	input     *schema.Message   // Message sent by the client
+	output    []*schema.Message // Answers of the agent kept for the session and the audit records
+	resumed   bool              // Whether the run resumes an interrupted task
+
+	cancel  context.CancelCauseFunc // Cancels the context of the run, nil if the request doesn't run the agent
+	timeout time.Duration           // Max duration of the run, unlimited if zero
+}
+
+// ids returns the task and the context of the run, empty until the run starts
+func (r *taskRun) ids() (taskID, contextID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.taskID, r.contextID
+}
+
+// TaskID returns the id of the A2A task whose agent run is handled with ctx, empty outside of a run.
+// Agents, tools and model callbacks can use it to attribute their work to the task, e.g. token usage.
+func TaskID(ctx context.Context) string {
+	run := taskRunFrom(ctx)
+	if run == nil {
+		return ""
+	}
+	taskID, _ := run.ids()
+	return taskID
+}
+
+func taskRunFrom(ctx context.Context) *taskRun {
+	run, _ := ctx.Value(taskRunKey{}).(*taskRun)
+	return run
+}
+
+// runOptions records the task and the input of the run, the run is registered by eventConvertor once the agent runs.
+// The context is the contextId sent by the client if any, so that clients continue a conversation
+// by sending the contextId of its first task.
+func runOptions(conv *messageConvertor) func(ctx context.Context, t *models.Task, input *models.Message, _ map[string]any) ([]adk.AgentRunOption, error) {
+	return func(ctx context.Context, t *models.Task, input *models.Message, _ map[string]any) ([]adk.AgentRunOption, error) {
+		run := taskRunFrom(ctx)
+		if run == nil {
+			return nil, nil
+		}
+		contextID := t.ContextID
+		if input.ContextID != nil && *input.ContextID != "" {
+			contextID = *input.ContextID
+		}
+		run.mu.Lock()
+		run.taskID, run.contextID = t.ID, contextID
+		run.mu.Unlock()
+		m, err := conv.toSchemaMessage(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		run.input = m
+		return nil, nil
+	}
+}
+
+// resumeOptions hands the answer of the client to the resumed agent, see GetResumeInput.
+// The answer is the input of the run, converted by runOptions.
+func resumeOptions(conv *messageConvertor) func(ctx context.Context, _ *models.Task, input *models.Message, _ map[string]any) ([]adk.AgentRunOption, error) {
+	return func(ctx context.Context, _ *models.Task, input *models.Message, _ map[string]any) ([]adk.AgentRunOption, error) {
+		run := taskRunFrom(ctx)
+		if run == nil || run.input == nil {
+			m, err := conv.toSchemaMessage(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			run = &taskRun{input: m}
+		}
+		run.resumed = true
+		return []adk.AgentRunOption{adk.WithSessionValues(map[string]any{resumeInputSessionKey: run.input})}, nil
+	}
+}
+
+// recordRunOutput keeps the answers of the agent for the session and the audit records,
+// tool calls and tool results are internal to the run
+func recordRunOutput(ctx context.Context, m *schema.Message) {
+	run := taskRunFrom(ctx)
+	if run == nil || m == nil || m.Role != schema.Assistant || len(m.ToolCalls) > 0 {
+		return
+	}
+	if m.Content == "" && len(m.MultiContent) == 0 {
+		return
+	}
+	run.output = append(run.output, &schema.Message{Role: schema.Assistant, Content: m.Content, MultiContent: m.MultiContent, Name: m.Name})
+}
+
+// defaultInterruptPrompt returns the interrupt data if it is a string or a fmt.Stringer.
+// For a ChatModelAgent interrupted by a tool, it returns the extra infos of the interrupted tools.
+// Otherwise it returns the interrupt info encoded as JSON.
+func defaultInterruptPrompt(_ context.Context, info *adk.InterruptInfo) (string, error) {
+	if prompt, ok := promptOf(info.Data); ok {
+		return prompt, nil
+	}
+	if cm, ok := info.Data.(*adk.ChatModelAgentInterruptInfo); ok && cm.Info != nil {
+		var prompts []string
+		for _, extra := range cm.Info.RerunNodesExtra {
+			if prompt, ok := promptOf(extra); ok {
+				prompts = append(prompts, prompt)
+			}
+		}
+		if len(prompts) > 0 {
+			return strings.Join(prompts, "\n"), nil
+		}
+	}
+	text, err := sonic.MarshalString(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal interrupted info: %w", err)
+	}
+	return text, nil
+}
+
+func promptOf(v any) (string, bool) {
+	switch d := v.(type) {
+	case string:
+		return d, d != ""
+	case fmt.Stringer:
+		return d.String(), true
+	}
+	return "", false
+}
+
+// checkPointDeleter is implemented by the checkpoint stores able to delete the checkpoint of a completed task
+type checkPointDeleter interface {
+	Delete(ctx context.Context, checkPointID string) error
+}
+
+// inMemoryCheckPointStore keeps the checkpoints of interrupted runs in memory, they are lost when the process exits
+type inMemoryCheckPointStore struct {
+	mu          sync.Mutex
+	checkPoints map[string][]byte
+}
+
+func newInMemoryCheckPointStore() *inMemoryCheckPointStore {
+	return &inMemoryCheckPointStore{checkPoints: make(map[string][]byte)}
+}
+
+func (s *inMemoryCheckPointStore) Get(_ context.Context, checkPointID string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.checkPoints[checkPointID]
+	return cp, ok, nil
+}
+
+func (s *inMemoryCheckPointStore) Set(_ context.Context, checkPointID string, checkPoint []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkPoints[checkPointID] = checkPoint
+	return nil
+}
+
+func (s *inMemoryCheckPointStore) Delete(_ context.Context, checkPointID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkPoints, checkPointID)
+	return nil
+}
@@ -0,0 +1,123 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+// approvalAgent asks for an approval before paying
+type approvalAgent struct{}
+
+func (a *approvalAgent) Name(_ context.Context) string { return "payer" }
+
+func (a *approvalAgent) Description(_ context.Context) string { return "pays once approved" }
+
+func (a *approvalAgent) Run(_ context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	gen.Send(&adk.AgentEvent{Action: &adk.AgentAction{Interrupted: &adk.InterruptInfo{Data: "Approve the payment of $100?"}}})
+	gen.Close()
+	return iter
+}
+
+func (a *approvalAgent) Resume(ctx context.Context, _ *adk.ResumeInfo, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	answer := "payment rejected"
+	if input, ok := GetResumeInput(ctx); ok && input.Content == "yes" {
+		answer = "payment approved"
+	}
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage(answer, nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+type taskResult struct {
+	Result struct {
+		ID     string `json:"id"`
+		Status struct {
+			State   string `json:"state"`
+			Message struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"message"`
+		} `json:"status"`
+	} `json:"result"`
+}
+
+func sendOnTask(t *testing.T, h *server.Hertz, taskID, text string) *taskResult {
+	t.Helper()
+	message := map[string]any{
+		"role":      "user",
+		"messageId": "m-" + text,
+		"parts":     []map[string]any{{"kind": "text", "text": text}},
+	}
+	if taskID != "" {
+		message["taskId"] = taskID
+	}
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": "1", "method": "message/send",
+		"params": map[string]any{"message": message},
+	})
+	assert.NoError(t, err)
+	resp := ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	out := &taskResult{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	return out
+}
+
+func statusText(r *taskResult) string {
+	if len(r.Result.Status.Message.Parts) == 0 {
+		return ""
+	}
+	return r.Result.Status.Message.Parts[0].Text
+}
+
+func TestInterruptResume(t *testing.T) {
+	ctx := context.Background()
+	store := newInMemoryCheckPointStore()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &approvalAgent{}, WithCheckPointStore(store)))
+	h := newTestEngine(t, s)
+
+	out := sendOnTask(t, h, "", "pay Bob")
+	assert.Equal(t, "input-required", out.Result.Status.State)
+	assert.Equal(t, "Approve the payment of $100?", statusText(out))
+	taskID := out.Result.ID
+	_, ok, _ := store.Get(ctx, taskID)
+	assert.True(t, ok)
+
+	out = sendOnTask(t, h, taskID, "yes")
+	assert.Equal(t, "completed", out.Result.Status.State)
+	assert.Equal(t, "payment approved", statusText(out))
+	_, ok, _ = store.Get(ctx, taskID)
+	assert.False(t, ok)
+
+	// the completed task is no longer interrupted, a new message runs the agent again
+	out = sendOnTask(t, h, taskID, "pay Alice")
+	assert.Equal(t, "input-required", out.Result.Status.State)
+}
+
+func TestInterruptPrompt(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &approvalAgent{}, WithInterruptPrompt(func(_ context.Context, info *adk.InterruptInfo) (string, error) {
+		return "please answer: " + info.Data.(string), nil
+	})))
+	h := newTestEngine(t, s)
+
+	out := sendOnTask(t, h, "", "pay Bob")
+	assert.Equal(t, "please answer: Approve the payment of $100?", statusText(out))
+
+	prompt, err := defaultInterruptPrompt(ctx, &adk.InterruptInfo{Data: map[string]any{"amount": 100}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Data":{"amount":100}}`, prompt)
+}
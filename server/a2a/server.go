@@ -2,31 +2,58 @@ package a2a
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"strconv"
 	"sync"
+	"time"
 
 	einoA2A "github.com/cloudwego/eino-ext/a2a/extension/eino"
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino-ext/a2a/server"
 	"github.com/cloudwego/eino-ext/a2a/transport/jsonrpc"
 	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/hertz/pkg/app"
 	hertzServer "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/route"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Server represents an A2A server instance that can register and run a single agent.
 // This design moves away from the singleton pattern to provide better isolation and flexibility.
 type Server struct {
-	agent  adk.Agent          // The registered agent instance
-	opts   *agentOption       // Configuration options for the agent
-	mu     sync.RWMutex       // Mutex for thread-safe operations
-	server *hertzServer.Hertz // The underlying HTTP server
+	agent     adk.Agent           // The registered agent instance
+	opts      *agentOption        // Configuration options for the agent
+	mu        sync.RWMutex        // Mutex for thread-safe operations
+	server    *hertzServer.Hertz  // The underlying HTTP server
+	transport network.Transporter // The transport of the underlying HTTP server, used to get the bound address
 }
 
 // agentOption holds configuration options for the registered agent
 type agentOption struct {
-	AgentCardPath *string // Agent card path
-	HandlerPath   string  // Agent handler path
+	AgentCardPath   *string                 // Agent card path
+	HandlerPath     string                  // Agent handler path
+	SessionStore    SessionStore            // Conversation memory per A2A context, disabled if nil
+	CheckPointStore compose.CheckPointStore // Checkpoints of the interrupted runs, in memory by default
+	InterruptPrompt InterruptPromptFunc     // Text of the input-required status of interrupted runs
+	TaskStore       server.TaskStore        // Tasks of the agent, in memory by default
+
+	MaxFileSize      int64         // Max decoded size in bytes of a file part, unlimited if zero
+	AllowedMIMETypes []string      // MIME types of the file parts accepted, any if empty
+	ArtifactStore    ArtifactStore // Keeps the file contents out of the agent input, disabled if nil
+	InlineFileSize   int64         // Max size in bytes of the file contents passed inline when an artifact store is set
+
+	Skills []models.AgentSkill // Skills advertised in the agent card
 }
 
 // AgentOptionFn is a function type for configuring agent options using the functional options pattern
@@ -39,6 +66,36 @@ type runOption struct {
 	BasePath string // Server base path
 
 	Middlewares []app.HandlerFunc
+	Routes      []func(*route.RouterGroup) // Custom routes registered next to the A2A handlers
+
+	TracerProvider trace.TracerProvider          // Tracing is disabled if nil
+	Propagator     propagation.TextMapPropagator // Propagator used to extract the trace context from request headers
+
+	CertFile     string      // TLS certificate file, reloaded when modified
+	KeyFile      string      // TLS private key file, reloaded when modified
+	TLSConfig    *tls.Config // Base TLS config
+	ClientCAFile string      // CA bundle used to verify client certificates
+	HTTP2        bool        // Whether HTTP/2 is served, over TLS with ALPN or as h2c without TLS
+
+	RateLimit            float64       // Agent runs per second allowed per caller, unlimited if zero
+	RateBurst            int           // Token bucket size of each caller
+	CallerKeyFunc        CallerKeyFunc // Identifies the caller of a request for rate limiting
+	MaxConcurrentTasks   int           // Max number of concurrent agent runs, unlimited if zero
+	ThrottleQueueTimeout time.Duration // How long a throttled request waits before being rejected
+
+	MeterProvider metric.MeterProvider // Metrics are disabled if nil
+
+	Logger      *slog.Logger // Access logs and audit records are disabled if nil
+	AuditLog    io.Writer    // JSON lines audit sink, disabled if nil
+	LogRedactor RedactFunc   // Redacts the message contents of audit records, contents are not logged if nil
+
+	CORS *corsOption // Cross-origin requests from browsers are not allowed if nil
+
+	TaskTimeout   time.Duration            // Max duration of an agent run, unlimited if zero
+	SkillTimeouts map[string]time.Duration // Max duration of the agent runs of each skill
+
+	PlaygroundPath string // Path of the web playground, disabled if empty
+	ToolCallEvents bool   // Whether tool calls and tool results are sent to clients
 }
 
 // RunOptionFn is a function type for configuring run options using the functional options pattern
@@ -92,6 +149,89 @@ func WithHandlerPath(handlerPath string) AgentOptionFn {
 	}
 }
 
+// WithSessionStore keeps the conversation of each A2A context in store and feeds the prior turns to the agent,
+// so that a message sent with the contextId of an earlier task continues its conversation.
+// The user messages and the answers of the agent are kept, tool calls and tool results are not.
+// See NewInMemorySessionStore and NewFileSessionStore.
+func WithSessionStore(store SessionStore) AgentOptionFn {
+	return func(o *agentOption) {
+		o.SessionStore = store
+	}
+}
+
+// WithCheckPointStore sets where the checkpoints of interrupted agent runs are kept, in memory by default.
+// When the agent interrupts, the task becomes input-required, and the next message sent on the task
+// resumes the agent from its checkpoint, with the message available through GetResumeInput.
+// Use a persistent store to resume tasks across restarts or servers. Checkpoints are deleted once
+// the task completes if the store has a "Delete(ctx context.Context, checkPointID string) error" method.
+func WithCheckPointStore(store compose.CheckPointStore) AgentOptionFn {
+	return func(o *agentOption) {
+		o.CheckPointStore = store
+	}
+}
+
+// WithTaskStore sets where the tasks are kept, by default in memory with the tasks in a terminal state
+// deleted an hour after their last update, see NewInMemoryTaskStore.
+// Use a persistent store to serve the tasks across restarts or servers.
+func WithTaskStore(store server.TaskStore) AgentOptionFn {
+	return func(o *agentOption) {
+		o.TaskStore = store
+	}
+}
+
+// WithInterruptPrompt sets how the input-required status message is made from the info of an interrupt.
+// By default, the interrupt data is sent if it is a string or a fmt.Stringer, or the extra info of the
+// interrupted tools for a ChatModelAgent, and the interrupt info encoded as JSON otherwise.
+func WithInterruptPrompt(fn InterruptPromptFunc) AgentOptionFn {
+	return func(o *agentOption) {
+		o.InterruptPrompt = fn
+	}
+}
+
+// WithSkills advertises the skills of the agent in its agent card.
+// Clients name the skill they call with the MetadataKeySkillID metadata, see WithSkillTimeout.
+func WithSkills(skills ...models.AgentSkill) AgentOptionFn {
+	return func(o *agentOption) {
+		o.Skills = skills
+	}
+}
+
+// WithMaxFileSize rejects the messages with a file part larger than size bytes once decoded,
+// with HTTP 413 and the JSON-RPC error ErrCodeInvalidParams. Files sent by URI are not checked.
+func WithMaxFileSize(size int64) AgentOptionFn {
+	return func(o *agentOption) {
+		o.MaxFileSize = size
+	}
+}
+
+// WithAllowedMIMETypes rejects the messages with a file part of another MIME type, with HTTP 415 and the
+// JSON-RPC error ErrCodeContentTypeNotSupported. Types can end with a wildcard, e.g. "image/*".
+// File parts without MIME type are treated as "application/octet-stream".
+func WithAllowedMIMETypes(types ...string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.AllowedMIMETypes = types
+	}
+}
+
+// WithArtifactStore puts the contents of the file parts sent to the agent in store, so that the input messages
+// of the agent, and the messages kept in the task history, reference them by the URI returned by the store
+// instead of carrying them inline. A file failing to be stored rejects the message with ErrCodeInternal.
+// Image, audio and video files become the matching multimodal parts of the input messages in any case.
+// See NewDirArtifactStore and NewS3ArtifactStore, and WithInlineFileSize to keep small files inline.
+func WithArtifactStore(store ArtifactStore) AgentOptionFn {
+	return func(o *agentOption) {
+		o.ArtifactStore = store
+	}
+}
+
+// WithInlineFileSize passes the files up to size bytes inline even if an artifact store is set,
+// e.g. so that models see small images without fetching them
+func WithInlineFileSize(size int64) AgentOptionFn {
+	return func(o *agentOption) {
+		o.InlineFileSize = size
+	}
+}
+
 // WithMiddlewares sets the server middlewares
 func WithMiddlewares(middlewares ...app.HandlerFunc) RunOptionFn {
 	return func(o *runOption) {
@@ -99,6 +239,192 @@ func WithMiddlewares(middlewares ...app.HandlerFunc) RunOptionFn {
 	}
 }
 
+// WithRoutes registers custom routes, e.g. admin pages, webhooks or a static UI, next to the A2A handlers.
+// The group is rooted at the base path and does not carry the A2A middlewares, add your own with group.Use.
+// It can be given several times, the functions are called in order.
+func WithRoutes(fn func(group *route.RouterGroup)) RunOptionFn {
+	return func(o *runOption) {
+		o.Routes = append(o.Routes, fn)
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry tracing with the given tracer provider.
+// Each request gets a server span named after its JSON-RPC method, and each agent run gets
+// an "invoke_agent" span, so model spans recorded with the same provider join the request trace.
+func WithTracerProvider(tp trace.TracerProvider) RunOptionFn {
+	return func(o *runOption) {
+		o.TracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator used to extract the trace context from A2A request headers.
+// Default is the global propagator, see otel.GetTextMapPropagator.
+func WithPropagator(propagator propagation.TextMapPropagator) RunOptionFn {
+	return func(o *runOption) {
+		o.Propagator = propagator
+	}
+}
+
+// WithTLS serves HTTPS with the certificate and private key in PEM files.
+// The files are watched on each handshake, so a renewed certificate is served without restarting the server.
+func WithTLS(certFile, keyFile string) RunOptionFn {
+	return func(o *runOption) {
+		o.CertFile = certFile
+		o.KeyFile = keyFile
+	}
+}
+
+// WithTLSConfig serves HTTPS with the given TLS config.
+// Set GetCertificate on it to rotate certificates, or combine it with WithTLS to serve reloaded certificate files
+// with the other settings of the config. The config is cloned and not modified.
+func WithTLSConfig(cfg *tls.Config) RunOptionFn {
+	return func(o *runOption) {
+		o.TLSConfig = cfg
+	}
+}
+
+// WithClientCA verifies client certificates against the CA certificates in the PEM file.
+// Clients must present a valid certificate unless the ClientAuth of WithTLSConfig is set to a weaker policy,
+// e.g. tls.VerifyClientCertIfGiven. TLS must be enabled with WithTLS or WithTLSConfig.
+func WithClientCA(caFile string) RunOptionFn {
+	return func(o *runOption) {
+		o.ClientCAFile = caFile
+	}
+}
+
+// WithHTTP2 enables HTTP/2, negotiated with ALPN when TLS is enabled, or served as cleartext h2c otherwise.
+// HTTP/1.1 is still served for clients that do not support HTTP/2.
+func WithHTTP2(enable bool) RunOptionFn {
+	return func(o *runOption) {
+		o.HTTP2 = enable
+	}
+}
+
+// WithRateLimit limits each caller to limit agent runs per second with a token bucket of burst size,
+// burst defaults to the limit rounded up if not positive. Only message/send and message/stream consume tokens.
+// Callers are identified by their Authorization header, or by IP if absent, see WithCallerKeyFunc.
+// Throttled requests are rejected with HTTP 429, a Retry-After header and the JSON-RPC error ErrCodeRateLimited,
+// unless WithThrottleQueue lets them wait.
+func WithRateLimit(limit float64, burst int) RunOptionFn {
+	return func(o *runOption) {
+		o.RateLimit = limit
+		o.RateBurst = burst
+	}
+}
+
+// WithCallerKeyFunc sets how callers are identified for rate limiting,
+// e.g. by a user id set on the request context by an authentication middleware.
+func WithCallerKeyFunc(fn CallerKeyFunc) RunOptionFn {
+	return func(o *runOption) {
+		o.CallerKeyFunc = fn
+	}
+}
+
+// WithMaxConcurrentTasks limits the number of agent runs across all callers.
+// A run holds its slot until it ends, including runs continuing after a non-blocking message/send returns.
+// Requests exceeding the limit are rejected with HTTP 429, a Retry-After header and the JSON-RPC error ErrCodeTooManyTasks,
+// unless WithThrottleQueue lets them wait.
+func WithMaxConcurrentTasks(n int) RunOptionFn {
+	return func(o *runOption) {
+		o.MaxConcurrentTasks = n
+	}
+}
+
+// WithThrottleQueue queues throttled requests for up to timeout instead of rejecting them right away.
+// Rate limited requests wait for a token of their caller, others wait for a concurrent task slot.
+func WithThrottleQueue(timeout time.Duration) RunOptionFn {
+	return func(o *runOption) {
+		o.ThrottleQueueTimeout = timeout
+	}
+}
+
+// WithMeterProvider enables OpenTelemetry metrics with the given meter provider,
+// e.g. "a2a.server.throttled_requests" counting the requests rejected by WithRateLimit or WithMaxConcurrentTasks.
+func WithMeterProvider(mp metric.MeterProvider) RunOptionFn {
+	return func(o *runOption) {
+		o.MeterProvider = mp
+	}
+}
+
+// WithLogger writes a structured access log of each request and an audit record of each agent run to logger.
+// Access logs carry the request id, the caller, the JSON-RPC method, the task and context ids, the HTTP status,
+// the JSON-RPC error if any and the latency. Audit records carry the outcome and the latency of the agent run.
+// Callers are identified as for rate limiting, see WithCallerKeyFunc, from the request as received by the server.
+func WithLogger(logger *slog.Logger) RunOptionFn {
+	return func(o *runOption) {
+		o.Logger = logger
+	}
+}
+
+// WithAuditLog writes the audit record of each agent run to w as JSON lines, e.g. to an append-only file for compliance.
+// Writes to w are serialized.
+func WithAuditLog(w io.Writer) RunOptionFn {
+	return func(o *runOption) {
+		o.AuditLog = w
+	}
+}
+
+// WithLogRedactor adds the text of the input and the answers of each agent run to the audit records,
+// as returned by fn. Message contents are not logged without a redactor.
+func WithLogRedactor(fn RedactFunc) RunOptionFn {
+	return func(o *runOption) {
+		o.LogRedactor = fn
+	}
+}
+
+// WithCORS lets browser clients, e.g. a web playground, call the agent from other origins,
+// see WithCORSAllowOrigins for the allowed origins, any by default.
+// Preflight requests are answered before the middlewares of WithMiddlewares, and the CORS headers are set
+// before the handler runs, so that message/stream responses are readable cross-origin as they are streamed.
+// The agent card becomes public: it is readable from any origin and served without the middlewares
+// of WithMiddlewares, e.g. authentication, so that browsers can discover the agent.
+func WithCORS(opts ...CORSOptionFn) RunOptionFn {
+	return func(o *runOption) {
+		o.CORS = newCORSOption(opts)
+	}
+}
+
+// WithTaskTimeout bounds the duration of each agent run started by message/send or message/stream.
+// The deadline is set on the context of the run, so that model and tool calls see it. When it expires,
+// the task fails with a status message telling the timeout, even if the agent doesn't stop.
+func WithTaskTimeout(timeout time.Duration) RunOptionFn {
+	return func(o *runOption) {
+		o.TaskTimeout = timeout
+	}
+}
+
+// WithSkillTimeout bounds the duration of the agent runs of a skill, overriding WithTaskTimeout.
+// The skill of a run is named by the MetadataKeySkillID metadata of the message, or of the message/send params.
+// It can be given several times, once per skill.
+func WithSkillTimeout(skillID string, timeout time.Duration) RunOptionFn {
+	return func(o *runOption) {
+		if o.SkillTimeouts == nil {
+			o.SkillTimeouts = make(map[string]time.Duration)
+		}
+		o.SkillTimeouts[skillID] = timeout
+	}
+}
+
+// WithPlayground serves a web playground at path under the base path, e.g. "/playground", to chat with the agent
+// from a browser for demos and debugging. The page reads the agent card, sends messages, renders the streamed
+// status, message and artifact events, and shows the history of the tasks. Tool calls are shown if WithToolCallEvents is enabled.
+// The page itself is served without the middlewares of WithMiddlewares, it sends the Authorization header entered in the page.
+// The playground is disabled by default.
+func WithPlayground(path string) RunOptionFn {
+	return func(o *runOption) {
+		o.PlaygroundPath = path
+	}
+}
+
+// WithToolCallEvents sends a working status update for each tool call and tool result of the agent,
+// with a data part of type "tool_call" or "tool_result" holding the id, the name, and the arguments or content of the call.
+// They are not sent by default since tool calls are internal to the agent.
+func WithToolCallEvents(enable bool) RunOptionFn {
+	return func(o *runOption) {
+		o.ToolCallEvents = enable
+	}
+}
+
 // New creates a new Server instance with default configuration
 func New() *Server {
 	return &Server{}
@@ -124,6 +450,12 @@ func (s *Server) RegisterAgent(ctx context.Context, agent adk.Agent, opts ...Age
 	for _, opt := range opts {
 		opt(agentOpts)
 	}
+	if agentOpts.CheckPointStore == nil {
+		agentOpts.CheckPointStore = newInMemoryCheckPointStore()
+	}
+	if agentOpts.InterruptPrompt == nil {
+		agentOpts.InterruptPrompt = defaultInterruptPrompt
+	}
 
 	s.agent = agent
 	s.opts = agentOpts
@@ -131,6 +463,45 @@ func (s *Server) RegisterAgent(ctx context.Context, agent adk.Agent, opts ...Age
 	return nil
 }
 
+// Mount registers the A2A handlers of the agent on a caller-provided router, e.g. a *server.Hertz or one of its route groups,
+// so that the agent is served next to other routes of an existing server.
+// Only the options configuring handlers apply, e.g. WithMiddlewares, WithRoutes, WithTracerProvider or WithLogger.
+// The options configuring the listener, e.g. WithPort or WithTLS, are ignored since the caller owns the server.
+func (s *Server) Mount(ctx context.Context, router route.IRouter, opts ...RunOptionFn) error {
+	s.mu.RLock()
+	agent := s.agent
+	s.mu.RUnlock()
+	if agent == nil {
+		return fmt.Errorf("no agent registered")
+	}
+
+	runOpts := &runOption{}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+	return s.mount(ctx, router, agent, runOpts)
+}
+
+// Engine returns the Hertz engine started by Run, nil if Run has not been called
+func (s *Server) Engine() *hertzServer.Hertz {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.server
+}
+
+// Addr returns the address the server started by Run is listening on, nil until it is listening.
+// It is useful to get the actual port when the server is run with WithPort(0).
+func (s *Server) Addr() net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if l, ok := s.transport.(interface{ Listener() net.Listener }); ok {
+		if ln := l.Listener(); ln != nil {
+			return ln.Addr()
+		}
+	}
+	return nil
+}
+
 // Run starts the server and blocks until the context is cancelled or an error occurs.
 // This method combines server startup and graceful shutdown in a single call.
 func (s *Server) Run(ctx context.Context, opts ...RunOptionFn) error {
@@ -153,20 +524,151 @@ func (s *Server) Run(ctx context.Context, opts ...RunOptionFn) error {
 		opt(runOpts)
 	}
 
+	transportOpts, err := transportOptions(runOpts)
+	if err != nil {
+		return err
+	}
+
 	// Create Hertz HTTP server instance
-	h := hertzServer.Default(
+	h := hertzServer.Default(append([]config.Option{
 		hertzServer.WithHostPorts(net.JoinHostPort(runOpts.Host, strconv.Itoa(runOpts.Port))),
 		hertzServer.WithBasePath(runOpts.BasePath),
-	)
+	}, append(transportOpts, s.captureTransport())...)...)
+	enableHTTP2(h, runOpts)
+	s.mu.Lock()
 	s.server = h
+	s.mu.Unlock()
+
+	if err := s.mount(ctx, h, agent, runOpts); err != nil {
+		return err
+	}
+
+	return h.Run()
+}
+
+// captureTransport returns the option keeping the transport created for the engine, it must be the last option
+func (s *Server) captureTransport() config.Option {
+	return config.Option{F: func(o *config.Options) {
+		newTransporter := o.TransporterNewer
+		if newTransporter == nil {
+			newTransporter = defaultTransporter
+		}
+		o.TransporterNewer = func(o *config.Options) network.Transporter {
+			t := newTransporter(o)
+			s.mu.Lock()
+			s.transport = t
+			s.mu.Unlock()
+			return t
+		}
+	}}
+}
+
+// mount registers the custom routes, the middlewares and the A2A handlers of agent on router.
+// The middlewares are scoped to a group of the A2A handlers, so that other routes of router are left untouched.
+func (s *Server) mount(ctx context.Context, router route.IRouter, agent adk.Agent, runOpts *runOption) error {
+	for _, fn := range runOpts.Routes {
+		fn(router.Group(""))
+	}
+	if runOpts.PlaygroundPath != "" {
+		if err := s.mountPlayground(router.Group(""), runOpts.PlaygroundPath); err != nil {
+			return err
+		}
+	}
+
+	group := router.Group("")
+	card := group // Routes of the agent card, public when CORS is enabled
+	if runOpts.CORS != nil {
+		group.Use(runOpts.CORS.middleware)
+		card = router.Group("", agentCardCORS.middleware)
+	}
+	tasks := newTaskRegistry(runOpts, s.opts.TaskStore)
+	convertor := &eventConvertor{
+		agentName:       agent.Name(ctx),
+		sessions:        s.opts.SessionStore,
+		checkPoints:     s.opts.CheckPointStore,
+		interruptPrompt: s.opts.InterruptPrompt,
+		logger:          newRequestLogger(runOpts),
+		toolCallEvents:  runOpts.ToolCallEvents,
+		tasks:           tasks,
+	}
+
+	var common []app.HandlerFunc // Middlewares of both the handler and the agent card
+	if runOpts.TracerProvider != nil {
+		propagator := runOpts.Propagator
+		if propagator == nil {
+			propagator = otel.GetTextMapPropagator()
+		}
+		tracer := runOpts.TracerProvider.Tracer(tracerName)
+		common = append(common, tracingMiddleware(tracer, propagator))
+		convertor.tracer = tracer
+	}
+	if convertor.logger != nil {
+		// log before the middlewares, so that the requests they reject are logged too
+		common = append(common, convertor.logger.middleware)
+	}
+	if len(common) > 0 {
+		group.Use(common...)
+		if card != group {
+			card.Use(common...)
+		}
+	}
 
 	if len(runOpts.Middlewares) > 0 {
-		h.Use(runOpts.Middlewares...)
+		group.Use(runOpts.Middlewares...)
+	}
+
+	if limits := newFileLimits(s.opts); limits != nil {
+		group.Use(limits.middleware)
+	}
+
+	var meter metric.Meter = noop.Meter{}
+	if runOpts.MeterProvider != nil {
+		meter = runOpts.MeterProvider.Meter(tracerName)
+	}
+	throttle, err := newThrottler(runOpts, meter)
+	if err != nil {
+		return fmt.Errorf("failed to create throttler: %w", err)
+	}
+	var locker server.TaskLocker
+	if throttle != nil {
+		// throttle after the middlewares, so that callers are authenticated first
+		group.Use(throttle.middleware)
+		if throttle.slots != nil {
+			locker = &slotTaskLocker{}
+		}
+	}
+
+	conv := &messageConvertor{store: s.opts.ArtifactStore, inlineSize: s.opts.InlineFileSize}
+	if conv.store != nil {
+		// store the files after the throttling, so that rejected requests store nothing
+		group.Use(conv.middleware)
+	}
+	group.Use(tasks.cancelMiddleware, tasks.middleware)
+	handlerConfig := &einoA2A.ServerConfig{
+		Skills:                  s.opts.Skills,
+		EventConvertor:          convertor.convert,
+		TaskStore:               tasks,
+		TaskLocker:              locker,
+		CheckPointStore:         s.opts.CheckPointStore,
+		AgentRunOptionConvertor: runOptions(conv),
+		ResumeConvertor:         resumeOptions(conv),
+		HistoryMessageConvertor: sessionHistory(s.opts.SessionStore, conv),
+	}
+
+	var routes route.IRoutes = group
+	if card != group {
+		routes = &agentCardRoutes{IRoutes: group, card: card}
+		cardPath := ".well-known/agent-card.json"
+		if s.opts.AgentCardPath != nil {
+			cardPath = *s.opts.AgentCardPath
+		}
+		group.OPTIONS(s.opts.HandlerPath, noContent)
+		card.OPTIONS(cardPath, noContent)
 	}
 
 	// Create JSON-RPC registrar for handling agent communication
 	r, err := jsonrpc.NewRegistrar(ctx, &jsonrpc.ServerConfig{
-		Router:        h,
+		Router:        routes,
 		AgentCardPath: s.opts.AgentCardPath, // Default agent card path ".well-known/agent-card.json"
 		HandlerPath:   s.opts.HandlerPath,   // Default handler path
 	})
@@ -175,12 +677,11 @@ func (s *Server) Run(ctx context.Context, opts ...RunOptionFn) error {
 	}
 
 	// Register agent handlers with the A2A framework
-	err = einoA2A.RegisterServerHandlers(ctx, agent, &einoA2A.ServerConfig{
-		Registrar: r,
-	})
+	handlerConfig.Registrar = r
+	err = einoA2A.RegisterServerHandlers(ctx, agent, handlerConfig)
 	if err != nil {
 		return fmt.Errorf("failed to register server handlers: %w", err)
 	}
 
-	return h.Run()
+	return nil
 }
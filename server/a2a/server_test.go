@@ -0,0 +1,156 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoAgent replies with the content of the last input message
+type echoAgent struct{}
+
+func (e *echoAgent) Name(_ context.Context) string { return "echo" }
+
+func (e *echoAgent) Description(_ context.Context) string { return "echoes the input" }
+
+func (e *echoAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	content := ""
+	if len(input.Messages) > 0 {
+		content = input.Messages[len(input.Messages)-1].Content
+	}
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage(content, nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func newTestEngine(t *testing.T, s *Server, opts ...RunOptionFn) *server.Hertz {
+	t.Helper()
+	ctx := context.Background()
+	if s.agent == nil {
+		assert.NoError(t, s.RegisterAgent(ctx, &echoAgent{}))
+	}
+	runOpts := &runOption{}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+	h := server.New()
+	assert.NoError(t, s.mount(ctx, h, s.agent, runOpts))
+	return h
+}
+
+func sendMessageRequest(t *testing.T, text string) *ut.Body {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      "1",
+		"method":  "message/send",
+		"params": map[string]any{
+			"message": map[string]any{
+				"role":      "user",
+				"messageId": "m1",
+				"parts":     []map[string]any{{"kind": "text", "text": text}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	return &ut.Body{Body: bytes.NewReader(body), Len: len(body)}
+}
+
+func TestServerMessageSend(t *testing.T) {
+	h := newTestEngine(t, New())
+
+	w := ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hello"),
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	resp := w.Result()
+	assert.Equal(t, 200, resp.StatusCode())
+
+	var out struct {
+		Result struct {
+			Kind   string `json:"kind"`
+			Status struct {
+				State   string `json:"state"`
+				Message struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"message"`
+			} `json:"status"`
+		} `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body(), &out))
+	assert.Equal(t, "task", out.Result.Kind)
+	assert.Equal(t, "completed", out.Result.Status.State)
+	assert.Equal(t, "hello", out.Result.Status.Message.Parts[0].Text)
+
+	w = ut.PerformRequest(h.Engine, "GET", "/.well-known/agent-card.json", nil)
+	assert.Contains(t, string(w.Result().Body()), `"name":"echo"`)
+}
+
+// runTestServer runs s on a random local port and returns the bound address
+func runTestServer(t *testing.T, s *Server, opts ...RunOptionFn) string {
+	t.Helper()
+	ctx := context.Background()
+	go func() {
+		_ = s.Run(ctx, append([]RunOptionFn{WithHost("127.0.0.1"), WithPort(0)}, opts...)...)
+	}()
+	assert.Eventually(t, func() bool { return s.Addr() != nil }, 5*time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		// don't wait for idle keep-alive connections
+		shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_ = s.Engine().Shutdown(shutdownCtx)
+	})
+	return s.Addr().String()
+}
+
+func TestServerRunAddr(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &echoAgent{}))
+	assert.Nil(t, s.Engine())
+	assert.Nil(t, s.Addr())
+
+	addr := runTestServer(t, s, WithRoutes(func(g *route.RouterGroup) {
+		g.GET("/healthz", func(_ context.Context, c *app.RequestContext) { c.String(200, "ok") })
+	}))
+	assert.NotNil(t, s.Engine())
+	assert.NotContains(t, addr, ":0")
+
+	resp, err := http.Get("http://" + addr + "/healthz")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, "ok", string(body))
+	}
+}
+
+func TestServerMount(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	assert.Error(t, s.Mount(ctx, server.New()))
+	assert.NoError(t, s.RegisterAgent(ctx, &echoAgent{}))
+
+	h := server.New()
+	h.GET("/admin", func(_ context.Context, c *app.RequestContext) { c.String(200, "admin") })
+	denyAll := func(_ context.Context, c *app.RequestContext) { c.AbortWithStatus(401) }
+	assert.NoError(t, s.Mount(ctx, h.Group("/agent"), WithMiddlewares(denyAll), WithRoutes(func(g *route.RouterGroup) {
+		g.GET("/ping", func(_ context.Context, c *app.RequestContext) { c.String(200, "pong") })
+	})))
+
+	// the middlewares only guard the A2A handlers
+	assert.Equal(t, 401, ut.PerformRequest(h.Engine, "GET", "/agent/.well-known/agent-card.json", nil).Result().StatusCode())
+	assert.Equal(t, "pong", string(ut.PerformRequest(h.Engine, "GET", "/agent/ping", nil).Result().Body()))
+	assert.Equal(t, "admin", string(ut.PerformRequest(h.Engine, "GET", "/admin", nil).Result().Body()))
+}
@@ -0,0 +1,201 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+// SessionStore keeps the conversation of each A2A context, so that the agent sees the prior turns
+// of a context when a client sends a new message with the same contextId.
+// Implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Load returns the messages of the context in order, empty if the context is unknown or expired
+	Load(ctx context.Context, contextID string) ([]*schema.Message, error)
+	// Append adds the messages of a turn to the context, truncating the oldest turns if the session is over its limits
+	Append(ctx context.Context, contextID string, messages []*schema.Message) error
+}
+
+// sessionOption holds the limits of the sessions of a store
+type sessionOption struct {
+	MaxTurns int           // Max number of turns kept, a turn starts at a user message, unlimited if zero
+	MaxSize  int           // Max JSON size in bytes of the messages kept, unlimited if zero
+	TTL      time.Duration // How long a session is kept after its last turn, forever if zero
+}
+
+// SessionOptionFn is a function type for configuring session stores using the functional options pattern
+type SessionOptionFn func(*sessionOption)
+
+// WithSessionMaxTurns keeps the last n turns of each session, a turn starts at a user message
+func WithSessionMaxTurns(n int) SessionOptionFn {
+	return func(o *sessionOption) {
+		o.MaxTurns = n
+	}
+}
+
+// WithSessionMaxSize keeps the last turns of each session fitting in size bytes once encoded as JSON.
+// The last turn is always kept, even if larger.
+func WithSessionMaxSize(size int) SessionOptionFn {
+	return func(o *sessionOption) {
+		o.MaxSize = size
+	}
+}
+
+// WithSessionTTL expires the sessions not updated for ttl
+func WithSessionTTL(ttl time.Duration) SessionOptionFn {
+	return func(o *sessionOption) {
+		o.TTL = ttl
+	}
+}
+
+func newSessionOption(opts []SessionOptionFn) *sessionOption {
+	o := &sessionOption{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *sessionOption) expired(updatedAt, now time.Time) bool {
+	return o.TTL > 0 && now.Sub(updatedAt) > o.TTL
+}
+
+// truncate drops the oldest turns of messages beyond the limits
+func (o *sessionOption) truncate(messages []*schema.Message) []*schema.Message {
+	var turnStarts []int
+	for i, m := range messages {
+		if m != nil && m.Role == schema.User && (i == 0 || messages[i-1] == nil || messages[i-1].Role != schema.User) {
+			turnStarts = append(turnStarts, i)
+		}
+	}
+	if len(turnStarts) == 0 {
+		return messages
+	}
+
+	first := 0 // Index in turnStarts of the first kept turn
+	if o.MaxTurns > 0 && len(turnStarts) > o.MaxTurns {
+		first = len(turnStarts) - o.MaxTurns
+	}
+	if o.MaxSize > 0 {
+		size := 0
+		for i := len(messages) - 1; i >= turnStarts[first]; i-- {
+			b, _ := json.Marshal(messages[i])
+			size += len(b)
+			if size <= o.MaxSize {
+				continue
+			}
+			// keep the turns after the one crossing the limit, and at least the last turn
+			for first < len(turnStarts)-1 && turnStarts[first] <= i {
+				first++
+			}
+			break
+		}
+	}
+	return messages[turnStarts[first]:]
+}
+
+// NewInMemorySessionStore returns a SessionStore keeping the sessions in memory, they are lost when the process exits
+func NewInMemorySessionStore(opts ...SessionOptionFn) SessionStore {
+	return &inMemorySessionStore{
+		opts:      newSessionOption(opts),
+		sessions:  make(map[string]*inMemorySession),
+		lastSweep: time.Now(),
+	}
+}
+
+type inMemorySessionStore struct {
+	opts      *sessionOption
+	mu        sync.Mutex
+	sessions  map[string]*inMemorySession
+	lastSweep time.Time
+}
+
+type inMemorySession struct {
+	messages  []*schema.Message
+	updatedAt time.Time
+}
+
+func (s *inMemorySessionStore) Load(_ context.Context, contextID string) ([]*schema.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[contextID]
+	if !ok {
+		return nil, nil
+	}
+	if s.opts.expired(session.updatedAt, time.Now()) {
+		delete(s.sessions, contextID)
+		return nil, nil
+	}
+	return append([]*schema.Message(nil), session.messages...), nil
+}
+
+func (s *inMemorySessionStore) Append(_ context.Context, contextID string, messages []*schema.Message) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	session, ok := s.sessions[contextID]
+	if !ok || s.opts.expired(session.updatedAt, now) {
+		session = &inMemorySession{}
+		s.sessions[contextID] = session
+	}
+	session.messages = s.opts.truncate(append(session.messages, messages...))
+	session.updatedAt = now
+	return nil
+}
+
+// sweep drops the expired sessions, at most once per TTL
+func (s *inMemorySessionStore) sweep(now time.Time) {
+	if s.opts.TTL <= 0 || now.Sub(s.lastSweep) < s.opts.TTL {
+		return
+	}
+	s.lastSweep = now
+	for id, session := range s.sessions {
+		if s.opts.expired(session.updatedAt, now) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// sessionHistory returns the agent input of a new run: the session of the context followed by the new message.
+// The earlier messages of the task are already in the session.
+// Without a session store, the agent input is the messages of the task.
+// The new message is the input of the run, already converted by runOptions.
+func sessionHistory(store SessionStore, conv *messageConvertor) func(ctx context.Context, messages []*models.Message) ([]adk.Message, error) {
+	return func(ctx context.Context, messages []*models.Message) ([]adk.Message, error) {
+		run := taskRunFrom(ctx)
+		if run == nil || run.input == nil || len(messages) == 0 {
+			return conv.toSchemaMessages(ctx, messages)
+		}
+		var history []adk.Message
+		var err error
+		if store != nil && run.contextID != "" {
+			if history, err = store.Load(ctx, run.contextID); err != nil {
+				return nil, fmt.Errorf("failed to load session[%s]: %w", run.contextID, err)
+			}
+		} else if history, err = conv.toSchemaMessages(ctx, messages[:len(messages)-1]); err != nil {
+			return nil, err
+		}
+		return append(history, run.input), nil
+	}
+}
+
+// saveSessionTurn appends the input and the answers of the run to the session of its context
+func saveSessionTurn(ctx context.Context, store SessionStore) error {
+	run := taskRunFrom(ctx)
+	if store == nil || run == nil || run.contextID == "" || run.input == nil {
+		return nil
+	}
+	if err := store.Append(ctx, run.contextID, append([]*schema.Message{run.input}, run.output...)); err != nil {
+		return fmt.Errorf("failed to save session[%s]: %w", run.contextID, err)
+	}
+	return nil
+}
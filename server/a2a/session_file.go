@@ -0,0 +1,142 @@
+package a2a
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// fileSession is the content of a session file
+type fileSession struct {
+	ContextID string            `json:"context_id"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Messages  []*schema.Message `json:"messages"`
+}
+
+// NewFileSessionStore returns a SessionStore keeping each session in a JSON file of dir, so that sessions
+// survive restarts. The directory is created if missing. Files are named after a hash of the contextId,
+// so any contextId sent by clients is safe to use.
+// Sessions are not shared between processes writing to the same directory at the same time.
+func NewFileSessionStore(dir string, opts ...SessionOptionFn) (SessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session dir: %w", err)
+	}
+	return &fileSessionStore{
+		dir:       dir,
+		opts:      newSessionOption(opts),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+type fileSessionStore struct {
+	dir       string
+	opts      *sessionOption
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func (s *fileSessionStore) path(contextID string) string {
+	sum := sha256.Sum256([]byte(contextID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *fileSessionStore) Load(_ context.Context, contextID string) ([]*schema.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.read(contextID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return session.Messages, nil
+}
+
+func (s *fileSessionStore) Append(_ context.Context, contextID string, messages []*schema.Message) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	session, err := s.read(contextID, now)
+	if err != nil {
+		return err
+	}
+	session.Messages = s.opts.truncate(append(session.Messages, messages...))
+	session.UpdatedAt = now
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	// write then rename, so that a crash never leaves a partial session
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path(contextID)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
+
+// read returns the session of the context, a new one if it doesn't exist or has expired
+func (s *fileSessionStore) read(contextID string, now time.Time) (*fileSession, error) {
+	data, err := os.ReadFile(s.path(contextID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &fileSession{ContextID: contextID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	session := &fileSession{}
+	if err = json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session file: %w", err)
+	}
+	if s.opts.expired(session.UpdatedAt, now) {
+		_ = os.Remove(s.path(contextID))
+		return &fileSession{ContextID: contextID}, nil
+	}
+	return session, nil
+}
+
+// sweep removes the expired session files, at most once per TTL.
+// The modification time of a file is the last update of its session.
+func (s *fileSessionStore) sweep(now time.Time) {
+	if s.opts.TTL <= 0 || now.Sub(s.lastSweep) < s.opts.TTL {
+		return
+	}
+	s.lastSweep = now
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		info, err := e.Info()
+		if err == nil && s.opts.expired(info.ModTime(), now) {
+			_ = os.Remove(filepath.Join(s.dir, e.Name()))
+		}
+	}
+}
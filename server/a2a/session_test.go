@@ -0,0 +1,153 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+// historyAgent replies with the contents of the user messages of its input
+type historyAgent struct{}
+
+func (h *historyAgent) Name(_ context.Context) string { return "history" }
+
+func (h *historyAgent) Description(_ context.Context) string { return "repeats the conversation" }
+
+func (h *historyAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	contents := make([]string, 0, len(input.Messages))
+	for _, m := range input.Messages {
+		if m.Role == schema.User {
+			contents = append(contents, m.Content)
+		}
+	}
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage(strings.Join(contents, ","), nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func TestServerSession(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &historyAgent{}, WithSessionStore(NewInMemorySessionStore(WithSessionMaxTurns(2)))))
+	h := newTestEngine(t, s)
+
+	send := func(contextID, text string) string {
+		message := map[string]any{
+			"role":      "user",
+			"messageId": "m-" + text,
+			"parts":     []map[string]any{{"kind": "text", "text": text}},
+		}
+		if contextID != "" {
+			message["contextId"] = contextID
+		}
+		body, err := json.Marshal(map[string]any{
+			"jsonrpc": "2.0", "id": "1", "method": "message/send",
+			"params": map[string]any{"message": message},
+		})
+		assert.NoError(t, err)
+		resp := ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+		var out struct {
+			Result struct {
+				ContextID string `json:"contextId"`
+				Status    struct {
+					Message struct {
+						Parts []struct {
+							Text string `json:"text"`
+						} `json:"parts"`
+					} `json:"message"`
+				} `json:"status"`
+			} `json:"result"`
+		}
+		assert.NoError(t, json.Unmarshal(resp.Body(), &out))
+		if !assert.Len(t, out.Result.Status.Message.Parts, 1) {
+			return ""
+		}
+		if contextID == "" {
+			return out.Result.ContextID + "|" + out.Result.Status.Message.Parts[0].Text
+		}
+		return out.Result.Status.Message.Parts[0].Text
+	}
+
+	first := send("", "a")
+	contextID, reply, _ := strings.Cut(first, "|")
+	assert.Equal(t, "a", reply)
+	assert.Equal(t, "a,b", send(contextID, "b"))
+	assert.Equal(t, "a,b,c", send(contextID, "c"))
+	// only the last 2 turns are kept
+	assert.Equal(t, "b,c,d", send(contextID, "d"))
+	assert.Equal(t, "e", send("other", "e"))
+}
+
+func TestSessionTruncate(t *testing.T) {
+	turn := func(q, a string) []*schema.Message {
+		return []*schema.Message{schema.UserMessage(q), schema.AssistantMessage(a, nil)}
+	}
+	var messages []*schema.Message
+	for _, s := range []string{"1", "2", "3"} {
+		messages = append(messages, turn("q"+s, "a"+s)...)
+	}
+
+	assert.Len(t, newSessionOption(nil).truncate(messages), 6)
+	kept := newSessionOption([]SessionOptionFn{WithSessionMaxTurns(2)}).truncate(messages)
+	assert.Equal(t, "q2", kept[0].Content)
+	assert.Len(t, kept, 4)
+
+	b, _ := json.Marshal(messages[5])
+	size := 3 * len(b) // fits the last turn and a half
+	kept = newSessionOption([]SessionOptionFn{WithSessionMaxSize(size)}).truncate(messages)
+	assert.Equal(t, "q3", kept[0].Content)
+	kept = newSessionOption([]SessionOptionFn{WithSessionMaxSize(1)}).truncate(messages)
+	assert.Equal(t, "q3", kept[0].Content)
+}
+
+func TestSessionStores(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fileStore, err := NewFileSessionStore(dir, WithSessionTTL(50*time.Millisecond))
+	assert.NoError(t, err)
+
+	for name, store := range map[string]SessionStore{
+		"memory": NewInMemorySessionStore(WithSessionTTL(50 * time.Millisecond)),
+		"file":   fileStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			messages, err := store.Load(ctx, "c/1")
+			assert.NoError(t, err)
+			assert.Empty(t, messages)
+
+			assert.NoError(t, store.Append(ctx, "c/1", []*schema.Message{schema.UserMessage("hi"), schema.AssistantMessage("hello", nil)}))
+			assert.NoError(t, store.Append(ctx, "c/1", []*schema.Message{schema.UserMessage("bye")}))
+			messages, err = store.Load(ctx, "c/1")
+			assert.NoError(t, err)
+			if assert.Len(t, messages, 3) {
+				assert.Equal(t, schema.Assistant, messages[1].Role)
+				assert.Equal(t, "bye", messages[2].Content)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			messages, err = store.Load(ctx, "c/1")
+			assert.NoError(t, err)
+			assert.Empty(t, messages)
+		})
+	}
+
+	// sessions survive a restart
+	fileStore, err = NewFileSessionStore(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, fileStore.Append(ctx, "c/2", []*schema.Message{schema.UserMessage("hi")}))
+	reopened, err := NewFileSessionStore(dir)
+	assert.NoError(t, err)
+	messages, err := reopened.Load(ctx, "c/2")
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
@@ -0,0 +1,279 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino-ext/a2a/server"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ErrCodeTaskNotCancelable is the JSON-RPC error of tasks/cancel on a task in a terminal state, as defined by A2A
+const ErrCodeTaskNotCancelable int64 = -32002
+
+// MetadataKeySkillID is the metadata key of the message, or of the message/send params, naming the skill
+// the client calls, see WithSkillTimeout
+const MetadataKeySkillID = "skillId"
+
+// defaultTaskTTL is how long the default task store keeps the tasks in a terminal state
+const defaultTaskTTL = time.Hour
+
+// errTaskCanceled is the cause of the context of a run canceled by tasks/cancel
+var errTaskCanceled = errors.New("task canceled")
+
+// taskRegistry tracks the agent runs in progress, so that tasks/cancel cancels them, and bounds their duration.
+// It wraps the task store of the server, so that canceled tasks not running, e.g. input-required, are saved as canceled.
+type taskRegistry struct {
+	timeout       time.Duration            // Max duration of the runs, unlimited if zero
+	skillTimeouts map[string]time.Duration // Max duration of the runs of each skill, overriding timeout
+
+	store     server.TaskStore
+	mu        sync.Mutex
+	running   map[string]*taskRun // Runs in progress by task id
+	canceling map[string]bool     // Tasks being canceled by tasks/cancel
+}
+
+func newTaskRegistry(runOpts *runOption, store server.TaskStore) *taskRegistry {
+	if store == nil {
+		store = NewInMemoryTaskStore(defaultTaskTTL)
+	}
+	return &taskRegistry{
+		timeout:       runOpts.TaskTimeout,
+		skillTimeouts: runOpts.SkillTimeouts,
+		store:         store,
+		running:       make(map[string]*taskRun),
+		canceling:     make(map[string]bool),
+	}
+}
+
+// taskParams is the part of the message/send and message/stream params read by taskRegistry
+type taskParams struct {
+	Message struct {
+		Metadata map[string]any `json:"metadata"`
+	} `json:"message"`
+	Configuration *struct {
+		Blocking *bool `json:"blocking"`
+	} `json:"configuration"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// middleware puts a taskRun in the request context, unless the logging middleware already did.
+// The runs started by message/send and message/stream get a cancelable context bounded by their timeout,
+// which is the context of the agent run and of its model and tool calls.
+func (r *taskRegistry) middleware(ctx context.Context, c *app.RequestContext) {
+	run := taskRunFrom(ctx)
+	if run == nil {
+		run = &taskRun{}
+		ctx = context.WithValue(ctx, taskRunKey{}, run)
+	}
+	req, ok := parseRPCRequest(c)
+	if !ok || !isTaskMethod(req.Method) {
+		c.Next(ctx)
+		return
+	}
+	params := &taskParams{}
+	_ = json.Unmarshal(req.Params, params)
+
+	// the run of a non-blocking message/send goes on after the request returns,
+	// its context is released once the run ends
+	run.timeout = r.timeoutOf(params)
+	stop := func() {}
+	if run.timeout > 0 {
+		ctx, stop = context.WithTimeout(ctx, run.timeout)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	run.cancel = func(cause error) {
+		cancel(cause)
+		stop()
+	}
+	c.Next(ctx)
+	if req.Method != "message/send" || params.Configuration == nil || params.Configuration.Blocking == nil || *params.Configuration.Blocking {
+		// also ends the runs that failed to start
+		r.end(run)
+	}
+}
+
+// timeoutOf returns the timeout of the skill named in the metadata of the request, or the server-wide timeout
+func (r *taskRegistry) timeoutOf(params *taskParams) time.Duration {
+	skill, _ := params.Message.Metadata[MetadataKeySkillID].(string)
+	if skill == "" {
+		skill, _ = params.Metadata[MetadataKeySkillID].(string)
+	}
+	if timeout, ok := r.skillTimeouts[skill]; ok {
+		return timeout
+	}
+	return r.timeout
+}
+
+// start registers the run of a task, once the agent runs
+func (r *taskRegistry) start(run *taskRun) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[run.taskID] = run
+}
+
+// end unregisters the run of a task and releases its context
+func (r *taskRegistry) end(run *taskRun) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.running[run.taskID] == run {
+		delete(r.running, run.taskID)
+	}
+	r.mu.Unlock()
+	if run.cancel != nil {
+		run.cancel(nil)
+	}
+}
+
+// cancelMiddleware handles tasks/cancel: it cancels the run of the task if any, then lets the handler
+// save the task, as canceled by Save. Tasks in a terminal state are rejected with ErrCodeTaskNotCancelable.
+func (r *taskRegistry) cancelMiddleware(ctx context.Context, c *app.RequestContext) {
+	req, ok := parseRPCRequest(c)
+	if !ok || req.Method != "tasks/cancel" {
+		c.Next(ctx)
+		return
+	}
+	params := struct {
+		ID string `json:"id"`
+	}{}
+	_ = json.Unmarshal(req.Params, &params)
+	// a task run by a non-blocking message/send is only stored once its run ends
+	r.mu.Lock()
+	run := r.running[params.ID]
+	r.mu.Unlock()
+	if run == nil {
+		t, ok, _ := r.Get(ctx, params.ID)
+		if !ok {
+			// left to the handler to answer
+			c.Next(ctx)
+			return
+		}
+		if isTerminal(t.Status.State) {
+			abortWithRPCError(c, consts.StatusConflict, req, &rpcError{
+				Code:    ErrCodeTaskNotCancelable,
+				Message: fmt.Sprintf("task[%s] is %s and can't be canceled", params.ID, t.Status.State),
+			})
+			return
+		}
+	}
+
+	r.mu.Lock()
+	r.canceling[params.ID] = true
+	run = r.running[params.ID]
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.canceling, params.ID)
+		r.mu.Unlock()
+	}()
+	if run != nil && run.cancel != nil {
+		run.cancel(errTaskCanceled)
+	}
+	// the handler waits for the end of the run holding the task
+	c.Next(ctx)
+}
+
+func (r *taskRegistry) Get(ctx context.Context, id string) (*models.Task, bool, error) {
+	return r.store.Get(ctx, id)
+}
+
+// Save stores the task, as canceled if it is being canceled by tasks/cancel and not in a terminal state yet
+func (r *taskRegistry) Save(ctx context.Context, t *models.Task) error {
+	r.mu.Lock()
+	canceling := r.canceling[t.ID]
+	r.mu.Unlock()
+	if canceling && !isTerminal(t.Status.State) {
+		t.Status = canceledStatus("task canceled")
+		if t.Metadata != nil {
+			// a canceled task is never resumed
+			t.Metadata[metadataKeyOfInterrupted] = false
+		}
+	}
+	return r.store.Save(ctx, t)
+}
+
+// inMemoryTaskStore keeps the tasks in memory, and deletes the tasks in a terminal state once expired
+type inMemoryTaskStore struct {
+	ttl time.Duration // How long the tasks in a terminal state are kept, forever if zero
+
+	mu        sync.Mutex
+	tasks     map[string]*storedTask
+	nextSweep time.Time // When the expired tasks are deleted next
+}
+
+type storedTask struct {
+	task      *models.Task
+	expiresAt time.Time // Zero until the task is in a terminal state
+}
+
+// NewInMemoryTaskStore returns a task store keeping the tasks in memory, the default of WithTaskStore.
+// The tasks in a terminal state are deleted ttl after their last update, they are kept forever if ttl is zero.
+func NewInMemoryTaskStore(ttl time.Duration) server.TaskStore {
+	return &inMemoryTaskStore{ttl: ttl, tasks: make(map[string]*storedTask)}
+}
+
+func (s *inMemoryTaskStore) Get(_ context.Context, id string) (*models.Task, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.tasks[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if s.expired(st, time.Now()) {
+		delete(s.tasks, id)
+		return nil, false, nil
+	}
+	return st.task, true, nil
+}
+
+func (s *inMemoryTaskStore) Save(_ context.Context, t *models.Task) error {
+	now := time.Now()
+	st := &storedTask{task: t}
+	if s.ttl > 0 && isTerminal(t.Status.State) {
+		st.expiresAt = now.Add(s.ttl)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[t.ID] = st
+	// the expired tasks are swept once per ttl, so that a save is O(1) amortized
+	if s.ttl > 0 && now.After(s.nextSweep) {
+		for id, st := range s.tasks {
+			if s.expired(st, now) {
+				delete(s.tasks, id)
+			}
+		}
+		s.nextSweep = now.Add(s.ttl)
+	}
+	return nil
+}
+
+func (s *inMemoryTaskStore) expired(st *storedTask, now time.Time) bool {
+	return !st.expiresAt.IsZero() && now.After(st.expiresAt)
+}
+
+func isTerminal(state models.TaskState) bool {
+	switch state {
+	case models.TaskStateCompleted, models.TaskStateCanceled, models.TaskStateFailed, models.TaskStateRejected:
+		return true
+	}
+	return false
+}
+
+func canceledStatus(text string) models.TaskStatus {
+	return models.TaskStatus{
+		State:     models.TaskStateCanceled,
+		Message:   newAgentMessage(models.Part{Kind: models.PartKindText, Text: &text}),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,220 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino-ext/a2a/models"
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// waitingAgent runs until its context is done, unless it ignores it
+type waitingAgent struct {
+	ignoreContext bool
+	started       chan struct{}
+	stopped       chan error // Error of the context of the run once done
+}
+
+func (w *waitingAgent) Name(_ context.Context) string { return "waiting" }
+
+func (w *waitingAgent) Description(_ context.Context) string { return "waits for its context" }
+
+func (w *waitingAgent) Run(ctx context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		defer gen.Close()
+		w.started <- struct{}{}
+		if w.ignoreContext {
+			time.Sleep(time.Second)
+			return
+		}
+		<-ctx.Done()
+		w.stopped <- ctx.Err()
+	}()
+	return iter
+}
+
+func newWaitingAgent(ignoreContext bool) *waitingAgent {
+	return &waitingAgent{ignoreContext: ignoreContext, started: make(chan struct{}, 1), stopped: make(chan error, 1)}
+}
+
+func performRPC(t *testing.T, h *server.Hertz, body string) *protocol.Response {
+	t.Helper()
+	return ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: strings.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+}
+
+func taskOf(t *testing.T, resp *protocol.Response) *taskResult {
+	t.Helper()
+	out := &taskResult{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	return out
+}
+
+func TestTaskTimeout(t *testing.T) {
+	ctx := context.Background()
+	agent := newWaitingAgent(false)
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithTaskTimeout(100*time.Millisecond))
+
+	out := taskOf(t, performRPC(t, h, `{"jsonrpc":"2.0","id":"1","method":"message/send","params":{`+
+		`"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"hi"}]}}}`))
+	assert.Equal(t, "failed", out.Result.Status.State)
+	assert.Equal(t, "agent run timed out after 100ms", statusText(out))
+	assert.ErrorIs(t, <-agent.stopped, context.DeadlineExceeded)
+
+	// the task ends even if the agent ignores its context, with the timeout of its skill
+	s = New()
+	assert.NoError(t, s.RegisterAgent(ctx, newWaitingAgent(true)))
+	h = newTestEngine(t, s, WithTaskTimeout(time.Minute), WithSkillTimeout("quick", 50*time.Millisecond))
+	start := time.Now()
+	out = taskOf(t, performRPC(t, h, `{"jsonrpc":"2.0","id":"1","method":"message/send","params":{`+
+		`"message":{"role":"user","messageId":"m1","metadata":{"skillId":"quick"},"parts":[{"kind":"text","text":"hi"}]}}}`))
+	assert.Equal(t, "failed", out.Result.Status.State)
+	assert.Equal(t, "agent run timed out after 50ms", statusText(out))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestTaskCancel(t *testing.T) {
+	ctx := context.Background()
+	agent := newWaitingAgent(false)
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s)
+
+	out := taskOf(t, performRPC(t, h, `{"jsonrpc":"2.0","id":"1","method":"message/send","params":{"configuration":{"blocking":false},`+
+		`"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"hi"}]}}}`))
+	taskID := out.Result.ID
+	<-agent.started
+
+	cancelBody := `{"jsonrpc":"2.0","id":"2","method":"tasks/cancel","params":{"id":"` + taskID + `"}}`
+	out = taskOf(t, performRPC(t, h, cancelBody))
+	assert.Equal(t, "canceled", out.Result.Status.State)
+	assert.Equal(t, "task canceled", statusText(out))
+	assert.ErrorIs(t, <-agent.stopped, context.Canceled)
+
+	resp := performRPC(t, h, cancelBody)
+	assert.Equal(t, 409, resp.StatusCode())
+	assert.Equal(t, ErrCodeTaskNotCancelable, rpcErrorCode(t, resp))
+
+	// an input-required task is canceled without running the agent
+	s = New()
+	assert.NoError(t, s.RegisterAgent(ctx, &approvalAgent{}))
+	h = newTestEngine(t, s)
+	taskID = sendOnTask(t, h, "", "pay Bob").Result.ID
+	out = taskOf(t, performRPC(t, h, `{"jsonrpc":"2.0","id":"2","method":"tasks/cancel","params":{"id":"`+taskID+`"}}`))
+	assert.Equal(t, "canceled", out.Result.Status.State)
+}
+
+// taskIDAgent replies with the id of its task
+type taskIDAgent struct{}
+
+func (a *taskIDAgent) Name(_ context.Context) string { return "task-id" }
+
+func (a *taskIDAgent) Description(_ context.Context) string { return "replies with its task id" }
+
+func (a *taskIDAgent) Run(ctx context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage(TaskID(ctx), nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func TestTaskID(t *testing.T) {
+	s := New()
+	assert.NoError(t, s.RegisterAgent(context.Background(), &taskIDAgent{}))
+	h := newTestEngine(t, s)
+	out := sendOnTask(t, h, "", "hi")
+	assert.NotEmpty(t, out.Result.ID)
+	assert.Equal(t, out.Result.ID, statusText(out))
+	assert.Empty(t, TaskID(context.Background()))
+}
+
+// failingArtifactStore fails to store the files
+type failingArtifactStore struct{}
+
+func (failingArtifactStore) Put(_ context.Context, _, _ string, _ io.Reader) (string, error) {
+	return "", errors.New("disk full")
+}
+
+func (failingArtifactStore) Open(_ context.Context, _ string) (io.ReadCloser, error) {
+	return nil, errors.New("not found")
+}
+
+func TestTaskRunRegistration(t *testing.T) {
+	tasks := newTaskRegistry(&runOption{}, nil)
+	conv := &messageConvertor{store: failingArtifactStore{}, inlineSize: 1}
+	run := &taskRun{}
+	ctx := context.WithValue(context.Background(), taskRunKey{}, run)
+	data := "aGVsbG8="
+	input := &models.Message{Role: models.RoleUser, MessageID: "m1",
+		Parts: []models.Part{{Kind: models.PartKindFile, File: &models.FileContent{MimeType: "text/plain", Bytes: &data}}}}
+
+	// a run whose input fails to convert never runs the agent, so it is never registered
+	_, err := runOptions(conv)(ctx, &models.Task{ID: "t1"}, input, nil)
+	assert.ErrorContains(t, err, "disk full")
+	assert.Equal(t, "t1", TaskID(ctx))
+	assert.Empty(t, tasks.running)
+
+	// the run is registered while the agent runs
+	e := &eventConvertor{agentName: "agent", tasks: tasks}
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	done := make(chan error)
+	go func() { done <- e.convert(ctx, iter, func(models.ResponseEvent) error { return nil }) }()
+	assert.Eventually(t, func() bool {
+		tasks.mu.Lock()
+		defer tasks.mu.Unlock()
+		return tasks.running["t1"] == run
+	}, time.Second, time.Millisecond)
+	gen.Close()
+	assert.NoError(t, <-done)
+	assert.Empty(t, tasks.running)
+}
+
+func TestInMemoryTaskStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryTaskStore(50 * time.Millisecond)
+	working := &models.Task{ID: "t1", Status: models.TaskStatus{State: models.TaskStateWorking}}
+	assert.NoError(t, store.Save(ctx, working))
+	assert.NoError(t, store.Save(ctx, &models.Task{ID: "t2", Status: models.TaskStatus{State: models.TaskStateCompleted}}))
+	got, ok, err := store.Get(ctx, "t2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "t2", got.ID)
+
+	// the tasks in a terminal state expire, the others are kept
+	time.Sleep(60 * time.Millisecond)
+	_, ok, _ = store.Get(ctx, "t2")
+	assert.False(t, ok)
+	got, ok, _ = store.Get(ctx, "t1")
+	assert.True(t, ok)
+	assert.Equal(t, working, got)
+
+	// the expired tasks are swept on save
+	assert.NoError(t, store.Save(ctx, &models.Task{ID: "t3", Status: models.TaskStatus{State: models.TaskStateFailed}}))
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, store.Save(ctx, working))
+	assert.Len(t, store.(*inMemoryTaskStore).tasks, 1)
+
+	// the tasks of the server are kept in the store of WithTaskStore
+	store = NewInMemoryTaskStore(0)
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &taskIDAgent{}, WithTaskStore(store)))
+	h := newTestEngine(t, s)
+	taskID := sendOnTask(t, h, "", "hi").Result.ID
+	got, ok, err = store.Get(ctx, taskID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, models.TaskStateCompleted, got.Status.State)
+}
@@ -0,0 +1,368 @@
+package a2a
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// JSON-RPC error codes of throttled requests, in the implementation-defined server error range
+const (
+	ErrCodeRateLimited  int64 = -32050 // The caller exceeded its rate limit
+	ErrCodeTooManyTasks int64 = -32051 // The server runs the max number of concurrent tasks
+)
+
+const (
+	throttleReasonRateLimit = "rate_limit"
+	throttleReasonTooMany   = "max_concurrent_tasks"
+
+	// callerLimiterIdleTTL is how long the token bucket of an idle caller is kept
+	callerLimiterIdleTTL = 10 * time.Minute
+)
+
+// CallerKeyFunc returns the key identifying the caller of a request for per-caller rate limiting
+type CallerKeyFunc func(ctx context.Context, c *app.RequestContext) string
+
+// defaultCallerKey identifies the caller by its credential if any, or by its IP
+func defaultCallerKey(_ context.Context, c *app.RequestContext) string {
+	if auth := c.Request.Header.Peek("Authorization"); len(auth) > 0 {
+		sum := sha256.Sum256(auth)
+		return "auth:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// isTaskMethod reports whether the JSON-RPC method starts or continues an agent run
+func isTaskMethod(method string) bool {
+	return method == "message/send" || method == "message/stream"
+}
+
+// throttler limits the rate of agent runs per caller and the number of concurrent agent runs.
+// Only message/send and message/stream are throttled, reading tasks and the agent card is not.
+type throttler struct {
+	limit        rate.Limit
+	burst        int
+	callerKey    CallerKeyFunc
+	queueTimeout time.Duration
+	slots        chan struct{} // Concurrent task slots, nil if unlimited
+
+	mu        sync.Mutex
+	limiters  map[string]*callerLimiter
+	lastSweep time.Time
+
+	throttled metric.Int64Counter
+	queued    metric.Int64Counter
+	active    metric.Int64UpDownCounter
+}
+
+type callerLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// newThrottler returns nil if neither rate limiting nor concurrency limiting is configured
+func newThrottler(runOpts *runOption, meter metric.Meter) (*throttler, error) {
+	if runOpts.RateLimit <= 0 && runOpts.MaxConcurrentTasks <= 0 {
+		return nil, nil
+	}
+
+	t := &throttler{
+		limit:        rate.Limit(runOpts.RateLimit),
+		burst:        runOpts.RateBurst,
+		callerKey:    runOpts.CallerKeyFunc,
+		queueTimeout: runOpts.ThrottleQueueTimeout,
+		limiters:     make(map[string]*callerLimiter),
+		lastSweep:    time.Now(),
+	}
+	if t.callerKey == nil {
+		t.callerKey = defaultCallerKey
+	}
+	if t.burst <= 0 {
+		t.burst = int(math.Max(1, math.Ceil(runOpts.RateLimit)))
+	}
+	if runOpts.MaxConcurrentTasks > 0 {
+		t.slots = make(chan struct{}, runOpts.MaxConcurrentTasks)
+	}
+
+	var err error
+	if t.throttled, err = meter.Int64Counter("a2a.server.throttled_requests",
+		metric.WithDescription("Requests rejected by rate limiting or concurrency limiting")); err != nil {
+		return nil, err
+	}
+	if t.queued, err = meter.Int64Counter("a2a.server.queued_requests",
+		metric.WithDescription("Requests delayed by rate limiting or concurrency limiting before running")); err != nil {
+		return nil, err
+	}
+	if t.active, err = meter.Int64UpDownCounter("a2a.server.active_tasks",
+		metric.WithDescription("Agent runs holding a concurrent task slot")); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *throttler) middleware(ctx context.Context, c *app.RequestContext) {
+	req, ok := parseRPCRequest(c)
+	if !ok || !isTaskMethod(req.Method) {
+		c.Next(ctx)
+		return
+	}
+	methodAttr := attribute.String("rpc.method", req.Method)
+
+	var (
+		reservation *rate.Reservation
+		tokenAt     time.Time
+	)
+	if t.limit > 0 {
+		now := time.Now()
+		r, delay, ok := t.reserve(t.callerKey(ctx, c), now)
+		if !ok {
+			t.reject(ctx, c, req, throttleReasonRateLimit, delay, methodAttr)
+			return
+		}
+		reservation, tokenAt = r, now.Add(delay)
+		if delay > 0 {
+			t.queued.Add(ctx, 1, metric.WithAttributes(attribute.String("a2a.throttle.reason", throttleReasonRateLimit), methodAttr))
+			if !sleep(ctx, delay) {
+				r.CancelAt(tokenAt)
+				c.Abort()
+				return
+			}
+		}
+	}
+
+	if t.slots != nil {
+		slot, ok := t.acquire(ctx, methodAttr)
+		if !ok {
+			if reservation != nil {
+				// give the token back, as of the time it was reserved for, so that rejected requests are not counted
+				reservation.CancelAt(tokenAt)
+			}
+			t.reject(ctx, c, req, throttleReasonTooMany, time.Second, methodAttr)
+			return
+		}
+		// the task locker takes the slot over for the lifetime of the run, which may outlive the request
+		ctx = context.WithValue(ctx, taskSlotKey{}, slot)
+		defer slot.releaseHeld()
+	}
+
+	c.Next(ctx)
+}
+
+// reserve takes a token of the caller's bucket, returning its reservation and how long to wait before running.
+// It returns false with the time until a token is available if the wait exceeds the queue timeout.
+func (t *throttler) reserve(key string, now time.Time) (*rate.Reservation, time.Duration, bool) {
+
+	t.mu.Lock()
+	if now.Sub(t.lastSweep) > time.Minute {
+		for k, l := range t.limiters {
+			if now.Sub(l.lastSeen) > callerLimiterIdleTTL {
+				delete(t.limiters, k)
+			}
+		}
+		t.lastSweep = now
+	}
+	l, ok := t.limiters[key]
+	if !ok {
+		l = &callerLimiter{Limiter: rate.NewLimiter(t.limit, t.burst)}
+		t.limiters[key] = l
+	}
+	l.lastSeen = now
+	t.mu.Unlock()
+
+	r := l.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay > t.queueTimeout {
+		r.CancelAt(now)
+		return nil, delay, false
+	}
+	return r, delay, true
+}
+
+// acquire takes a concurrent task slot, waiting up to the queue timeout for one to be released
+func (t *throttler) acquire(ctx context.Context, methodAttr attribute.KeyValue) (*taskSlot, bool) {
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		if t.queueTimeout <= 0 {
+			return nil, false
+		}
+		t.queued.Add(ctx, 1, metric.WithAttributes(attribute.String("a2a.throttle.reason", throttleReasonTooMany), methodAttr))
+		timer := time.NewTimer(t.queueTimeout)
+		defer timer.Stop()
+		select {
+		case t.slots <- struct{}{}:
+		case <-timer.C:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	t.active.Add(ctx, 1)
+	return &taskSlot{t: t}, true
+}
+
+func (t *throttler) reject(ctx context.Context, c *app.RequestContext, req *rpcRequest, reason string,
+	retryAfter time.Duration, methodAttr attribute.KeyValue) {
+	t.throttled.Add(ctx, 1, metric.WithAttributes(attribute.String("a2a.throttle.reason", reason), methodAttr))
+
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	rpcErr := &rpcError{
+		Code:    ErrCodeRateLimited,
+		Message: "rate limit exceeded",
+		Data:    map[string]any{"retryAfter": seconds},
+	}
+	if reason == throttleReasonTooMany {
+		rpcErr.Code = ErrCodeTooManyTasks
+		rpcErr.Message = "too many concurrent tasks"
+	}
+	c.Response.Header.Set("Retry-After", strconv.FormatInt(seconds, 10))
+	abortWithRPCError(c, consts.StatusTooManyRequests, req, rpcErr)
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type taskSlotKey struct{}
+
+// states of a taskSlot, changed atomically so that the slot is handed over or released exactly once
+const (
+	slotHeld     int32 = iota // Held by the request
+	slotOwned                 // Taken over by the task lock
+	slotReleased              // Released
+)
+
+// taskSlot is a concurrent task slot, held by the request until a task locker takes it over
+type taskSlot struct {
+	t     *throttler
+	state atomic.Int32
+}
+
+// releaseHeld releases the slot at the end of the request, unless a task lock took it over
+func (s *taskSlot) releaseHeld() {
+	if s.state.CompareAndSwap(slotHeld, slotReleased) {
+		s.free()
+	}
+}
+
+// takeOver hands the slot over to a task lock, so that it is released when the task is unlocked.
+// If the request released the slot first, the run waits for another one, so that the limit is never exceeded.
+func (s *taskSlot) takeOver(ctx context.Context) error {
+	if s.state.CompareAndSwap(slotHeld, slotOwned) {
+		return nil
+	}
+	if s.state.Load() != slotReleased {
+		return errSlotTaken
+	}
+	select {
+	case s.t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.t.active.Add(ctx, 1)
+	s.state.Store(slotOwned)
+	return nil
+}
+
+// releaseOwned releases the slot taken over by a task lock
+func (s *taskSlot) releaseOwned() {
+	if s.state.CompareAndSwap(slotOwned, slotReleased) {
+		s.free()
+	}
+}
+
+func (s *taskSlot) free() {
+	<-s.t.slots
+	s.t.active.Add(context.Background(), -1)
+}
+
+// errSlotTaken is returned when a request locks a second task, its slot is already owned by the first one
+var errSlotTaken = errors.New("task slot already taken over")
+
+// slotTaskLocker is an in-memory task locker that keeps the concurrent task slot of a run until the task is unlocked.
+// The A2A handlers lock a task for the whole agent run, including runs continuing after a non-blocking message/send returns.
+type slotTaskLocker struct {
+	mu    sync.Mutex
+	locks map[string]*taskLock // Locks by task id, deleted once no run holds or waits for them
+	slots sync.Map             // task id -> *taskSlot
+}
+
+// taskLock is the lock of a task, counting the runs holding or waiting for it
+type taskLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (l *slotTaskLocker) Lock(ctx context.Context, id string) error {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*taskLock)
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &taskLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+	lock.Lock()
+
+	if slot, ok := ctx.Value(taskSlotKey{}).(*taskSlot); ok {
+		switch err := slot.takeOver(ctx); {
+		case err == nil:
+			l.slots.Store(id, slot)
+		case !errors.Is(err, errSlotTaken):
+			l.release(id, lock)
+			return fmt.Errorf("failed to take the concurrent task slot of task %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (l *slotTaskLocker) Unlock(_ context.Context, id string) error {
+	if slot, ok := l.slots.LoadAndDelete(id); ok {
+		slot.(*taskSlot).releaseOwned()
+	}
+	l.mu.Lock()
+	lock, ok := l.locks[id]
+	l.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no lock found for task with id %s", id)
+	}
+	l.release(id, lock)
+	return nil
+}
+
+// release unlocks the lock of a task, deleting it once the last run holding or waiting for it is done
+func (l *slotTaskLocker) release(id string, lock *taskLock) {
+	lock.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lock.refs--; lock.refs == 0 {
+		delete(l.locks, id)
+	}
+}
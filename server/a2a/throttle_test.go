@@ -0,0 +1,268 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// blockingAgent replies once release is closed
+type blockingAgent struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingAgent) Name(_ context.Context) string { return "blocking" }
+
+func (b *blockingAgent) Description(_ context.Context) string { return "replies when released" }
+
+func (b *blockingAgent) Run(_ context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		b.started <- struct{}{}
+		<-b.release
+		gen.Send(adk.EventFromMessage(schema.AssistantMessage("done", nil), nil, schema.Assistant, ""))
+		gen.Close()
+	}()
+	return iter
+}
+
+func rpcErrorCode(t *testing.T, resp *protocol.Response) int64 {
+	t.Helper()
+	out := struct {
+		Error *rpcError `json:"error"`
+	}{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), &out))
+	if out.Error == nil {
+		return 0
+	}
+	return out.Error.Code
+}
+
+func TestRateLimit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	h := newTestEngine(t, New(), WithRateLimit(1, 1), WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	send := func(token string) *protocol.Response {
+		return ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+			ut.Header{Key: "Content-Type", Value: "application/json"},
+			ut.Header{Key: "Authorization", Value: token}).Result()
+	}
+
+	assert.Equal(t, 200, send("Bearer a").StatusCode())
+	resp := send("Bearer a")
+	assert.Equal(t, 429, resp.StatusCode())
+	assert.Equal(t, "1", string(resp.Header.Peek("Retry-After")))
+	assert.Equal(t, ErrCodeRateLimited, rpcErrorCode(t, resp))
+
+	// another caller has its own bucket
+	assert.Equal(t, 200, send("Bearer b").StatusCode())
+	// reading the agent card is not throttled
+	assert.Equal(t, 200, ut.PerformRequest(h.Engine, "GET", "/.well-known/agent-card.json", nil).Result().StatusCode())
+
+	rm := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	var throttled int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "a2a.server.throttled_requests" {
+				for _, dp := range sum.DataPoints {
+					throttled += dp.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(1), throttled)
+}
+
+func TestMaxConcurrentTasks(t *testing.T) {
+	ctx := context.Background()
+	agent := &blockingAgent{started: make(chan struct{}, 3), release: make(chan struct{})}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithMaxConcurrentTasks(1))
+	send := func() *protocol.Response {
+		return ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	}
+
+	first := make(chan *protocol.Response)
+	go func() { first <- send() }()
+	<-agent.started
+
+	resp := send()
+	assert.Equal(t, 429, resp.StatusCode())
+	assert.Equal(t, ErrCodeTooManyTasks, rpcErrorCode(t, resp))
+
+	close(agent.release)
+	assert.Equal(t, 200, (<-first).StatusCode())
+	assert.Equal(t, 200, send().StatusCode())
+}
+
+func TestThrottleQueue(t *testing.T) {
+	ctx := context.Background()
+	agent := &blockingAgent{started: make(chan struct{}, 3), release: make(chan struct{})}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithMaxConcurrentTasks(1), WithThrottleQueue(5*time.Second))
+	send := func() int {
+		return ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result().StatusCode()
+	}
+
+	first := make(chan int)
+	go func() { first <- send() }()
+	<-agent.started
+
+	// the second request waits for the first run to end
+	second := make(chan int)
+	go func() { second <- send() }()
+	select {
+	case <-agent.started:
+		t.Fatal("the queued request should not run before a slot is released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(agent.release)
+	assert.Equal(t, 200, <-first)
+	assert.Equal(t, 200, <-second)
+}
+
+func TestMaxConcurrentTasksNonBlocking(t *testing.T) {
+	ctx := context.Background()
+	agent := &blockingAgent{started: make(chan struct{}, 3), release: make(chan struct{})}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithMaxConcurrentTasks(1))
+
+	body := `{"jsonrpc":"2.0","id":"1","method":"message/send","params":{"configuration":{"blocking":false},` +
+		`"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"hi"}]}}}`
+	resp := ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: strings.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	assert.Equal(t, 200, resp.StatusCode())
+	<-agent.started
+
+	// the run keeps its slot after the non-blocking request returned
+	resp = ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+	assert.Equal(t, ErrCodeTooManyTasks, rpcErrorCode(t, resp))
+
+	close(agent.release)
+	assert.Eventually(t, func() bool {
+		return ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+			ut.Header{Key: "Content-Type", Value: "application/json"}).Result().StatusCode() == 200
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMaxConcurrentTasksNonBlockingBurst(t *testing.T) {
+	ctx := context.Background()
+	const limit = 2
+	agent := &blockingAgent{started: make(chan struct{}, limit+1), release: make(chan struct{})}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithMaxConcurrentTasks(limit))
+
+	body := `{"jsonrpc":"2.0","id":"1","method":"message/send","params":{"configuration":{"blocking":false},` +
+		`"message":{"role":"user","messageId":"m1","parts":[{"kind":"text","text":"hi"}]}}}`
+	codes := make(chan int64, limit+1)
+	var wg sync.WaitGroup
+	for i := 0; i < limit+1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := ut.PerformRequest(h.Engine, "POST", "/", &ut.Body{Body: strings.NewReader(body), Len: len(body)},
+				ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+			codes <- rpcErrorCode(t, resp)
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	var rejected int
+	for code := range codes {
+		if code == ErrCodeTooManyTasks {
+			rejected++
+		}
+	}
+	// the runs keep their slots after their requests returned, whatever the order they lock their tasks in
+	assert.Equal(t, 1, rejected)
+	for i := 0; i < limit; i++ {
+		<-agent.started
+	}
+	select {
+	case <-agent.started:
+		t.Fatal("more runs than the concurrent task limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(agent.release)
+}
+
+func TestSlotTaskLockerLateLock(t *testing.T) {
+	ctx := context.Background()
+	th := &throttler{slots: make(chan struct{}, 1), active: noop.Int64UpDownCounter{}}
+	attr := attribute.String("rpc.method", "message/send")
+	l := &slotTaskLocker{}
+
+	// the request returned before its run locked the task, and another request took the slot
+	slot, ok := th.acquire(ctx, attr)
+	assert.True(t, ok)
+	slot.releaseHeld()
+	other, ok := th.acquire(ctx, attr)
+	assert.True(t, ok)
+
+	locked := make(chan error)
+	go func() { locked <- l.Lock(context.WithValue(ctx, taskSlotKey{}, slot), "t1") }()
+	select {
+	case <-locked:
+		t.Fatal("the late run should wait for a slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	other.releaseHeld()
+	assert.NoError(t, <-locked)
+	assert.Len(t, th.slots, 1)
+
+	assert.NoError(t, l.Unlock(ctx, "t1"))
+	assert.Len(t, th.slots, 0)
+	// the locks of the unlocked tasks are dropped
+	assert.Empty(t, l.locks)
+	assert.Error(t, l.Unlock(ctx, "t1"))
+}
+
+func TestRateLimitTooManyTasks(t *testing.T) {
+	ctx := context.Background()
+	agent := &blockingAgent{started: make(chan struct{}, 2), release: make(chan struct{})}
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, agent))
+	h := newTestEngine(t, s, WithRateLimit(0.1, 2), WithMaxConcurrentTasks(1))
+	send := func() chan int64 {
+		codes := make(chan int64, 1)
+		go func() {
+			resp := ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hi"),
+				ut.Header{Key: "Content-Type", Value: "application/json"}).Result()
+			codes <- rpcErrorCode(t, resp)
+		}()
+		return codes
+	}
+
+	first := send()
+	<-agent.started
+	// the requests rejected for want of a slot give their token back
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrCodeTooManyTasks, <-send())
+	}
+	close(agent.release)
+	assert.Equal(t, int64(0), <-first)
+	assert.Equal(t, int64(0), <-send())
+}
@@ -0,0 +1,142 @@
+package a2a
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	hertzServer "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/hertz-contrib/http2/factory"
+)
+
+// alpnHTTP2 is the ALPN protocol id of HTTP/2 over TLS
+const alpnHTTP2 = "h2"
+
+// certReloader serves a certificate loaded from files, and reloads it once the files are modified,
+// so that renewed certificates are picked up without restarting the server.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.load()
+}
+
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certInfo, certErr := os.Stat(r.certFile)
+	keyInfo, keyErr := os.Stat(r.keyFile)
+	if certErr != nil || keyErr != nil {
+		if r.cert != nil {
+			// files may be replaced non-atomically, keep serving the last good certificate
+			return r.cert, nil
+		}
+		if certErr != nil {
+			return nil, fmt.Errorf("failed to stat certificate file: %w", certErr)
+		}
+		return nil, fmt.Errorf("failed to stat key file: %w", keyErr)
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certTime) && keyInfo.ModTime().Equal(r.keyTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load key pair: %w", err)
+	}
+	r.cert = &cert
+	r.certTime = certInfo.ModTime()
+	r.keyTime = keyInfo.ModTime()
+	return r.cert, nil
+}
+
+// buildTLSConfig builds the TLS config of the server, nil if TLS is not enabled
+func buildTLSConfig(runOpts *runOption) (*tls.Config, error) {
+	if runOpts.TLSConfig == nil && runOpts.CertFile == "" && runOpts.ClientCAFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if runOpts.TLSConfig != nil {
+		cfg = runOpts.TLSConfig.Clone()
+	}
+
+	if runOpts.CertFile != "" {
+		reloader, err := newCertReloader(runOpts.CertFile, runOpts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetCertificate = reloader.GetCertificate
+	}
+	if len(cfg.Certificates) == 0 && cfg.GetCertificate == nil && cfg.GetConfigForClient == nil {
+		return nil, fmt.Errorf("tls enabled without certificate, use WithTLS or set it in the tls config")
+	}
+
+	if runOpts.ClientCAFile != "" {
+		pem, err := os.ReadFile(runOpts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in client ca file %s", runOpts.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		if cfg.ClientAuth == tls.NoClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return cfg, nil
+}
+
+// transportOptions returns the hertz options enabling TLS and HTTP/2 as configured
+func transportOptions(runOpts *runOption) ([]config.Option, error) {
+	tlsCfg, err := buildTLSConfig(runOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []config.Option
+	if tlsCfg != nil {
+		if runOpts.HTTP2 {
+			tlsCfg.NextProtos = append([]string{alpnHTTP2}, tlsCfg.NextProtos...)
+			opts = append(opts, hertzServer.WithALPN(true))
+		}
+		opts = append(opts, hertzServer.WithTLS(tlsCfg))
+	} else if runOpts.HTTP2 {
+		opts = append(opts, hertzServer.WithH2C(true))
+	}
+	return opts, nil
+}
+
+// enableHTTP2 registers the HTTP/2 protocol server on h if enabled
+func enableHTTP2(h *hertzServer.Hertz, runOpts *runOption) {
+	if runOpts.HTTP2 {
+		h.AddProtocol(alpnHTTP2, factory.NewServerFactory())
+	}
+}
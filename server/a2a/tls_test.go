@@ -0,0 +1,144 @@
+package a2a
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate for cn signed by parent, or a self-signed CA if parent is nil
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+// write writes the certificate and key as PEM files, returning their paths
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestServerTLS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ca := newTestCert(t, "test ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "server-1", ca).write(t, dir, "server")
+	clientCert := newTestCert(t, "client", ca)
+
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &echoAgent{}))
+	addr := runTestServer(t, s, WithTLS(certFile, keyFile), WithClientCA(caFile), WithHTTP2(true))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://" + addr + "/.well-known/agent-card.json"
+	get := func(certs ...tls.Certificate) (*http.Response, error) {
+		cli := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: true,
+		}}
+		resp, err := cli.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	resp, err := get(clientCert.tlsCertificate())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "server-1", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	// client certificate is required
+	_, err = get()
+	assert.Error(t, err)
+
+	// the renewed certificate is served to new connections
+	newTestCert(t, "server-2", ca).write(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	assert.NoError(t, os.Chtimes(keyFile, future, future))
+	resp, err = get(clientCert.tlsCertificate())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "server-2", resp.TLS.PeerCertificates[0].Subject.CommonName)
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	cfg, err := buildTLSConfig(&runOption{})
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	_, err = buildTLSConfig(&runOption{TLSConfig: &tls.Config{}})
+	assert.Error(t, err)
+
+	_, err = buildTLSConfig(&runOption{CertFile: "missing.crt", KeyFile: "missing.key"})
+	assert.Error(t, err)
+
+	base := &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, Certificates: []tls.Certificate{{}}}
+	dir := t.TempDir()
+	caFile, _ := newTestCert(t, "test ca", nil).write(t, dir, "ca")
+	cfg, err = buildTLSConfig(&runOption{TLSConfig: base, ClientCAFile: caFile})
+	assert.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+	assert.Nil(t, base.ClientCAs)
+}
@@ -0,0 +1,71 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/eino-contrib/agentkit-ve/server/a2a"
+
+// tracingMiddleware extracts the trace context from the request headers
+// and starts a server span named after the JSON-RPC method of the request.
+func tracingMiddleware(tracer trace.Tracer, propagator propagation.TextMapPropagator) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		ctx = propagator.Extract(ctx, &headerCarrier{c: c})
+
+		name := string(c.Method()) + " " + string(c.Path())
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", string(c.Method())),
+			attribute.String("url.path", string(c.Path())),
+		}
+		if req, ok := parseRPCRequest(c); ok {
+			name = req.Method
+			attrs = append(attrs,
+				attribute.String("rpc.system", "jsonrpc"),
+				attribute.String("rpc.method", req.Method),
+				attribute.String("rpc.jsonrpc.request_id", fmt.Sprint(req.ID)),
+			)
+		}
+
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		c.Next(ctx)
+
+		status := c.Response.StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if rpcErr := parseRPCResponseError(c); rpcErr != nil {
+			span.SetAttributes(attribute.Int64("rpc.jsonrpc.error_code", rpcErr.Code))
+			span.SetStatus(codes.Error, rpcErr.Message)
+		} else if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP status %d", status))
+		}
+	}
+}
+
+// headerCarrier adapts the hertz request headers to propagation.TextMapCarrier
+type headerCarrier struct {
+	c *app.RequestContext
+}
+
+func (h *headerCarrier) Get(key string) string {
+	return string(h.c.Request.Header.Peek(key))
+}
+
+func (h *headerCarrier) Set(key, value string) {
+	h.c.Request.Header.Set(key, value)
+}
+
+func (h *headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request.Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
@@ -0,0 +1,44 @@
+package a2a
+
+import (
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	h := newTestEngine(t, New(), WithTracerProvider(tp), WithPropagator(propagation.TraceContext{}))
+
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	w := ut.PerformRequest(h.Engine, "POST", "/", sendMessageRequest(t, "hello"),
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+		ut.Header{Key: "traceparent", Value: traceParent})
+	assert.Equal(t, 200, w.Result().StatusCode())
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+
+	rpcSpan := byName["message/send"]
+	if assert.NotNil(t, rpcSpan) {
+		assert.Equal(t, trace.SpanKindServer, rpcSpan.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rpcSpan.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", rpcSpan.Parent().SpanID().String())
+	}
+
+	agentSpan := byName["invoke_agent echo"]
+	if assert.NotNil(t, agentSpan) && rpcSpan != nil {
+		assert.Equal(t, rpcSpan.SpanContext().SpanID(), agentSpan.Parent().SpanID())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build (amd64 || arm64) && (linux || darwin)
+
+package a2a
+
+import "github.com/cloudwego/hertz/pkg/network/netpoll"
+
+// defaultTransporter is the transporter Hertz uses when none is configured
+var defaultTransporter = netpoll.NewTransporter
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpServer "github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodProgress  = "notifications/progress"
+	methodCancelled = "notifications/cancelled"
+
+	// callKeyMeta is the meta field the request of a tool call is tagged with, to find the call to cancel
+	callKeyMeta = "agentkit/call_key"
+)
+
+// calls tracks the running tool calls by session and request id.
+// The MCP handlers don't know the id of their request, so it is added to the request meta by a before-call hook.
+type calls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func callKey(ctx context.Context, id any) string {
+	sessionID := ""
+	if session := mcpServer.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	// ids are compared as JSON, numbers decoded from notifications are float64 while request ids are int64
+	b, _ := json.Marshal(id)
+	return sessionID + "/" + string(b)
+}
+
+func (c *calls) tagRequest(ctx context.Context, id any, req *mcp.CallToolRequest) {
+	if req.Params.Meta == nil {
+		req.Params.Meta = &mcp.Meta{}
+	}
+	if req.Params.Meta.AdditionalFields == nil {
+		req.Params.Meta.AdditionalFields = map[string]any{}
+	}
+	req.Params.Meta.AdditionalFields[callKeyMeta] = callKey(ctx, id)
+}
+
+// start makes the context of a tool call, canceled when the client cancels the request
+func (c *calls) start(ctx context.Context, req mcp.CallToolRequest) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if req.Params.Meta == nil {
+		return ctx, cancel
+	}
+	key, ok := req.Params.Meta.AdditionalFields[callKeyMeta].(string)
+	if !ok {
+		return ctx, cancel
+	}
+
+	c.mu.Lock()
+	c.cancels[key] = cancel
+	c.mu.Unlock()
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.cancels, key)
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+func (c *calls) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	cancel := c.cancels[callKey(ctx, id)]
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+type toolHandler struct {
+	agent adk.Agent
+	calls *calls
+}
+
+// handle runs the agent with the input argument and returns its final answer.
+// Agent errors are returned as tool errors so that the calling model can see them.
+func (h *toolHandler) handle(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input, err := req.RequireString("input")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ctx, done := h.calls.start(ctx, req)
+	defer done()
+
+	progress := &progressReporter{}
+	if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+		progress.token = req.Params.Meta.ProgressToken
+		progress.server = mcpServer.ServerFromContext(ctx)
+	}
+
+	runner := adk.NewRunner(ctx, adk.RunnerConfig{Agent: h.agent})
+	iter := runner.Query(ctx, input)
+
+	// the agent may not watch ctx, so the result is waited for apart from the cancellation
+	resultCh := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		resultCh <- h.consume(ctx, iter, progress)
+	}()
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case <-ctx.Done():
+		return mcp.NewToolResultError(fmt.Sprintf("agent run canceled: %v", ctx.Err())), nil
+	}
+}
+
+// consume iterates the agent events, reporting them as progress, and returns the last answer of the agent
+func (h *toolHandler) consume(ctx context.Context, iter *adk.AsyncIterator[*adk.AgentEvent], progress *progressReporter) *mcp.CallToolResult {
+	var answer string
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			return mcp.NewToolResultText(answer)
+		}
+		if event.Err != nil {
+			return mcp.NewToolResultError(event.Err.Error())
+		}
+		if event.Action != nil && event.Action.Interrupted != nil {
+			return mcp.NewToolResultError("agent interrupted, human input is not supported over MCP")
+		}
+
+		var msg *schema.Message
+		if event.Output != nil && event.Output.MessageOutput != nil {
+			var err error
+			if msg, err = event.Output.MessageOutput.GetMessage(); err != nil {
+				return mcp.NewToolResultError(err.Error())
+			}
+		}
+		if msg != nil && msg.Role == schema.Assistant && len(msg.ToolCalls) == 0 {
+			answer = msg.Content
+		}
+		progress.report(ctx, describeEvent(event, msg))
+	}
+}
+
+// progressReporter sends a progress notification per agent event, if the client asked for them with a progress token
+type progressReporter struct {
+	server *mcpServer.MCPServer
+	token  mcp.ProgressToken
+	count  int
+}
+
+func (p *progressReporter) report(ctx context.Context, message string) {
+	if p.server == nil || message == "" {
+		return
+	}
+	p.count++
+	// progress notifications are best effort, the client may have gone away
+	_ = p.server.SendNotificationToClient(ctx, methodProgress, map[string]any{
+		"progressToken": p.token,
+		"progress":      p.count,
+		"message":       message,
+	})
+}
+
+// describeEvent summarizes an agent event as a progress message, empty if the event is not worth reporting
+func describeEvent(event *adk.AgentEvent, msg *schema.Message) string {
+	if event.Action != nil && event.Action.TransferToAgent != nil {
+		return fmt.Sprintf("%s: transferring to %s", event.AgentName, event.Action.TransferToAgent.DestAgentName)
+	}
+	if msg == nil {
+		return ""
+	}
+	switch {
+	case msg.Role == schema.Tool:
+		name := msg.ToolName
+		if event.Output.MessageOutput.ToolName != "" {
+			name = event.Output.MessageOutput.ToolName
+		}
+		return fmt.Sprintf("%s: tool %s done", event.AgentName, name)
+	case len(msg.ToolCalls) > 0:
+		names := make([]string, 0, len(msg.ToolCalls))
+		for _, tc := range msg.ToolCalls {
+			names = append(names, tc.Function.Name)
+		}
+		return fmt.Sprintf("%s: calling %s", event.AgentName, strings.Join(names, ", "))
+	case msg.Content != "":
+		return fmt.Sprintf("%s: %s", event.AgentName, msg.Content)
+	}
+	return ""
+}
@@ -0,0 +1,232 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpServer "github.com/mark3labs/mcp-go/server"
+)
+
+// Server exposes agents as MCP tools, so that MCP hosts (IDEs, desktop assistants, other agents) can call them.
+// Each registered agent becomes a tool named and described after the agent, like its A2A agent card.
+// It is served over stdio with ServeStdio, or over the streamable HTTP transport with Run or Handler.
+type Server struct {
+	name    string               // Server name reported at initialization
+	version string               // Server version reported at initialization
+	mu      sync.Mutex           // Mutex for thread-safe operations
+	mcp     *mcpServer.MCPServer // The underlying MCP server
+	calls   *calls               // Running tool calls, canceled by notifications/cancelled
+	tools   map[string]bool      // Names of the registered tools
+}
+
+// OptionFn is a function type for configuring the server using the functional options pattern
+type OptionFn func(*Server)
+
+// WithServerInfo sets the server name and version reported to the clients at initialization,
+// default is "agentkit" and "1.0.0"
+func WithServerInfo(name, version string) OptionFn {
+	return func(s *Server) {
+		s.name = name
+		s.version = version
+	}
+}
+
+// agentOption holds configuration options for a registered agent
+type agentOption struct {
+	ToolName        *string // Tool name, the agent name by default
+	ToolDescription *string // Tool description, the agent description by default
+}
+
+// AgentOptionFn is a function type for configuring agent options using the functional options pattern
+type AgentOptionFn func(*agentOption)
+
+// WithToolName sets the name of the tool, default is the agent name with the characters not allowed
+// in tool names replaced by "_"
+func WithToolName(name string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.ToolName = &name
+	}
+}
+
+// WithToolDescription sets the description of the tool, default is the agent description
+func WithToolDescription(description string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.ToolDescription = &description
+	}
+}
+
+// runOption holds configuration options for serving over HTTP
+type runOption struct {
+	Host         string // Server host address (e.g., "0.0.0.0", "localhost")
+	Port         int    // Server port number (e.g., 8080)
+	EndpointPath string // Path of the MCP endpoint
+	Stateless    bool   // Whether no session is kept between requests
+}
+
+// RunOptionFn is a function type for configuring run options using the functional options pattern
+type RunOptionFn func(*runOption)
+
+// WithHost sets the server host address
+func WithHost(host string) RunOptionFn {
+	return func(o *runOption) {
+		o.Host = host
+	}
+}
+
+// WithPort sets the server port number
+func WithPort(port int) RunOptionFn {
+	return func(o *runOption) {
+		o.Port = port
+	}
+}
+
+// WithEndpointPath sets the path of the MCP endpoint, default is "/mcp"
+func WithEndpointPath(path string) RunOptionFn {
+	return func(o *runOption) {
+		o.EndpointPath = path
+	}
+}
+
+// WithStateless sets whether the server keeps no session between requests, default is false.
+// Stateless servers can't receive notifications/cancelled from the clients, calls are still canceled
+// when the client disconnects.
+func WithStateless(stateless bool) RunOptionFn {
+	return func(o *runOption) {
+		o.Stateless = stateless
+	}
+}
+
+// New creates a new Server instance
+func New(opts ...OptionFn) *Server {
+	s := &Server{
+		name:    "agentkit",
+		version: "1.0.0",
+		calls:   &calls{cancels: map[string]context.CancelFunc{}},
+		tools:   map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	hooks := &mcpServer.Hooks{}
+	hooks.AddBeforeCallTool(s.calls.tagRequest)
+	s.mcp = mcpServer.NewMCPServer(s.name, s.version,
+		mcpServer.WithToolCapabilities(true),
+		mcpServer.WithRecovery(),
+		mcpServer.WithHooks(hooks),
+	)
+	s.mcp.AddNotificationHandler(methodCancelled, s.calls.handleCancelled)
+	return s
+}
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// RegisterAgent registers an agent as a tool of the server, taking the input of the agent as its "input" argument
+func (s *Server) RegisterAgent(ctx context.Context, agent adk.Agent, opts ...AgentOptionFn) error {
+	if agent == nil {
+		return fmt.Errorf("agent cannot be nil")
+	}
+
+	agentOpts := &agentOption{}
+	for _, opt := range opts {
+		opt(agentOpts)
+	}
+	name := invalidToolNameChars.ReplaceAllString(agent.Name(ctx), "_")
+	if agentOpts.ToolName != nil {
+		name = *agentOpts.ToolName
+	}
+	if name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+	description := agent.Description(ctx)
+	if agentOpts.ToolDescription != nil {
+		description = *agentOpts.ToolDescription
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tools[name] {
+		return fmt.Errorf("tool %q already registered", name)
+	}
+	s.tools[name] = true
+
+	h := &toolHandler{agent: agent, calls: s.calls}
+	s.mcp.AddTool(mcp.NewTool(name,
+		mcp.WithDescription(description),
+		mcp.WithString("input", mcp.Required(), mcp.Description("The request to the agent")),
+	), h.handle)
+	return nil
+}
+
+// MCPServer returns the underlying MCP server, e.g. to add other tools, resources or prompts
+func (s *Server) MCPServer() *mcpServer.MCPServer {
+	return s.mcp
+}
+
+// ServeStdio serves the agents over stdin and stdout until ctx is done or stdin is closed
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.serveIO(ctx, os.Stdin, os.Stdout)
+}
+
+func (s *Server) serveIO(ctx context.Context, in io.Reader, out io.Writer) error {
+	return mcpServer.NewStdioServer(s.mcp).Listen(ctx, in, out)
+}
+
+// Handler returns the streamable HTTP handler of the server, to be served by the caller at the endpoint path.
+// Only WithEndpointPath and WithStateless apply.
+func (s *Server) Handler(opts ...RunOptionFn) http.Handler {
+	runOpts := &runOption{EndpointPath: "/mcp"}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+	return s.handler(runOpts)
+}
+
+func (s *Server) handler(runOpts *runOption) *mcpServer.StreamableHTTPServer {
+	return mcpServer.NewStreamableHTTPServer(s.mcp,
+		mcpServer.WithEndpointPath(runOpts.EndpointPath),
+		mcpServer.WithStateLess(runOpts.Stateless),
+	)
+}
+
+// Run serves the agents over streamable HTTP and blocks until ctx is done or an error occurs
+func (s *Server) Run(ctx context.Context, opts ...RunOptionFn) error {
+	runOpts := &runOption{
+		Host:         "0.0.0.0", // Default to all interfaces
+		Port:         8000,      // Default HTTP port
+		EndpointPath: "/mcp",
+	}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+
+	h := s.handler(runOpts)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.Start(net.JoinHostPort(runOpts.Host, strconv.Itoa(runOpts.Port)))
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return h.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// weatherAgent calls a weather tool, then answers with its result
+type weatherAgent struct{}
+
+func (w *weatherAgent) Name(_ context.Context) string { return "weather" }
+
+func (w *weatherAgent) Description(_ context.Context) string { return "tells the weather" }
+
+func (w *weatherAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	city := input.Messages[len(input.Messages)-1].Content
+	gen.Send(adk.EventFromMessage(&schema.Message{
+		Role:      schema.Assistant,
+		ToolCalls: []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{}`}}},
+	}, nil, schema.Assistant, ""))
+	gen.Send(adk.EventFromMessage(schema.ToolMessage("sunny", "call_1"), nil, schema.Tool, "get_weather"))
+	gen.Send(adk.EventFromMessage(schema.AssistantMessage("It is sunny in "+city, nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+// slowAgent runs until its context is canceled
+type slowAgent struct {
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func (a *slowAgent) Name(_ context.Context) string { return "slow agent" }
+
+func (a *slowAgent) Description(_ context.Context) string { return "takes forever" }
+
+func (a *slowAgent) Run(ctx context.Context, _ *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		defer gen.Close()
+		close(a.started)
+		<-ctx.Done()
+		close(a.canceled)
+	}()
+	return iter
+}
+
+// stdioConn talks JSON-RPC to a server served over pipes
+type stdioConn struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan map[string]any
+}
+
+func serveStdio(t *testing.T, s *Server) *stdioConn {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() { _ = s.serveIO(ctx, inR, outW) }()
+	t.Cleanup(func() {
+		cancel()
+		_ = inW.Close()
+		_ = outR.Close()
+	})
+
+	conn := &stdioConn{t: t, in: inW, messages: make(chan map[string]any, 100)}
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			msg := map[string]any{}
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+				conn.messages <- msg
+			}
+		}
+	}()
+
+	conn.send(map[string]any{"jsonrpc": "2.0", "id": 0, "method": "initialize", "params": map[string]any{
+		"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+		"clientInfo":      map[string]any{"name": "test", "version": "1.0.0"},
+		"capabilities":    map[string]any{},
+	}})
+	conn.response(0, nil)
+	conn.send(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	return conn
+}
+
+func (c *stdioConn) send(msg map[string]any) {
+	b, err := json.Marshal(msg)
+	assert.NoError(c.t, err)
+	_, err = c.in.Write(append(b, '\n'))
+	assert.NoError(c.t, err)
+}
+
+// response waits for the response of a request, collecting the notifications received meanwhile
+func (c *stdioConn) response(id int, notifications *[]map[string]any) map[string]any {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-c.messages:
+			if _, ok := msg["method"]; ok {
+				if notifications != nil {
+					*notifications = append(*notifications, msg)
+				}
+				continue
+			}
+			if msg["id"] == float64(id) {
+				result, _ := msg["result"].(map[string]any)
+				return result
+			}
+		case <-timeout:
+			c.t.Fatalf("no response for request %d", id)
+			return nil
+		}
+	}
+}
+
+func toolText(result map[string]any) string {
+	content, _ := result["content"].([]any)
+	if len(content) == 0 {
+		return ""
+	}
+	text, _ := content[0].(map[string]any)["text"].(string)
+	return text
+}
+
+func TestServeStdio(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &weatherAgent{}))
+	slow := &slowAgent{started: make(chan struct{}), canceled: make(chan struct{})}
+	assert.NoError(t, s.RegisterAgent(ctx, slow))
+	assert.Error(t, s.RegisterAgent(ctx, &weatherAgent{}))
+	conn := serveStdio(t, s)
+
+	conn.send(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	tools, _ := conn.response(1, nil)["tools"].([]any)
+	if assert.Len(t, tools, 2) {
+		names := map[string]string{}
+		for _, tl := range tools {
+			m := tl.(map[string]any)
+			names[m["name"].(string)] = m["description"].(string)
+		}
+		assert.Equal(t, map[string]string{"weather": "tells the weather", "slow_agent": "takes forever"}, names)
+	}
+
+	conn.send(map[string]any{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]any{
+		"name":      "weather",
+		"arguments": map[string]any{"input": "Paris"},
+		"_meta":     map[string]any{"progressToken": "p1"},
+	}})
+	var notifications []map[string]any
+	result := conn.response(2, &notifications)
+	assert.Equal(t, "It is sunny in Paris", toolText(result))
+	assert.NotEqual(t, true, result["isError"])
+	// stdio writes the notifications apart from the responses, so some may come after the result
+	timeout := time.After(time.Second)
+	for len(notifications) < 3 {
+		select {
+		case msg := <-conn.messages:
+			notifications = append(notifications, msg)
+			continue
+		case <-timeout:
+		}
+		break
+	}
+	var progress []string
+	for _, n := range notifications {
+		if n["method"] != "notifications/progress" {
+			continue
+		}
+		params := n["params"].(map[string]any)
+		assert.Equal(t, "p1", params["progressToken"])
+		progress = append(progress, params["message"].(string))
+	}
+	assert.Equal(t, []string{
+		"weather: calling get_weather",
+		"weather: tool get_weather done",
+		"weather: It is sunny in Paris",
+	}, progress)
+
+	conn.send(map[string]any{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": map[string]any{
+		"name":      "slow_agent",
+		"arguments": map[string]any{"input": "hi"},
+	}})
+	<-slow.started
+	conn.send(map[string]any{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": map[string]any{
+		"requestId": 3, "reason": "user canceled",
+	}})
+	result = conn.response(3, nil)
+	assert.Equal(t, true, result["isError"])
+	assert.Contains(t, toolText(result), "canceled")
+	select {
+	case <-slow.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent context not canceled")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	s := New(WithServerInfo("weather-server", "0.1.0"))
+	assert.NoError(t, s.RegisterAgent(ctx, &weatherAgent{}, WithToolName("get_forecast"), WithToolDescription("forecasts")))
+	hs := httptest.NewServer(s.Handler())
+	defer hs.Close()
+
+	c, err := client.NewStreamableHttpClient(hs.URL + "/mcp")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+	assert.NoError(t, c.Start(ctx))
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+	initResult, err := c.Initialize(ctx, initReq)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "weather-server", initResult.ServerInfo.Name)
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if assert.NoError(t, err) && assert.Len(t, tools.Tools, 1) {
+		assert.Equal(t, "get_forecast", tools.Tools[0].Name)
+		assert.Equal(t, "forecasts", tools.Tools[0].Description)
+	}
+
+	callReq := mcp.CallToolRequest{}
+	callReq.Params.Name = "get_forecast"
+	callReq.Params.Arguments = map[string]any{"input": "Paris"}
+	result, err := c.CallTool(ctx, callReq)
+	if assert.NoError(t, err) && assert.Len(t, result.Content, 1) {
+		assert.Equal(t, "It is sunny in Paris", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	callReq.Params.Arguments = map[string]any{}
+	result, err = c.CallTool(ctx, callReq)
+	if assert.NoError(t, err) {
+		assert.True(t, result.IsError)
+	}
+}
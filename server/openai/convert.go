@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// toSchemaMessages converts the request messages into the agent input.
+// The "developer" role is treated as "system", as most models behind agents don't distinguish them.
+func toSchemaMessages(messages []*chatMessage) ([]*schema.Message, error) {
+	out := make([]*schema.Message, 0, len(messages))
+	for i, m := range messages {
+		if m == nil {
+			continue
+		}
+		msg, err := toSchemaMessage(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message %d: %w", i, err)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+func toSchemaMessage(m *chatMessage) (*schema.Message, error) {
+	text, parts, err := parseContent(m.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m.Role {
+	case "system", "developer":
+		return &schema.Message{Role: schema.System, Content: text, Name: m.Name}, nil
+	case "user":
+		msg := &schema.Message{Role: schema.User, Content: text, Name: m.Name}
+		if parts != nil {
+			msg.Content = ""
+			msg.UserInputMultiContent = parts
+		}
+		return msg, nil
+	case "assistant":
+		msg := &schema.Message{Role: schema.Assistant, Content: text, Name: m.Name}
+		for _, tc := range m.ToolCalls {
+			if tc == nil {
+				continue
+			}
+			msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+				ID:       tc.ID,
+				Type:     "function",
+				Function: schema.FunctionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+			})
+		}
+		return msg, nil
+	case "tool":
+		return schema.ToolMessage(text, m.ToolCallID), nil
+	}
+	return nil, fmt.Errorf("unsupported role %q", m.Role)
+}
+
+// parseContent parses a string content, or a list of content parts.
+// It returns the text of the content, and the parts if any is not a text part.
+func parseContent(raw json.RawMessage) (string, []schema.MessageInputPart, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil, nil
+	}
+
+	var parts []*contentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", nil, fmt.Errorf("content must be a string or a list of parts: %w", err)
+	}
+	var (
+		texts      []string
+		inputParts []schema.MessageInputPart
+		multimodal bool
+	)
+	for _, p := range parts {
+		if p == nil {
+			continue
+		}
+		switch p.Type {
+		case "text":
+			texts = append(texts, p.Text)
+			inputParts = append(inputParts, schema.MessageInputPart{Type: schema.ChatMessagePartTypeText, Text: p.Text})
+		case "image_url":
+			if p.ImageURL == nil || p.ImageURL.URL == "" {
+				return "", nil, fmt.Errorf("image_url part without url")
+			}
+			url := p.ImageURL.URL
+			inputParts = append(inputParts, schema.MessageInputPart{
+				Type: schema.ChatMessagePartTypeImageURL,
+				Image: &schema.MessageInputImage{
+					MessagePartCommon: schema.MessagePartCommon{URL: &url},
+					Detail:            schema.ImageURLDetail(p.ImageURL.Detail),
+				},
+			})
+			multimodal = true
+		default:
+			return "", nil, fmt.Errorf("unsupported content part type %q", p.Type)
+		}
+	}
+	if !multimodal {
+		return strings.Join(texts, "\n"), nil, nil
+	}
+	return strings.Join(texts, "\n"), inputParts, nil
+}
@@ -0,0 +1,328 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/sse"
+	"github.com/google/uuid"
+)
+
+const ownedBy = "agentkit"
+
+type handler struct {
+	agent     adk.Agent
+	model     string
+	toolCalls bool
+	created   int64
+}
+
+func (h *handler) listModels(_ context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, &modelList{Object: "list", Data: []*model{h.modelInfo()}})
+}
+
+func (h *handler) getModel(_ context.Context, c *app.RequestContext) {
+	if c.Param("model") != h.model {
+		modelNotFound(c, c.Param("model"))
+		return
+	}
+	c.JSON(http.StatusOK, h.modelInfo())
+}
+
+func (h *handler) modelInfo() *model {
+	return &model{ID: h.model, Object: "model", Created: h.created, OwnedBy: ownedBy}
+}
+
+func (h *handler) chatCompletions(ctx context.Context, c *app.RequestContext) {
+	req := &chatCompletionRequest{}
+	if err := json.Unmarshal(c.Request.Body(), req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Model != "" && req.Model != h.model {
+		modelNotFound(c, req.Model)
+		return
+	}
+	messages, err := toSchemaMessages(req.Messages)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if len(messages) == 0 {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", "messages cannot be empty")
+		return
+	}
+
+	runner := adk.NewRunner(ctx, adk.RunnerConfig{Agent: h.agent, EnableStreaming: req.Stream})
+	iter := runner.Run(ctx, messages)
+	completion := &chatCompletion{
+		ID:      "chatcmpl-" + uuid.NewString(),
+		Created: time.Now().Unix(),
+		Model:   h.model,
+	}
+
+	if req.Stream {
+		h.stream(ctx, c, iter, completion, req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+		return
+	}
+
+	acc := &chatResponse{Role: string(schema.Assistant)}
+	state := &runState{}
+	err = h.consume(iter, state, func(delta *chatResponse) error {
+		mergeDelta(acc, delta)
+		return nil
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	finishReason := state.finishReason()
+	completion.Object = "chat.completion"
+	completion.Choices = []*chatChoice{{Message: acc, FinishReason: &finishReason}}
+	completion.Usage = state.usage
+	c.JSON(http.StatusOK, completion)
+}
+
+// stream writes the agent output as chat completion chunks with SSE, ended by "data: [DONE]"
+func (h *handler) stream(_ context.Context, c *app.RequestContext, iter *adk.AsyncIterator[*adk.AgentEvent],
+	completion *chatCompletion, includeUsage bool) {
+	completion.Object = "chat.completion.chunk"
+	w := sse.NewWriter(c)
+	defer func() { _ = w.Close() }()
+
+	write := func(v any) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return w.WriteEvent("", "", b)
+	}
+	chunk := func(delta *chatResponse, finishReason *string) *chatCompletion {
+		ck := *completion
+		ck.Choices = []*chatChoice{{Delta: delta, FinishReason: finishReason}}
+		return &ck
+	}
+
+	if err := write(chunk(&chatResponse{Role: string(schema.Assistant)}, nil)); err != nil {
+		return
+	}
+	state := &runState{}
+	err := h.consume(iter, state, func(delta *chatResponse) error {
+		return write(chunk(delta, nil))
+	})
+	if err != nil {
+		// the status has been sent, report the error in the stream like OpenAI does
+		_ = write(&errorResponse{Error: &apiError{Message: err.Error(), Type: "server_error"}})
+		return
+	}
+
+	finishReason := state.finishReason()
+	if err = write(chunk(&chatResponse{}, &finishReason)); err != nil {
+		return
+	}
+	if includeUsage {
+		ck := *completion
+		ck.Choices = []*chatChoice{}
+		ck.Usage = state.usage
+		if ck.Usage == nil {
+			ck.Usage = &usage{}
+		}
+		if err = write(&ck); err != nil {
+			return
+		}
+	}
+	_ = w.WriteEvent("", "", []byte("[DONE]"))
+}
+
+// runState tracks the agent run across its messages
+type runState struct {
+	lastFinishReason string
+	usage            *usage
+	toolCallOffset   int // Index of the first tool call of the current message in the whole response
+	hasContent       bool // Whether a previous message had content, the next one is separated from it
+}
+
+// messageSeparator separates the contents of the successive assistant messages of a multi-step run
+const messageSeparator = "\n\n"
+
+func (s *runState) finishReason() string {
+	switch s.lastFinishReason {
+	case "length", "content_filter":
+		return s.lastFinishReason
+	}
+	// tool calls have been run by the agent, so the completion is over
+	return "stop"
+}
+
+func (s *runState) addUsage(u *schema.TokenUsage) {
+	if u == nil {
+		return
+	}
+	if s.usage == nil {
+		s.usage = &usage{}
+	}
+	s.usage.PromptTokens += u.PromptTokens
+	s.usage.CompletionTokens += u.CompletionTokens
+	s.usage.TotalTokens += u.TotalTokens
+}
+
+// consume iterates the agent events and emits the deltas of the assistant messages
+func (h *handler) consume(iter *adk.AsyncIterator[*adk.AgentEvent], state *runState, emit func(*chatResponse) error) error {
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if event.Err != nil {
+			return event.Err
+		}
+		if event.Output == nil || event.Output.MessageOutput == nil {
+			continue
+		}
+
+		mo := event.Output.MessageOutput
+		if mo.Role == schema.Tool {
+			if mo.IsStreaming && mo.MessageStream != nil {
+				mo.MessageStream.Close()
+			}
+			continue
+		}
+		if err := h.consumeMessage(mo, state, emit); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *handler) consumeMessage(mo *adk.MessageVariant, state *runState, emit func(*chatResponse) error) error {
+	toolCalls := 0
+	messageHasContent := false
+	var usage *schema.TokenUsage
+	handle := func(msg *schema.Message) error {
+		if msg == nil {
+			return nil
+		}
+		if msg.ResponseMeta != nil {
+			if msg.ResponseMeta.FinishReason != "" {
+				state.lastFinishReason = msg.ResponseMeta.FinishReason
+			}
+			if msg.ResponseMeta.Usage != nil {
+				usage = msg.ResponseMeta.Usage
+			}
+		}
+		delta, n := h.toDelta(msg, state.toolCallOffset)
+		if n > toolCalls {
+			toolCalls = n
+		}
+		if delta == nil {
+			return nil
+		}
+		if delta.Content != "" {
+			if state.hasContent && !messageHasContent {
+				delta.Content = messageSeparator + delta.Content
+			}
+			state.hasContent, messageHasContent = true, true
+		}
+		return emit(delta)
+	}
+
+	if !mo.IsStreaming {
+		if err := handle(mo.Message); err != nil {
+			return err
+		}
+	} else if mo.MessageStream != nil {
+		defer mo.MessageStream.Close()
+		for {
+			chunk, err := mo.MessageStream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err = handle(chunk); err != nil {
+				return err
+			}
+		}
+	}
+
+	state.toolCallOffset += toolCalls
+	state.addUsage(usage)
+	return nil
+}
+
+// toDelta converts a message or message chunk into a delta, returning nil if it carries nothing to send.
+// It also returns the number of tool calls of the message seen so far, used to index the tool calls of later messages.
+func (h *handler) toDelta(msg *schema.Message, toolCallOffset int) (*chatResponse, int) {
+	delta := &chatResponse{Content: msg.Content, ReasoningContent: msg.ReasoningContent}
+	n := 0
+	for i, tc := range msg.ToolCalls {
+		index := i
+		if tc.Index != nil {
+			index = *tc.Index
+		}
+		if index+1 > n {
+			n = index + 1
+		}
+		if !h.toolCalls {
+			continue
+		}
+		index += toolCallOffset
+		delta.ToolCalls = append(delta.ToolCalls, &toolCall{
+			Index:    &index,
+			ID:       tc.ID,
+			Type:     "function",
+			Function: functionCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments},
+		})
+	}
+	if delta.Content == "" && delta.ReasoningContent == "" && len(delta.ToolCalls) == 0 {
+		return nil, n
+	}
+	return delta, n
+}
+
+// mergeDelta accumulates a delta into the message of a non-streaming completion
+func mergeDelta(acc, delta *chatResponse) {
+	acc.Content += delta.Content
+	acc.ReasoningContent += delta.ReasoningContent
+	for _, tc := range delta.ToolCalls {
+		var existing *toolCall
+		for _, a := range acc.ToolCalls {
+			if *a.Index == *tc.Index {
+				existing = a
+				break
+			}
+		}
+		if existing == nil {
+			c := *tc
+			acc.ToolCalls = append(acc.ToolCalls, &c)
+			continue
+		}
+		if tc.ID != "" {
+			existing.ID = tc.ID
+		}
+		existing.Function.Name += tc.Function.Name
+		existing.Function.Arguments += tc.Function.Arguments
+	}
+}
+
+func modelNotFound(c *app.RequestContext, model string) {
+	code := "model_not_found"
+	c.AbortWithStatusJSON(http.StatusNotFound, &errorResponse{Error: &apiError{
+		Message: fmt.Sprintf("The model `%s` does not exist", model),
+		Type:    "invalid_request_error",
+		Code:    &code,
+	}})
+}
+
+func writeError(c *app.RequestContext, status int, typ, msg string) {
+	c.AbortWithStatusJSON(status, &errorResponse{Error: &apiError{Message: msg, Type: typ}})
+}
@@ -0,0 +1,185 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/hertz/pkg/app"
+	hertzServer "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// Server exposes a single agent with the OpenAI Chat Completions API, so that OpenAI SDKs and UIs can talk to it.
+// It serves "/v1/chat/completions" and "/v1/models", and can be run on its own or mounted next to the A2A handlers
+// of server/a2a with its WithRoutes option.
+type Server struct {
+	agent   adk.Agent          // The registered agent instance
+	opts    *agentOption       // Configuration options for the agent
+	created int64              // Creation time of the model, reported by /v1/models
+	mu      sync.RWMutex       // Mutex for thread-safe operations
+	server  *hertzServer.Hertz // The underlying HTTP server
+}
+
+// agentOption holds configuration options for the registered agent
+type agentOption struct {
+	ModelName *string // Model id of the agent, the agent name by default
+	ToolCalls bool    // Whether the tool calls made by the agent are sent to the client
+}
+
+// AgentOptionFn is a function type for configuring agent options using the functional options pattern
+type AgentOptionFn func(*agentOption)
+
+// WithModelName sets the model id the agent is served as, the agent name by default.
+// Requests naming another model are rejected with a model_not_found error.
+func WithModelName(name string) AgentOptionFn {
+	return func(o *agentOption) {
+		o.ModelName = &name
+	}
+}
+
+// WithToolCalls sets whether the tool calls made by the agent are sent to the client as tool_calls, default is true.
+// The agent has already run the tools, so the finish reason stays "stop" and the client must not run them again.
+// The tool results are not sent since the Chat Completions API has no assistant-side tool results.
+func WithToolCalls(enable bool) AgentOptionFn {
+	return func(o *agentOption) {
+		o.ToolCalls = enable
+	}
+}
+
+// runOption holds configuration options for running the server
+type runOption struct {
+	Host     string // Server host address (e.g., "0.0.0.0", "localhost")
+	Port     int    // Server port number (e.g., 8080)
+	BasePath string // Server base path
+
+	Middlewares []app.HandlerFunc
+}
+
+// RunOptionFn is a function type for configuring run options using the functional options pattern
+type RunOptionFn func(*runOption)
+
+// WithHost sets the server host address
+func WithHost(host string) RunOptionFn {
+	return func(o *runOption) {
+		o.Host = host
+	}
+}
+
+// WithPort sets the server port number
+func WithPort(port int) RunOptionFn {
+	return func(o *runOption) {
+		o.Port = port
+	}
+}
+
+// WithBasePath sets the server base path, the API is served at path.Join(BasePath, "v1/chat/completions").
+// Default is "/" meaning no prefix.
+func WithBasePath(basePath string) RunOptionFn {
+	return func(o *runOption) {
+		o.BasePath = basePath
+	}
+}
+
+// WithMiddlewares sets the middlewares of the API handlers, e.g. to check the API key of the clients
+func WithMiddlewares(middlewares ...app.HandlerFunc) RunOptionFn {
+	return func(o *runOption) {
+		o.Middlewares = middlewares
+	}
+}
+
+// New creates a new Server instance with default configuration
+func New() *Server {
+	return &Server{}
+}
+
+// RegisterAgent registers a single agent with the server
+func (s *Server) RegisterAgent(ctx context.Context, agent adk.Agent, opts ...AgentOptionFn) error {
+	if agent == nil {
+		return fmt.Errorf("agent cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.agent != nil {
+		return fmt.Errorf("agent already registered, only one agent per server is allowed")
+	}
+
+	agentOpts := &agentOption{ToolCalls: true}
+	for _, opt := range opts {
+		opt(agentOpts)
+	}
+	if agentOpts.ModelName == nil {
+		name := agent.Name(ctx)
+		agentOpts.ModelName = &name
+	}
+
+	s.agent = agent
+	s.opts = agentOpts
+	s.created = time.Now().Unix()
+
+	return nil
+}
+
+// Mount registers the API handlers on a caller-provided router, e.g. a *server.Hertz or one of its route groups.
+// Only WithMiddlewares applies, the options configuring the listener are ignored since the caller owns the server.
+func (s *Server) Mount(ctx context.Context, router route.IRouter, opts ...RunOptionFn) error {
+	runOpts := &runOption{}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+	return s.mount(ctx, router, runOpts)
+}
+
+// Run starts the server and blocks until the server stops or an error occurs
+func (s *Server) Run(ctx context.Context, opts ...RunOptionFn) error {
+	runOpts := &runOption{
+		Host:     "0.0.0.0", // Default to all interfaces
+		Port:     8000,      // Default HTTP port
+		BasePath: "/",       // Default base path
+	}
+	for _, opt := range opts {
+		opt(runOpts)
+	}
+
+	h := hertzServer.Default(
+		hertzServer.WithHostPorts(net.JoinHostPort(runOpts.Host, strconv.Itoa(runOpts.Port))),
+		hertzServer.WithBasePath(runOpts.BasePath),
+	)
+	if err := s.mount(ctx, h, runOpts); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.server = h
+	s.mu.Unlock()
+
+	return h.Run()
+}
+
+// Engine returns the Hertz engine started by Run, nil if Run has not been called
+func (s *Server) Engine() *hertzServer.Hertz {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.server
+}
+
+func (s *Server) mount(_ context.Context, router route.IRouter, runOpts *runOption) error {
+	s.mu.RLock()
+	agent, opts, created := s.agent, s.opts, s.created
+	s.mu.RUnlock()
+	if agent == nil {
+		return fmt.Errorf("no agent registered")
+	}
+
+	h := &handler{agent: agent, model: *opts.ModelName, toolCalls: opts.ToolCalls, created: created}
+	group := router.Group("/v1", runOpts.Middlewares...)
+	group.POST("/chat/completions", h.chatCompletions)
+	group.GET("/models", h.listModels)
+	group.GET("/models/:model", h.getModel)
+	return nil
+}
@@ -0,0 +1,242 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/stretchr/testify/assert"
+)
+
+// weatherAgent calls a weather tool, then answers with its result
+type weatherAgent struct{}
+
+func (w *weatherAgent) Name(_ context.Context) string { return "weather" }
+
+func (w *weatherAgent) Description(_ context.Context) string { return "tells the weather" }
+
+func (w *weatherAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	city := input.Messages[len(input.Messages)-1].Content
+	call := &schema.Message{
+		Role:         schema.Assistant,
+		ToolCalls:    []schema.ToolCall{{ID: "call_1", Type: "function", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"` + city + `"}`}}},
+		ResponseMeta: &schema.ResponseMeta{FinishReason: "tool_calls", Usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+	}
+	result := schema.ToolMessage("sunny", "call_1")
+	answers := []*schema.Message{
+		{Role: schema.Assistant, ReasoningContent: "the tool says sunny"},
+		{Role: schema.Assistant, Content: "It is sunny in "},
+		{Role: schema.Assistant, Content: city, ResponseMeta: &schema.ResponseMeta{
+			FinishReason: "stop", Usage: &schema.TokenUsage{PromptTokens: 20, CompletionTokens: 6, TotalTokens: 26}}},
+	}
+
+	if input.EnableStreaming {
+		gen.Send(adk.EventFromMessage(nil, schema.StreamReaderFromArray([]*schema.Message{call}), schema.Assistant, ""))
+		gen.Send(adk.EventFromMessage(nil, schema.StreamReaderFromArray([]*schema.Message{result}), schema.Tool, "get_weather"))
+		gen.Send(adk.EventFromMessage(nil, schema.StreamReaderFromArray(answers), schema.Assistant, ""))
+	} else {
+		answer, _ := schema.ConcatMessages(answers)
+		gen.Send(adk.EventFromMessage(call, nil, schema.Assistant, ""))
+		gen.Send(adk.EventFromMessage(result, nil, schema.Tool, "get_weather"))
+		gen.Send(adk.EventFromMessage(answer, nil, schema.Assistant, ""))
+	}
+	gen.Close()
+	return iter
+}
+
+// stepsAgent answers with one assistant message per step
+type stepsAgent struct{ steps []string }
+
+func (a *stepsAgent) Name(_ context.Context) string { return "steps" }
+
+func (a *stepsAgent) Description(_ context.Context) string { return "answers in steps" }
+
+func (a *stepsAgent) Run(_ context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	for _, step := range a.steps {
+		msg := schema.AssistantMessage(step, nil)
+		if input.EnableStreaming {
+			gen.Send(adk.EventFromMessage(nil, schema.StreamReaderFromArray([]*schema.Message{msg}), schema.Assistant, ""))
+		} else {
+			gen.Send(adk.EventFromMessage(msg, nil, schema.Assistant, ""))
+		}
+	}
+	gen.Close()
+	return iter
+}
+
+func newTestEngine(t *testing.T, opts ...AgentOptionFn) *server.Hertz {
+	t.Helper()
+	ctx := context.Background()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &weatherAgent{}, opts...))
+	h := server.New()
+	assert.NoError(t, s.Mount(ctx, h))
+	return h
+}
+
+// startServer serves the weather agent on a random local port and returns its base url
+func startServer(t *testing.T, opts ...AgentOptionFn) string {
+	t.Helper()
+	ctx := context.Background()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &weatherAgent{}, opts...))
+	h := server.New(server.WithHostPorts(addr), server.WithExitWaitTime(0))
+	assert.NoError(t, s.Mount(ctx, h))
+	go h.Spin()
+	t.Cleanup(func() { _ = h.Shutdown(ctx) })
+	assert.Eventually(t, h.IsRunning, 5*time.Second, 10*time.Millisecond)
+
+	return "http://" + addr
+}
+
+func chatRequest(t *testing.T, req map[string]any) *ut.Body {
+	t.Helper()
+	body, err := json.Marshal(req)
+	assert.NoError(t, err)
+	return &ut.Body{Body: bytes.NewReader(body), Len: len(body)}
+}
+
+func TestChatCompletions(t *testing.T) {
+	h := newTestEngine(t)
+
+	w := ut.PerformRequest(h.Engine, "POST", "/v1/chat/completions", chatRequest(t, map[string]any{
+		"model": "weather",
+		"messages": []map[string]any{
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": []map[string]any{{"type": "text", "text": "Paris"}}},
+		},
+	}), ut.Header{Key: "Content-Type", Value: "application/json"})
+	resp := w.Result()
+	assert.Equal(t, 200, resp.StatusCode())
+
+	out := &chatCompletion{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	assert.Equal(t, "chat.completion", out.Object)
+	assert.Equal(t, "weather", out.Model)
+	if assert.Len(t, out.Choices, 1) {
+		msg := out.Choices[0].Message
+		assert.Equal(t, "assistant", msg.Role)
+		assert.Equal(t, "It is sunny in Paris", msg.Content)
+		assert.Equal(t, "the tool says sunny", msg.ReasoningContent)
+		if assert.Len(t, msg.ToolCalls, 1) {
+			assert.Equal(t, "get_weather", msg.ToolCalls[0].Function.Name)
+			assert.Equal(t, `{"city":"Paris"}`, msg.ToolCalls[0].Function.Arguments)
+		}
+		assert.Equal(t, "stop", *out.Choices[0].FinishReason)
+	}
+	assert.Equal(t, &usage{PromptTokens: 30, CompletionTokens: 11, TotalTokens: 41}, out.Usage)
+
+	w = ut.PerformRequest(h.Engine, "POST", "/v1/chat/completions", chatRequest(t, map[string]any{
+		"model":    "gpt-4o",
+		"messages": []map[string]any{{"role": "user", "content": "Paris"}},
+	}))
+	assert.Equal(t, 404, w.Result().StatusCode())
+	assert.Contains(t, string(w.Result().Body()), "model_not_found")
+}
+
+func TestChatCompletionsMultiStep(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	assert.NoError(t, s.RegisterAgent(ctx, &stepsAgent{steps: []string{"Step one is done.", "Here is the result."}}))
+	h := server.New()
+	assert.NoError(t, s.Mount(ctx, h))
+
+	w := ut.PerformRequest(h.Engine, "POST", "/v1/chat/completions", chatRequest(t, map[string]any{
+		"model":    "steps",
+		"messages": []map[string]any{{"role": "user", "content": "go"}},
+	}), ut.Header{Key: "Content-Type", Value: "application/json"})
+	assert.Equal(t, 200, w.Result().StatusCode())
+	out := &chatCompletion{}
+	assert.NoError(t, json.Unmarshal(w.Result().Body(), out))
+	if assert.Len(t, out.Choices, 1) {
+		assert.Equal(t, "Step one is done.\n\nHere is the result.", out.Choices[0].Message.Content)
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	baseURL := startServer(t, WithToolCalls(false))
+
+	body, err := json.Marshal(map[string]any{
+		"model":          "weather",
+		"stream":         true,
+		"stream_options": map[string]any{"include_usage": true},
+		"messages":       []map[string]any{{"role": "user", "content": "Paris"}},
+	})
+	assert.NoError(t, err)
+	resp, err := http.Post(baseURL+"/v1/chat/completions", "application/json", bytes.NewReader(body))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	var (
+		chunks  []*chatCompletion
+		content strings.Builder
+		done    bool
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		chunk := &chatCompletion{}
+		assert.NoError(t, json.Unmarshal([]byte(data), chunk))
+		assert.Equal(t, "chat.completion.chunk", chunk.Object)
+		chunks = append(chunks, chunk)
+		for _, c := range chunk.Choices {
+			content.WriteString(c.Delta.Content)
+			assert.Empty(t, c.Delta.ToolCalls)
+		}
+	}
+	assert.True(t, done)
+	assert.Equal(t, "It is sunny in Paris", content.String())
+	if assert.GreaterOrEqual(t, len(chunks), 3) {
+		assert.Equal(t, "assistant", chunks[0].Choices[0].Delta.Role)
+		finish := chunks[len(chunks)-2].Choices[0].FinishReason
+		if assert.NotNil(t, finish) {
+			assert.Equal(t, "stop", *finish)
+		}
+		last := chunks[len(chunks)-1]
+		assert.Empty(t, last.Choices)
+		assert.Equal(t, &usage{PromptTokens: 30, CompletionTokens: 11, TotalTokens: 41}, last.Usage)
+	}
+}
+
+func TestModels(t *testing.T) {
+	h := newTestEngine(t, WithModelName("weather-agent"))
+
+	resp := ut.PerformRequest(h.Engine, "GET", "/v1/models", nil).Result()
+	out := &modelList{}
+	assert.NoError(t, json.Unmarshal(resp.Body(), out))
+	if assert.Len(t, out.Data, 1) {
+		assert.Equal(t, "weather-agent", out.Data[0].ID)
+	}
+
+	assert.Equal(t, 200, ut.PerformRequest(h.Engine, "GET", "/v1/models/weather-agent", nil).Result().StatusCode())
+	assert.Equal(t, 404, ut.PerformRequest(h.Engine, "GET", "/v1/models/weather", nil).Result().StatusCode())
+}